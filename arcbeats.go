@@ -0,0 +1,123 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"strings"
+)
+
+// SceneBeats holds the characters with an arc beat in a scene
+type SceneBeats struct {
+	// Heading is the text of the scene heading
+	Heading string `json:"heading" yaml:"heading"`
+	// Paragraph is the scene heading paragraph in Content
+	Paragraph *Paragraph `json:"-" yaml:"-"`
+	// Characters holds the names of the characters carrying the scene
+	Characters []string `json:"characters,omitempty" yaml:"characters,omitempty"`
+}
+
+// ArcBeats returns the names of the characters with an arc beat in
+// the paragraph's scene properties
+func (paragraph *Paragraph) ArcBeats() []string {
+	names := []string{}
+	if paragraph == nil {
+		return names
+	}
+	for _, props := range paragraph.SceneProperties {
+		if props.SceneArcBeats == nil {
+			continue
+		}
+		for _, beat := range props.SceneArcBeats.CharacterArcBeat {
+			names = append(names, beat.Name)
+		}
+	}
+	return names
+}
+
+// AddArcBeat adds a character arc beat to the paragraph's scene
+// properties. Names are compared ignoring case, a character is
+// only added once.
+func (paragraph *Paragraph) AddArcBeat(name string) {
+	for _, existing := range paragraph.ArcBeats() {
+		if strings.EqualFold(existing, name) {
+			return
+		}
+	}
+	if len(paragraph.SceneProperties) == 0 {
+		paragraph.SceneProperties = append(paragraph.SceneProperties, new(SceneProperties))
+	}
+	props := paragraph.SceneProperties[0]
+	if props.SceneArcBeats == nil {
+		props.SceneArcBeats = new(SceneArcBeats)
+	}
+	beat := new(CharacterArcBeat)
+	beat.Name = name
+	props.SceneArcBeats.CharacterArcBeat = append(props.SceneArcBeats.CharacterArcBeat, beat)
+}
+
+// RemoveArcBeat removes a character's arc beat from the paragraph's
+// scene properties, it returns true if one was removed.
+func (paragraph *Paragraph) RemoveArcBeat(name string) bool {
+	removed := false
+	for _, props := range paragraph.SceneProperties {
+		if props.SceneArcBeats == nil {
+			continue
+		}
+		beats := []*CharacterArcBeat{}
+		for _, beat := range props.SceneArcBeats.CharacterArcBeat {
+			if strings.EqualFold(beat.Name, name) {
+				removed = true
+			} else {
+				beats = append(beats, beat)
+			}
+		}
+		props.SceneArcBeats.CharacterArcBeat = beats
+	}
+	return removed
+}
+
+// ArcBeats returns the character arc beats for each scene heading
+// in the document's Content.
+func (document *FinalDraft) ArcBeats() []*SceneBeats {
+	scenes := []*SceneBeats{}
+	if document == nil || document.Content == nil {
+		return scenes
+	}
+	for _, paragraph := range document.Content.Paragraph {
+		if paragraph.Type == SceneHeadingType {
+			scene := new(SceneBeats)
+			scene.Heading = paragraph.PlainText()
+			scene.Paragraph = paragraph
+			scene.Characters = paragraph.ArcBeats()
+			scenes = append(scenes, scene)
+		}
+	}
+	return scenes
+}
@@ -0,0 +1,308 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strings"
+
+	// My Packages
+	"github.com/rsdoiel/fountain"
+)
+
+var (
+	// celtxTypes maps the classes of paragraphs in a Celtx script to
+	// paragraph types, other classes are read as General.
+	celtxTypes = map[string]string{
+		"sceneheading":  SceneHeadingType,
+		"action":        ActionType,
+		"character":     CharacterType,
+		"dialog":        DialogueType,
+		"dialogue":      DialogueType,
+		"parenthetical": ParentheticalType,
+		"transition":    TransitionType,
+		"shot":          ShotType,
+		"act":           ActBreakType,
+	}
+
+	// celtxStyles maps inline HTML elements to Text styles
+	celtxStyles = map[string]string{
+		"b":      BoldStyle,
+		"strong": BoldStyle,
+		"i":      ItalicStyle,
+		"em":     ItalicStyle,
+		"u":      UnderlineStyle,
+		"s":      Strikethrough,
+		"strike": Strikethrough,
+		"del":    Strikethrough,
+	}
+
+	reSpaces = regexp.MustCompile(`[ \t\r\n]+`)
+)
+
+// rdfNode is an element of Celtx's project.rdf
+type rdfNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []*rdfNode `xml:",any"`
+	Text     string     `xml:",chardata"`
+}
+
+// value returns the attribute, child element text or child element
+// resource with the given local name.
+func (node *rdfNode) value(name string) string {
+	for _, attr := range node.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	for _, child := range node.Children {
+		if child.XMLName.Local != name {
+			continue
+		}
+		if s := strings.TrimSpace(child.Text); s != "" {
+			return s
+		}
+		for _, attr := range child.Attrs {
+			if attr.Name.Local == "resource" {
+				return attr.Value
+			}
+		}
+	}
+	return ""
+}
+
+// walk calls fn for the node and all its descendants
+func (node *rdfNode) walk(fn func(*rdfNode)) {
+	fn(node)
+	for _, child := range node.Children {
+		child.walk(fn)
+	}
+}
+
+// celtxScript finds the script in a Celtx project.rdf returning the
+// script's file name and its RDF node, or an empty name if none is
+// found.
+func celtxScript(src []byte) (string, *rdfNode) {
+	root := new(rdfNode)
+	if err := xml.Unmarshal(src, root); err != nil {
+		return "", nil
+	}
+	var (
+		name   string
+		script *rdfNode
+	)
+	root.walk(func(node *rdfNode) {
+		if script == nil && strings.HasSuffix(node.value("doctype"), "ScriptDocument") && node.value("localFile") != "" {
+			name, script = node.value("localFile"), node
+		}
+	})
+	return name, script
+}
+
+// celtxTitlePage builds a title page from the Dublin Core fields of a
+// Celtx script's RDF node. A title alone is the name of the script in
+// the project so a title page is only made if there is also an author,
+// copyright, source or contact.
+func celtxTitlePage(node *rdfNode) *TitlePage {
+	if node == nil {
+		return nil
+	}
+	fields := []*fountain.Element{}
+	for _, field := range []struct{ key, name string }{
+		{"Title", "title"},
+		{"Credit", "byline"},
+		{"Author", "creator"},
+		{"Source", "source"},
+		{"Copyright", "rights"},
+		{"Contact", "contact"},
+	} {
+		if s := node.value(field.name); s != "" {
+			fields = append(fields, &fountain.Element{Name: field.key, Content: s})
+		}
+	}
+	if len(fields) == 0 || (len(fields) == 1 && fields[0].Name == "Title") {
+		return nil
+	}
+	tp := new(TitlePage)
+	tp.Content = new(Content)
+	tp.Content.Paragraph = titlePageLayout(fields)
+	return tp
+}
+
+// addRun appends text with a style to runs, joining it to the last
+// run when the style is the same.
+func addRun(runs []*Text, s string, style string) []*Text {
+	if s == "" {
+		return runs
+	}
+	if len(runs) > 0 && runs[len(runs)-1].Style == style {
+		runs[len(runs)-1].InnerText += s
+		return runs
+	}
+	return append(runs, &Text{Style: style, InnerText: s})
+}
+
+// trimRuns trims the whitespace around a paragraph's lines
+func trimRuns(runs []*Text) []*Text {
+	for _, run := range runs {
+		run.InnerText = strings.ReplaceAll(strings.ReplaceAll(run.InnerText, " \n", "\n"), "\n ", "\n")
+	}
+	if len(runs) > 0 {
+		runs[0].InnerText = strings.TrimLeft(runs[0].InnerText, " ")
+		runs[len(runs)-1].InnerText = strings.TrimRight(runs[len(runs)-1].InnerText, " ")
+	}
+	trimmed := []*Text{}
+	for _, run := range runs {
+		if run.InnerText != "" {
+			trimmed = append(trimmed, run)
+		}
+	}
+	return trimmed
+}
+
+// celtxParagraphs reads the paragraphs of a Celtx script (HTML). Each
+// "p" element's class is its type, "b", "i" and "u" elements style the
+// text and "br" breaks a line.
+func celtxParagraphs(src []byte) ([]*Paragraph, error) {
+	d := xml.NewDecoder(bytes.NewReader(src))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+	paragraphs := []*Paragraph{}
+	var (
+		paragraph *Paragraph
+		styles    []string
+	)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "p":
+				paragraph = new(Paragraph)
+				paragraph.Type = GeneralType
+				for _, attr := range t.Attr {
+					if strings.ToLower(attr.Name.Local) != "class" {
+						continue
+					}
+					for _, class := range strings.Fields(strings.ToLower(attr.Value)) {
+						if elementType, ok := celtxTypes[class]; ok {
+							paragraph.Type = elementType
+							break
+						}
+					}
+				}
+				styles = []string{}
+			case name == "br" && paragraph != nil:
+				paragraph.Text = addRun(paragraph.Text, "\n", strings.Join(styles, "+"))
+			case celtxStyles[name] != "" && paragraph != nil:
+				styles = append(styles, celtxStyles[name])
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case name == "p" && paragraph != nil:
+				paragraph.Text = trimRuns(paragraph.Text)
+				paragraphs = append(paragraphs, paragraph)
+				paragraph = nil
+			case celtxStyles[name] != "" && len(styles) > 0:
+				styles = styles[0 : len(styles)-1]
+			}
+		case xml.CharData:
+			if paragraph != nil {
+				paragraph.Text = addRun(paragraph.Text, reSpaces.ReplaceAllString(string(t), " "), strings.Join(styles, "+"))
+			}
+		}
+	}
+	return paragraphs, nil
+}
+
+// ParseCeltx takes []byte holding a Celtx (.celtx) file and returns a
+// FinalDraft struct and error. A .celtx file is a zip archive holding
+// a project.rdf, naming the project's documents, and each document as
+// a file. The screenplay is an HTML file (e.g. script-xxxx.html) whose
+// paragraphs are typed by their class (e.g. "sceneheading", "dialog").
+func ParseCeltx(src []byte) (*FinalDraft, error) {
+	files, names, err := unzip(src)
+	if err != nil {
+		return nil, err
+	}
+	scriptName, script := "", (*rdfNode)(nil)
+	if rdf, ok := files["project.rdf"]; ok {
+		scriptName, script = celtxScript(rdf)
+	}
+	if _, ok := files[scriptName]; !ok {
+		// Fallback to the first script in the archive
+		scriptName = ""
+		for _, name := range names {
+			base := strings.ToLower(path.Base(name))
+			if strings.HasPrefix(base, "script") && strings.HasSuffix(base, ".html") {
+				scriptName = name
+				break
+			}
+		}
+	}
+	if scriptName == "" {
+		return nil, fmt.Errorf("no script found in Celtx file")
+	}
+	paragraphs, err := celtxParagraphs(files[scriptName])
+	if err != nil {
+		return nil, fmt.Errorf("%s, %s", scriptName, err)
+	}
+	document := NewFinalDraft()
+	document.Content = new(Content)
+	document.Content.Paragraph = paragraphs
+	document.TitlePage = celtxTitlePage(script)
+	return document, nil
+}
+
+// ParseCeltxFile takes a filename of a Celtx file and returns a
+// FinalDraft struct and error
+func ParseCeltxFile(fname string) (*FinalDraft, error) {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCeltx(src)
+}
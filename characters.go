@@ -0,0 +1,225 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// reExtensionText matches the text of a character extension
+	// (e.g. "V.O." in "JOHN (V.O.)")
+	reExtensionText = regexp.MustCompile(`\(([^)]*)\)`)
+)
+
+// CharacterStats holds the dialogue statistics of a character
+type CharacterStats struct {
+	// Name of the character without extensions (e.g. "JOHN")
+	Name string `json:"name" yaml:"name"`
+	// Speeches is the number of times the character speaks
+	Speeches int `json:"speeches" yaml:"speeches"`
+	// Words is the number of words of dialogue
+	Words int `json:"words" yaml:"words"`
+	// Lines is the number of lines of dialogue as laid out on the page
+	Lines int `json:"lines" yaml:"lines"`
+	// Scenes is the number of scenes the character speaks in
+	Scenes int `json:"scenes" yaml:"scenes"`
+	// FirstPage and LastPage are the pages of the character's first
+	// and last speeches
+	FirstPage int `json:"first_page" yaml:"first_page"`
+	LastPage  int `json:"last_page" yaml:"last_page"`
+	// Extensions counts the speeches using each extension (e.g. "V.O.")
+	Extensions map[string]int `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+}
+
+// extensionKey returns an extension's letters for comparing extensions
+// (e.g. "V.O." and "VO" match).
+func extensionKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, s)
+}
+
+// extensions returns the spelling of the extensions listed in
+// SmartType and of the dialogue top marker (e.g. "CONT'D") by their
+// extensionKey.
+func (document *FinalDraft) extensions() map[string]string {
+	known := map[string]string{}
+	add := func(s string) {
+		s = strings.ToUpper(strings.Trim(strings.TrimSpace(s), "()"))
+		if key := extensionKey(s); key != "" {
+			known[key] = s
+		}
+	}
+	dialogue, _ := document.breakSettings()
+	add(dialogue.DialogueTop)
+	if document.SmartType != nil && document.SmartType.Extensions != nil {
+		for _, extension := range document.SmartType.Extensions.Extension {
+			add(extension.InnerText)
+		}
+	}
+	return known
+}
+
+// CharacterStats returns the dialogue statistics of each character
+// speaking in the script, in the order they first speak. Names are
+// compared without their extensions (e.g. "JOHN (V.O.)" is "JOHN"),
+// extensions are counted using the spelling in SmartType when listed.
+// Words and Lines count dialogue, including sung lines, not
+// parentheticals.
+func (document *FinalDraft) CharacterStats() []*CharacterStats {
+	list := []*CharacterStats{}
+	if document == nil || document.Content == nil {
+		return list
+	}
+	known := document.extensions()
+	byName := map[string]*CharacterStats{}
+
+	// Find the page and dialogue lines of each paragraph
+	pages := document.Pages()
+	pageOf, dialogueLines := map[int]int{}, map[int]int{}
+	for _, page := range pages {
+		for _, line := range page.Lines {
+			if _, ok := pageOf[line.Paragraph]; !ok {
+				pageOf[line.Paragraph] = page.Number
+			}
+			if line.Type == DialogueType || line.Type == SingingType {
+				dialogueLines[line.Paragraph]++
+			}
+		}
+	}
+
+	// speak adds a speech starting with a character cue
+	speak := func(cue string, page int) *CharacterStats {
+		name := speakerName(cue)
+		if name == "" {
+			return nil
+		}
+		stats, ok := byName[name]
+		if !ok {
+			stats = &CharacterStats{Name: name, FirstPage: page, Extensions: map[string]int{}}
+			byName[name] = stats
+			list = append(list, stats)
+		}
+		stats.Speeches++
+		stats.LastPage = page
+		for _, m := range reExtensionText.FindAllStringSubmatch(strings.ToUpper(cue), -1) {
+			extension := strings.TrimSpace(m[1])
+			if s, ok := known[extensionKey(extension)]; ok {
+				extension = s
+			}
+			if extension != "" {
+				stats.Extensions[extension]++
+			}
+		}
+		return stats
+	}
+
+	var speaker *CharacterStats
+	for i, paragraph := range document.Content.Paragraph {
+		if paragraph.DualDialogue != nil {
+			speaker = nil
+			columns := document.dualDialogueColumns(paragraph.DualDialogue, i)
+			for j, speech := range paragraph.DualDialogue.Speeches() {
+				var stats *CharacterStats
+				for _, p := range speech {
+					switch {
+					case p.Type == CharacterType:
+						stats = speak(p.PlainText(), pageOf[i])
+					case (p.Type == DialogueType || p.Type == SingingType) && stats != nil:
+						stats.Words += countWords(p.PlainText())
+					}
+				}
+				if stats != nil && j < len(columns) {
+					for _, line := range columns[j] {
+						if line.Type == DialogueType || line.Type == SingingType {
+							stats.Lines++
+						}
+					}
+				}
+			}
+			continue
+		}
+		switch paragraph.Type {
+		case CharacterType:
+			speaker = speak(paragraph.PlainText(), pageOf[i])
+		case DialogueType, SingingType:
+			if speaker != nil {
+				speaker.Words += countWords(paragraph.PlainText())
+				speaker.Lines += dialogueLines[i]
+			}
+		case ParentheticalType:
+		default:
+			speaker = nil
+		}
+	}
+	for _, scene := range document.scenes(pages) {
+		for _, name := range scene.Characters {
+			if stats, ok := byName[name]; ok {
+				stats.Scenes++
+			}
+		}
+	}
+	return list
+}
+
+// WriteCharacterReport writes the character statistics as a table,
+// CSV or JSON (see TableFormat, CSVFormat and JSONFormat).
+func (document *FinalDraft) WriteCharacterReport(w io.Writer, format string) error {
+	list := document.CharacterStats()
+	header := []string{"Character", "Speeches", "Words", "Lines", "Scenes", "First Page", "Last Page", "Extensions"}
+	rows := [][]string{}
+	for _, stats := range list {
+		extensions := []string{}
+		for extension, count := range stats.Extensions {
+			extensions = append(extensions, extension+" "+strconv.Itoa(count))
+		}
+		sort.Strings(extensions)
+		rows = append(rows, []string{
+			stats.Name,
+			strconv.Itoa(stats.Speeches),
+			strconv.Itoa(stats.Words),
+			strconv.Itoa(stats.Lines),
+			strconv.Itoa(stats.Scenes),
+			strconv.Itoa(stats.FirstPage),
+			strconv.Itoa(stats.LastPage),
+			strings.Join(extensions, ", "),
+		})
+	}
+	return writeReport(w, format, header, rows, list)
+}
@@ -0,0 +1,183 @@
+// fdx2html converts a fdx file into HTML following the Scrippets convention.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	// My packages
+	"github.com/rsdoiel/fdx"
+)
+
+var (
+	helpText = `%{app_name}(1) | version {version} {release_hash}
+% R. S. Doiel
+% {release_date} 
+
+# NAME
+
+{app_name}
+
+# SYNOPSIS
+
+{app_name} [OPTIONS]
+
+# DESCRIPTION
+
+{app_name} is a command line program that reads an fdx file
+and writes HTML following the Scrippets convention, a paragraph
+per screenplay element with a class for its type (e.g. "sceneheader",
+"action", "character", "dialogue"). Scene headings get ids (e.g.
+"scene-1") so they can be linked to.
+
+# OPTIONS
+
+-help
+: display help
+
+-license
+: display license
+
+-version
+: display version
+
+
+-i
+: read input from file
+
+-o
+: write output to file
+
+-lenient
+: repair common corruptions (bad entities, invalid UTF-8, truncated
+files) writing a warning for each repair
+
+-css
+: embed the Scrippets CSS in a style element
+
+-fragment
+: only write the scrippet div, suitable for embedding in another page
+
+-notes
+: include script notes
+
+# EXAMPLES
+
+Convert *screenplay.fdx* into *screenplay.html*.
+
+~~~
+    {app_name} -i screenplay.fdx -o screenplay.html
+~~~
+
+Or alternatively
+
+~~~
+    cat screenplay.fdx | fdx2html > screenplay.html
+~~~
+
+`
+
+	// Standard Options
+	showHelp    bool
+	showLicense bool
+	showVersion bool
+	quiet       bool
+	lenient     bool
+	embedCSS    bool
+	fragment    bool
+	showNotes   bool
+	inputFName  string
+	outputFName string
+)
+
+func main() {
+	appName := path.Base(os.Args[0])
+	version := fdx.Version
+	// NOTE: This is the date that version.go was generated.
+	releaseDate := fdx.ReleaseDate
+	releaseHash := fdx.ReleaseHash
+	fmtHelp := fdx.FmtHelp
+
+	// Standard Options
+	flag.BoolVar(&showHelp, "help", false, "display help")
+	flag.BoolVar(&showLicense, "license", false, "display license")
+	flag.BoolVar(&showVersion, "version", false, "display version")
+	flag.BoolVar(&quiet, "quiet", false, "suppress error messages")
+	flag.BoolVar(&lenient, "lenient", false, "repair common corruptions writing warnings")
+	flag.BoolVar(&embedCSS, "css", false, "embed the Scrippets CSS")
+	flag.BoolVar(&fragment, "fragment", false, "only write the scrippet div")
+	flag.BoolVar(&showNotes, "notes", false, "include script notes")
+	flag.StringVar(&inputFName, "i", "", "set the input filename")
+	flag.StringVar(&outputFName, "o", "", "set the output filename")
+
+	// Parse environment and options
+	flag.Parse()
+	//args := flag.Args()
+
+	// Setup IO
+	var err error
+	in := os.Stdin
+	out := os.Stdout
+	eout := os.Stderr
+
+	if inputFName != "" {
+		in, err = os.Open(inputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer in.Close()
+	}
+	if outputFName != "" {
+		out, err = os.Create(outputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	// Process options
+	if showHelp {
+		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
+		os.Exit(0)
+	}
+	if showLicense {
+		fmt.Fprintf(out, "%s\n", fdx.LicenseText)
+		os.Exit(0)
+	}
+	if showVersion {
+		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
+		os.Exit(0)
+	}
+
+	// ReadAll of input
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+
+	// Parse input
+	var warn io.Writer
+	if !quiet {
+		warn = eout
+	}
+	screenplay, err := fdx.ParseWithWarnings(src, lenient, warn)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+
+	fdx.ShowNotes = showNotes
+
+	// and then render as HTML
+	if err := screenplay.ToHTML(out, fragment, embedCSS); err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+}
@@ -2,8 +2,10 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -48,9 +50,20 @@ and returns plain text
 -o
 : write output to file
 
+-lenient
+: repair common corruptions (bad entities, invalid UTF-8, truncated
+files) writing a warning for each repair
+
 -newline
 : add a trailing newline 
 
+-notes
+: include script notes as Fountain notes (e.g. "[[a note]]")
+
+-fountain
+: write spec-valid Fountain, adding forced markers (e.g. ".", "!", "@", ">")
+where an element would otherwise be read as a different type
+
 # EXAMPLES
 
 Convert *screenplay.fdx* into *screenplay.txt*.
@@ -73,17 +86,15 @@ Or alternatively
 	showVersion bool
 	newLine     bool
 	quiet       bool
+	lenient     bool
+	showNotes   bool
+	asFountain  bool
 	inputFName  string
 	outputFName string
 )
 
 func main() {
 	appName := path.Base(os.Args[0])
-	version := fdx.Version
-	// NOTE: This is the date that version.go was generated.
-	releaseDate := fdx.ReleaseDate
-	releaseHash := fdx.ReleaseHash
-	fmtHelp := fdx.FmtHelp
 
 	// Standard Options
 	flag.BoolVar(&showHelp, "help", false, "display help")
@@ -91,6 +102,9 @@ func main() {
 	flag.BoolVar(&showVersion, "version", false, "display version")
 	flag.BoolVar(&newLine, "newline", false, "add a trailing newline")
 	flag.BoolVar(&quiet, "quiet", false, "suppress error messages")
+	flag.BoolVar(&lenient, "lenient", false, "repair common corruptions writing warnings")
+	flag.BoolVar(&showNotes, "notes", false, "include script notes as Fountain notes")
+	flag.BoolVar(&asFountain, "fountain", false, "write spec-valid Fountain with forced markers")
 	flag.StringVar(&inputFName, "i", "", "set the input filename")
 	flag.StringVar(&outputFName, "o", "", "set the output filename")
 
@@ -98,6 +112,21 @@ func main() {
 	flag.Parse()
 	//args := flag.Args()
 
+	if err := run(appName); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run converts the input, returning rather than exiting on errors so
+// open files are closed and temporary files removed.
+func run(appName string) error {
+	version := fdx.Version
+	// NOTE: This is the date that version.go was generated.
+	releaseDate := fdx.ReleaseDate
+	releaseHash := fdx.ReleaseHash
+	fmtHelp := fdx.FmtHelp
+
 	// Setup IO
 	var err error
 	in := os.Stdin
@@ -107,16 +136,14 @@ func main() {
 	if inputFName != "" {
 		in, err = os.Open(inputFName)
 		if err != nil {
-			fmt.Fprintf(eout, "%s\n", err)
-			os.Exit(1)
+			return err
 		}
 		defer in.Close()
 	}
 	if outputFName != "" {
 		out, err = os.Create(outputFName)
 		if err != nil {
-			fmt.Fprintf(eout, "%s\n", err)
-			os.Exit(1)
+			return err
 		}
 		defer out.Close()
 	}
@@ -124,35 +151,90 @@ func main() {
 	// Process options
 	if showHelp {
 		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
-		os.Exit(0)
+		return nil
 	}
 	if showLicense {
 		fmt.Fprintf(out, "%s\n", fdx.LicenseText)
-		os.Exit(0)
+		return nil
 	}
 	if showVersion {
 		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
-		os.Exit(0)
+		return nil
 	}
 
-	// ReadAll of input
-	src, err := ioutil.ReadAll(in)
-	if err != nil {
-		fmt.Fprintf(eout, "%s\n", err)
-		os.Exit(1)
+	fdx.ShowNotes = showNotes
+
+	// Repairs need the whole file
+	if lenient {
+		src, err := ioutil.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		var warn io.Writer
+		if !quiet {
+			warn = eout
+		}
+		screenplay, err := fdx.ParseWithWarnings(src, true, warn)
+		if err != nil {
+			return err
+		}
+		if asFountain {
+			err = screenplay.ToFountain(out)
+		} else {
+			_, err = fmt.Fprintf(out, "%s", screenplay.String())
+		}
+		if err != nil {
+			return err
+		}
+		if newLine {
+			fmt.Fprintln(out, "")
+		}
+		return nil
 	}
 
-	// Parse input
-	screenplay, err := fdx.Parse(src)
-	if err != nil {
-		fmt.Fprintf(eout, "%s\n", err)
-		os.Exit(1)
+	// The title page and scene number options follow Content in an
+	// .fdx file. A first pass reads them skipping the paragraphs, the
+	// second decodes and writes each paragraph. Input that can't be
+	// rewound (e.g. a pipe) is copied to a temporary file first.
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		tmp, err := os.CreateTemp("", appName)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if _, err := io.Copy(tmp, in); err != nil {
+			return err
+		}
+		in = tmp
+		if _, err := in.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	dec := fdx.NewDecoder(bufio.NewReader(in))
+	if err := dec.Skip(); err != nil {
+		return err
+	}
+	settings := dec.Document()
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return err
 	}
 
 	//and then render as a string
+	tw := fdx.NewTextWriter(out, settings, asFountain)
+	for paragraph, err := range fdx.NewDecoder(bufio.NewReader(in)).Paragraphs() {
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteParagraph(paragraph); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
 	if newLine {
-		fmt.Fprintf(out, "%s\n", screenplay.String())
-	} else {
-		fmt.Fprintf(out, "%s", screenplay.String())
+		fmt.Fprintln(out, "")
 	}
+	return nil
 }
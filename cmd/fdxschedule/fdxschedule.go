@@ -0,0 +1,237 @@
+// fdxschedule writes a stripboard or day out of days for a fdx file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+
+	// My packages
+	"github.com/rsdoiel/fdx"
+)
+
+var (
+	helpText = `%{app_name}(1) | version {version} {release_hash}
+% R. S. Doiel
+% {release_date} 
+
+# NAME
+
+{app_name}
+
+# SYNOPSIS
+
+{app_name} [OPTIONS]
+
+# DESCRIPTION
+
+{app_name} is a command line program that reads an fdx file,
+groups its scenes into shoot days and writes the stripboard, a strip
+for each scene with its cast IDs and a banner ending each day. With
+-dood the day out of days, when each cast member starts work (SW),
+works (W), is held (H) and finishes (WF), is written instead.
+
+Scenes are grouped by location, day/night and cast, a new day starts
+when the next scene would go over the pages per day. A YAML file given
+with -config can set the pages per day, list scenes to shoot first
+(order), keep the other scenes in script order (locked) and start a
+new day at given scenes (day_breaks).
+
+~~~
+    pages_per_day: 4 4/8
+    order: [12, 3, 4]
+    locked: false
+    day_breaks: [7, 20]
+~~~
+
+# OPTIONS
+
+-help
+: display help
+
+-license
+: display license
+
+-version
+: display version
+
+
+-i
+: read input from file
+
+-o
+: write output to file
+
+-lenient
+: repair common corruptions (bad entities, invalid UTF-8, truncated
+files) writing a warning for each repair
+
+-format
+: write the report as a "table" (the default), "csv", "json" or "html"
+
+-config
+: read the schedule options from a YAML file
+
+-pages-per-day
+: set the pages shot in a day (e.g. "4 4/8"), overrides the config
+
+-locked
+: keep the scenes in script order instead of grouping them
+
+-dood
+: write the day out of days instead of the stripboard
+
+# EXAMPLES
+
+Print the stripboard of *screenplay.fdx* shooting five pages a day.
+
+~~~
+    {app_name} -i screenplay.fdx -pages-per-day 5
+~~~
+
+Write the stripboard as HTML using the options in *schedule.yaml*.
+
+~~~
+    {app_name} -i screenplay.fdx -config schedule.yaml \
+        -format html -o stripboard.html
+~~~
+
+Write the day out of days as CSV for a spreadsheet.
+
+~~~
+    cat screenplay.fdx | fdxschedule -dood -format csv > dood.csv
+~~~
+
+`
+
+	// Standard Options
+	showHelp    bool
+	showLicense bool
+	showVersion bool
+	quiet       bool
+	lenient     bool
+	format      string
+	configFName string
+	pagesPerDay string
+	locked      bool
+	dood        bool
+	inputFName  string
+	outputFName string
+)
+
+func main() {
+	appName := path.Base(os.Args[0])
+	version := fdx.Version
+	// NOTE: This is the date that version.go was generated.
+	releaseDate := fdx.ReleaseDate
+	releaseHash := fdx.ReleaseHash
+	fmtHelp := fdx.FmtHelp
+
+	// Standard Options
+	flag.BoolVar(&showHelp, "help", false, "display help")
+	flag.BoolVar(&showLicense, "license", false, "display license")
+	flag.BoolVar(&showVersion, "version", false, "display version")
+	flag.BoolVar(&quiet, "quiet", false, "suppress error messages")
+	flag.BoolVar(&lenient, "lenient", false, "repair common corruptions writing warnings")
+	flag.StringVar(&format, "format", fdx.TableFormat, "set the report format, table, csv, json or html")
+	flag.StringVar(&configFName, "config", "", "read the schedule options from a YAML file")
+	flag.StringVar(&pagesPerDay, "pages-per-day", "", "set the pages shot in a day")
+	flag.BoolVar(&locked, "locked", false, "keep the scenes in script order")
+	flag.BoolVar(&dood, "dood", false, "write the day out of days")
+	flag.StringVar(&inputFName, "i", "", "set the input filename")
+	flag.StringVar(&outputFName, "o", "", "set the output filename")
+
+	// Parse environment and options
+	flag.Parse()
+	//args := flag.Args()
+
+	// Setup IO
+	var err error
+	in := os.Stdin
+	out := os.Stdout
+	eout := os.Stderr
+
+	if inputFName != "" {
+		in, err = os.Open(inputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer in.Close()
+	}
+	if outputFName != "" {
+		out, err = os.Create(outputFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+
+	// Process options
+	if showHelp {
+		fmt.Fprintf(out, "%s\n", fmtHelp(helpText, appName, version, releaseDate, releaseHash))
+		os.Exit(0)
+	}
+	if showLicense {
+		fmt.Fprintf(out, "%s\n", fdx.LicenseText)
+		os.Exit(0)
+	}
+	if showVersion {
+		fmt.Fprintf(out, "%s %s %s\n", appName, version, releaseHash)
+		os.Exit(0)
+	}
+
+	// ReadAll of input
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+
+	// Parse input
+	var warn io.Writer
+	if !quiet {
+		warn = eout
+	}
+	screenplay, err := fdx.ParseWithWarnings(src, lenient, warn)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+
+	// Schedule the scenes
+	options := new(fdx.ScheduleOptions)
+	if configFName != "" {
+		options, err = fdx.ParseScheduleOptionsFile(configFName)
+		if err != nil {
+			fmt.Fprintf(eout, "%s\n", err)
+			os.Exit(1)
+		}
+	}
+	if pagesPerDay != "" {
+		options.PagesPerDay = pagesPerDay
+	}
+	if locked {
+		options.Locked = true
+	}
+	schedule, err := screenplay.Schedule(options)
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+
+	// and then write the report
+	if dood {
+		err = schedule.WriteDayOutOfDays(out, format)
+	} else {
+		err = schedule.WriteStripboard(out, format)
+	}
+	if err != nil {
+		fmt.Fprintf(eout, "%s\n", err)
+		os.Exit(1)
+	}
+}
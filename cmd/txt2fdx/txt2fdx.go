@@ -136,7 +136,9 @@ func main() {
 		os.Exit(0)
 	}
 
-	// ReadAll of input
+	// ReadAll of input, Fountain can't be read a paragraph at a time
+	// (the title page, dual dialogue and boneyard comments depend on
+	// the text around them) so only the output is streamed.
 	src, err := ioutil.ReadAll(in)
 	if err != nil {
 		fmt.Fprintf(eout, "%s\n", err)
@@ -153,16 +155,11 @@ func main() {
 	// Now create our fdx document
 	document := fdx.NewFinalDraft()
 	document.FromFountain(screenplay)
-	src, err = document.ToXML()
-	if err != nil {
+	if err := fdx.NewEncoder(out).Encode(document); err != nil {
 		fmt.Fprintf(eout, "%s\n", err)
 		os.Exit(1)
 	}
-
-	//and then render as a string
 	if newLine {
-		fmt.Fprintf(out, "%s\n", src)
-	} else {
-		fmt.Fprintf(out, "%s", src)
+		fmt.Fprintln(out, "")
 	}
 }
@@ -0,0 +1,363 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// JSONSchemaURI identifies the JSON Schema draft used by JSONSchema()
+	JSONSchemaURI = "https://json-schema.org/draft/2020-12/schema"
+)
+
+// elementOrder returns order (the children of an element as read from
+// the FDX file) when it differs from the order v's fields are encoded
+// in, otherwise nil. v is a pointer to a struct. Only non-default
+// orders need to be kept in JSON and YAML to round trip the XML.
+func elementOrder(v interface{}, order []string) []string {
+	t := reflect.TypeOf(v).Elem()
+	index := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("xml"), ",")[0]; tag != "" {
+			name = tag
+		}
+		index[name] = i
+	}
+	last := -1
+	for _, name := range order {
+		i, ok := index[name]
+		if !ok {
+			// Unknown elements are encoded last
+			i = t.NumField()
+		}
+		if i < last {
+			return order
+		}
+		last = i
+	}
+	return nil
+}
+
+// MarshalJSON encodes a FinalDraft including the order of its children
+// when needed to recreate the FDX file.
+func (document *FinalDraft) MarshalJSON() ([]byte, error) {
+	type rawFinalDraft FinalDraft
+	return json.Marshal(struct {
+		*rawFinalDraft
+		Order []string `json:"order,omitempty"`
+	}{(*rawFinalDraft)(document), elementOrder(document, document.order)})
+}
+
+// UnmarshalJSON decodes a FinalDraft
+func (document *FinalDraft) UnmarshalJSON(src []byte) error {
+	type rawFinalDraft FinalDraft
+	v := struct {
+		*rawFinalDraft
+		Order []string `json:"order,omitempty"`
+	}{rawFinalDraft: (*rawFinalDraft)(document)}
+	err := json.Unmarshal(src, &v)
+	document.order = v.Order
+	return err
+}
+
+// MarshalYAML encodes a FinalDraft including the order of its children
+// when needed to recreate the FDX file.
+func (document *FinalDraft) MarshalYAML() (interface{}, error) {
+	type rawFinalDraft FinalDraft
+	return struct {
+		*rawFinalDraft `yaml:",inline"`
+		Order          []string `yaml:"order,omitempty"`
+	}{(*rawFinalDraft)(document), elementOrder(document, document.order)}, nil
+}
+
+// UnmarshalYAML decodes a FinalDraft
+func (document *FinalDraft) UnmarshalYAML(node *yaml.Node) error {
+	type rawFinalDraft FinalDraft
+	v := struct {
+		*rawFinalDraft `yaml:",inline"`
+		Order          []string `yaml:"order,omitempty"`
+	}{rawFinalDraft: (*rawFinalDraft)(document)}
+	err := node.Decode(&v)
+	document.order = v.Order
+	return err
+}
+
+// MarshalJSON encodes a TitlePage including the order of its children
+// when needed to recreate the FDX file.
+func (tp *TitlePage) MarshalJSON() ([]byte, error) {
+	type rawTitlePage TitlePage
+	return json.Marshal(struct {
+		*rawTitlePage
+		Order []string `json:"order,omitempty"`
+	}{(*rawTitlePage)(tp), elementOrder(tp, tp.order)})
+}
+
+// UnmarshalJSON decodes a TitlePage
+func (tp *TitlePage) UnmarshalJSON(src []byte) error {
+	type rawTitlePage TitlePage
+	v := struct {
+		*rawTitlePage
+		Order []string `json:"order,omitempty"`
+	}{rawTitlePage: (*rawTitlePage)(tp)}
+	err := json.Unmarshal(src, &v)
+	tp.order = v.Order
+	return err
+}
+
+// MarshalYAML encodes a TitlePage including the order of its children
+// when needed to recreate the FDX file.
+func (tp *TitlePage) MarshalYAML() (interface{}, error) {
+	type rawTitlePage TitlePage
+	return struct {
+		*rawTitlePage `yaml:",inline"`
+		Order         []string `yaml:"order,omitempty"`
+	}{(*rawTitlePage)(tp), elementOrder(tp, tp.order)}, nil
+}
+
+// UnmarshalYAML decodes a TitlePage
+func (tp *TitlePage) UnmarshalYAML(node *yaml.Node) error {
+	type rawTitlePage TitlePage
+	v := struct {
+		*rawTitlePage `yaml:",inline"`
+		Order         []string `yaml:"order,omitempty"`
+	}{rawTitlePage: (*rawTitlePage)(tp)}
+	err := node.Decode(&v)
+	tp.order = v.Order
+	return err
+}
+
+// MarshalJSON encodes a Paragraph including the order of its children
+// (e.g. DynamicLabel and Text in a header) when needed to recreate
+// the FDX file.
+func (paragraph *Paragraph) MarshalJSON() ([]byte, error) {
+	type rawParagraph Paragraph
+	return json.Marshal(struct {
+		*rawParagraph
+		Order []string `json:"order,omitempty"`
+	}{(*rawParagraph)(paragraph), elementOrder(paragraph, paragraph.order)})
+}
+
+// UnmarshalJSON decodes a Paragraph
+func (paragraph *Paragraph) UnmarshalJSON(src []byte) error {
+	type rawParagraph Paragraph
+	v := struct {
+		*rawParagraph
+		Order []string `json:"order,omitempty"`
+	}{rawParagraph: (*rawParagraph)(paragraph)}
+	err := json.Unmarshal(src, &v)
+	paragraph.order = v.Order
+	return err
+}
+
+// MarshalYAML encodes a Paragraph including the order of its children
+// when needed to recreate the FDX file.
+// NOTE: headers and footers hold Paragraph values, the YAML encoder
+// only finds a value receiver.
+func (paragraph Paragraph) MarshalYAML() (interface{}, error) {
+	type rawParagraph Paragraph
+	return struct {
+		*rawParagraph `yaml:",inline"`
+		Order         []string `yaml:"order,omitempty"`
+	}{(*rawParagraph)(&paragraph), elementOrder(&paragraph, paragraph.order)}, nil
+}
+
+// UnmarshalYAML decodes a Paragraph
+func (paragraph *Paragraph) UnmarshalYAML(node *yaml.Node) error {
+	type rawParagraph Paragraph
+	v := struct {
+		*rawParagraph `yaml:",inline"`
+		Order         []string `yaml:"order,omitempty"`
+	}{rawParagraph: (*rawParagraph)(paragraph)}
+	err := node.Decode(&v)
+	paragraph.order = v.Order
+	return err
+}
+
+// MarshalJSON encodes a PageLayout including the order of its children
+// when needed to recreate the FDX file.
+func (layout *PageLayout) MarshalJSON() ([]byte, error) {
+	type rawPageLayout PageLayout
+	return json.Marshal(struct {
+		*rawPageLayout
+		Order []string `json:"order,omitempty"`
+	}{(*rawPageLayout)(layout), elementOrder(layout, layout.order)})
+}
+
+// UnmarshalJSON decodes a PageLayout
+func (layout *PageLayout) UnmarshalJSON(src []byte) error {
+	type rawPageLayout PageLayout
+	v := struct {
+		*rawPageLayout
+		Order []string `json:"order,omitempty"`
+	}{rawPageLayout: (*rawPageLayout)(layout)}
+	err := json.Unmarshal(src, &v)
+	layout.order = v.Order
+	return err
+}
+
+// MarshalYAML encodes a PageLayout including the order of its children
+// when needed to recreate the FDX file.
+func (layout *PageLayout) MarshalYAML() (interface{}, error) {
+	type rawPageLayout PageLayout
+	return struct {
+		*rawPageLayout `yaml:",inline"`
+		Order          []string `yaml:"order,omitempty"`
+	}{(*rawPageLayout)(layout), elementOrder(layout, layout.order)}, nil
+}
+
+// UnmarshalYAML decodes a PageLayout
+func (layout *PageLayout) UnmarshalYAML(node *yaml.Node) error {
+	type rawPageLayout PageLayout
+	v := struct {
+		*rawPageLayout `yaml:",inline"`
+		Order          []string `yaml:"order,omitempty"`
+	}{rawPageLayout: (*rawPageLayout)(layout)}
+	err := node.Decode(&v)
+	layout.order = v.Order
+	return err
+}
+
+// ParseJSON takes []byte holding JSON (as written by ToJSON) and
+// returns a FinalDraft struct and error
+func ParseJSON(src []byte) (*FinalDraft, error) {
+	document := new(FinalDraft)
+	err := json.Unmarshal(src, document)
+	return document, err
+}
+
+// ToJSON takes a FinalDraft struct and renders it as JSON. The
+// format is described by JSONSchema(), ParseJSON followed by ToXML
+// recreates the FDX file.
+func (document *FinalDraft) ToJSON() ([]byte, error) {
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// ParseYAML takes []byte holding YAML (as written by ToYAML) and
+// returns a FinalDraft struct and error
+func ParseYAML(src []byte) (*FinalDraft, error) {
+	document := new(FinalDraft)
+	err := yaml.Unmarshal(src, document)
+	return document, err
+}
+
+// ToYAML takes a FinalDraft struct and renders it as YAML. It uses
+// the same keys as ToJSON, ParseYAML followed by ToXML recreates the
+// FDX file.
+func (document *FinalDraft) ToYAML() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	err := encoder.Close()
+	return buf.Bytes(), err
+}
+
+// orderedTypes are the types which may hold an "order" property
+var orderedTypes = map[string]bool{
+	"FinalDraft": true,
+	"TitlePage":  true,
+	"Paragraph":  true,
+	"PageLayout": true,
+}
+
+// schemaType returns the JSON Schema of t, struct types are added to
+// defs and referenced.
+func schemaType(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaType(t.Elem(), defs)
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaType(t.Elem(), defs)}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Struct:
+		name := t.Name()
+		ref := map[string]interface{}{"$ref": "#/$defs/" + name}
+		if _, ok := defs[name]; ok {
+			return ref
+		}
+		properties := map[string]interface{}{}
+		def := map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+		defs[name] = def
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := strings.Split(field.Tag.Get("json"), ",")[0]
+			if field.PkgPath != "" || key == "-" {
+				continue
+			}
+			if key == "" {
+				key = field.Name
+			}
+			properties[key] = schemaType(field.Type, defs)
+		}
+		if orderedTypes[name] && t.PkgPath() == reflect.TypeOf(FinalDraft{}).PkgPath() {
+			properties["order"] = map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "element names of the children in the order they appear in the FDX file, present when it differs from the default order",
+			}
+		}
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+// JSONSchema returns a JSON Schema describing the JSON written by
+// ToJSON (the YAML written by ToYAML uses the same structure).
+func JSONSchema() ([]byte, error) {
+	defs := map[string]interface{}{}
+	root := schemaType(reflect.TypeOf(FinalDraft{}), defs)
+	schema := map[string]interface{}{
+		"$schema":     JSONSchemaURI,
+		"$id":         "https://github.com/rsdoiel/fdx/fdx.schema.json",
+		"title":       "FinalDraft",
+		"description": "A Final Draft (fdx) screenplay as rendered by the fdx package's ToJSON and ToYAML",
+		"$ref":        root["$ref"],
+		"$defs":       defs,
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
@@ -0,0 +1,186 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"strings"
+)
+
+const (
+	// DualDialogueGutter is the number of spaces between the columns
+	// of dual dialogue in text output
+	DualDialogueGutter = 4
+
+	// DualDialogueMarker follows the second character name of
+	// dual dialogue in Fountain
+	DualDialogueMarker = "^"
+)
+
+// wrapText word wraps s into lines no wider than width
+func wrapText(s string, width int) []string {
+	lines := []string{}
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			switch {
+			case line == "":
+				line = word
+			case len([]rune(line))+1+len([]rune(word)) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Speeches (of DualDialogue) splits the paragraphs into speeches,
+// each starting with a Character paragraph.
+func (dd *DualDialogue) Speeches() [][]*Paragraph {
+	speeches := [][]*Paragraph{}
+	if dd == nil {
+		return speeches
+	}
+	for _, paragraph := range dd.Paragraph {
+		if paragraph.Type == CharacterType || len(speeches) == 0 {
+			speeches = append(speeches, []*Paragraph{})
+		}
+		i := len(speeches) - 1
+		speeches[i] = append(speeches[i], paragraph)
+	}
+	return speeches
+}
+
+// column renders a speech as lines no wider than width
+func column(speech []*Paragraph, width int) []string {
+	lines := []string{}
+	for _, paragraph := range speech {
+		s := strings.TrimSpace(paragraph.String())
+		if s == "" {
+			continue
+		}
+		lines = append(lines, wrapText(s, width)...)
+	}
+	return lines
+}
+
+// String (of DualDialogue) returns plain text with the speeches
+// rendered side by side in two columns.
+func (dd *DualDialogue) String() string {
+	speeches := dd.Speeches()
+	width := (MaxLineWidth - DualDialogueGutter) / 2
+	src := []string{}
+	for i := 0; i < len(speeches); i += 2 {
+		left := column(speeches[i], width)
+		right := []string{}
+		if i+1 < len(speeches) {
+			right = column(speeches[i+1], width)
+		}
+		for j := 0; j < len(left) || j < len(right); j++ {
+			l, r := "", ""
+			if j < len(left) {
+				l = left[j]
+			}
+			if j < len(right) {
+				r = right[j]
+			}
+			line := l
+			if r != "" {
+				line = padTo(l, width+DualDialogueGutter) + r
+			}
+			src = append(src, line+"\n")
+		}
+		src = append(src, "\n")
+	}
+	return strings.Join(src, "")
+}
+
+// ToFountain (of DualDialogue) returns the speeches as Fountain source,
+// the second character name is followed by the "^" marker.
+func (dd *DualDialogue) ToFountain() string {
+	speeches := []string{}
+	for i, speech := range dd.Speeches() {
+		lines := []string{}
+		for _, paragraph := range speech {
+			s := paragraph.ToFountain()
+			if s == "" {
+				continue
+			}
+			if i > 0 && paragraph.Type == CharacterType {
+				s += " " + DualDialogueMarker
+			}
+			lines = append(lines, s)
+		}
+		speeches = append(speeches, strings.Join(lines, "\n"))
+	}
+	return strings.Join(speeches, "\n\n")
+}
+
+// isDualDialogueCharacter reports if a Fountain character line
+// carries the dual dialogue marker, returning the name without it.
+func isDualDialogueCharacter(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, DualDialogueMarker) {
+		return strings.TrimSpace(strings.TrimSuffix(s, DualDialogueMarker)), true
+	}
+	return s, false
+}
+
+// startDualDialogue moves the speech ending the content's paragraphs
+// into a new DualDialogue paragraph followed by the character paragraph
+// which starts the second speech. Empty paragraphs following the first
+// speech are kept after the DualDialogue paragraph.
+func (c *Content) startDualDialogue(character *Paragraph) *DualDialogue {
+	i := len(c.Paragraph)
+	// Skip trailing empty paragraphs
+	for i > 0 && c.Paragraph[i-1].Type == "Empty" {
+		i--
+	}
+	end := i
+	for i > 0 && (c.Paragraph[i-1].Type == DialogueType || c.Paragraph[i-1].Type == ParentheticalType) {
+		i--
+	}
+	dd := new(DualDialogue)
+	wrapper := new(Paragraph)
+	wrapper.DualDialogue = dd
+	if i > 0 && c.Paragraph[i-1].Type == CharacterType {
+		i--
+		dd.Paragraph = append(dd.Paragraph, c.Paragraph[i:end]...)
+		trailing := append([]*Paragraph{wrapper}, c.Paragraph[end:]...)
+		c.Paragraph = append(c.Paragraph[0:i], trailing...)
+	} else {
+		c.Paragraph = append(c.Paragraph, wrapper)
+	}
+	dd.Paragraph = append(dd.Paragraph, character)
+	return dd
+}
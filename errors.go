@@ -0,0 +1,371 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// reEntity matches a character or entity reference
+	reEntity = regexp.MustCompile(`^&(#[0-9]{1,8}|#x[0-9a-fA-F]{1,8}|[A-Za-z][A-Za-z0-9._-]{0,31});`)
+
+	// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to
+	// runes, the rest of the high bytes are the same as Latin-1.
+	windows1252 = []rune{
+		'€', '�', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+		'�', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+	}
+)
+
+// ParseError describes a problem found parsing a Final Draft document.
+// Line and Column count from one, Path names the elements enclosing
+// the problem (e.g. "FinalDraft/Content/Paragraph/Text") and Snippet
+// holds the source around it. Parse returns a ParseError when a
+// document can't be read, ParseLenient returns one as a warning for
+// each problem it repaired.
+type ParseError struct {
+	Line    int
+	Column  int
+	Path    string
+	Snippet string
+	Err     error
+}
+
+// Error returns the problem with its position, path and snippet
+func (e *ParseError) Error() string {
+	src := []string{}
+	if e.Line > 0 {
+		src = append(src, fmt.Sprintf("line %d, column %d", e.Line, e.Column))
+	}
+	if e.Path != "" {
+		src = append(src, e.Path)
+	}
+	src = append(src, e.Err.Error())
+	s := strings.Join(src, ", ")
+	if e.Snippet != "" {
+		s += fmt.Sprintf(", near %q", e.Snippet)
+	}
+	return s
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// position returns the line and column of offset in src
+func position(src []byte, offset int) (int, int) {
+	offset = min(max(offset, 0), len(src))
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	return bytes.Count(src[:offset], []byte("\n")) + 1, utf8.RuneCount(src[start:offset]) + 1
+}
+
+// snippet returns up to 60 characters of the line holding offset
+// centered on offset.
+func snippet(src []byte, offset int) string {
+	offset = min(max(offset, 0), len(src))
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := bytes.IndexByte(src[offset:], '\n')
+	if end < 0 {
+		end = len(src)
+	} else {
+		end += offset
+	}
+	line := []rune(string(src[start:end]))
+	column := utf8.RuneCount(src[start:offset])
+	return strings.TrimSpace(string(line[max(column-30, 0):min(column+30, len(line))]))
+}
+
+// scanXML reads the tokens of src returning the elements open when it
+// stopped, the offset following the last complete token, the offset it
+// stopped at and the error that stopped it (nil at the end of src).
+func scanXML(src []byte) ([]string, int, int, error) {
+	d := xml.NewDecoder(bytes.NewReader(src))
+	path, last := []string{}, 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return path, last, last, nil
+		}
+		if err != nil {
+			return path, last, int(d.InputOffset()), err
+		}
+		last = int(d.InputOffset())
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+		case xml.EndElement:
+			path = path[0 : len(path)-1]
+		}
+	}
+}
+
+// newParseError describes err, returned parsing src, as a ParseError.
+// offset is where the decoder stopped, it places errors found decoding
+// well formed XML.
+func newParseError(src []byte, err error, offset int) *ParseError {
+	path, _, stop, scanErr := scanXML(src)
+	if scanErr == nil {
+		// The XML is well formed, the error came from decoding it
+		offset = min(max(offset, 0), len(src))
+		path, _, _, _ = scanXML(src[:offset])
+		scanErr = err
+	} else {
+		offset = stop
+	}
+	line, column := position(src, offset)
+	return &ParseError{
+		Line:    line,
+		Column:  column,
+		Path:    strings.Join(path, "/"),
+		Snippet: snippet(src, offset),
+		Err:     scanErr,
+	}
+}
+
+// recentSize is the number of bytes recentReader keeps
+const recentSize = 16 << 10
+
+// recentReader keeps the last bytes read from r so errors found
+// reading a stream can quote the input around them.
+type recentReader struct {
+	r      io.Reader
+	buf    []byte
+	offset int64 // the offset of buf[0] in the stream
+}
+
+func (rr *recentReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	rr.buf = append(rr.buf, p[:n]...)
+	if extra := len(rr.buf) - recentSize; extra > recentSize {
+		rr.buf = append([]byte{}, rr.buf[extra:]...)
+		rr.offset += int64(extra)
+	}
+	return n, err
+}
+
+// snippet returns the input around offset, an empty string if it is
+// no longer kept.
+func (rr *recentReader) snippet(offset int64) string {
+	if offset < rr.offset || offset > rr.offset+int64(len(rr.buf)) {
+		return ""
+	}
+	return snippet(rr.buf, int(offset-rr.offset))
+}
+
+// isXMLChar reports if r may appear in an XML document
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		(r >= 0x20 && r <= 0xD7FF) || (r >= 0xE000 && r <= 0xFFFD) ||
+		(r >= 0x10000 && r <= 0x10FFFF)
+}
+
+// repair describes a change made to the source and where it was made
+type repair struct {
+	offset int
+	msg    string
+}
+
+// repairSource fixes invalid UTF-8 (read as Windows-1252), characters
+// not allowed in XML (dropped) and bad entities (HTML entities are
+// replaced by their characters, other ampersands are escaped) in src.
+// Comments and CDATA sections are left alone except for the
+// characters. It returns the repaired source and where it was changed.
+func repairSource(src []byte) ([]byte, []repair) {
+	out := new(bytes.Buffer)
+	repairs := []repair{}
+	literal := "" // the end of a comment or CDATA section
+	for i := 0; i < len(src); {
+		switch {
+		case literal != "" && bytes.HasPrefix(src[i:], []byte(literal)):
+			out.WriteString(literal)
+			i += len(literal)
+			literal = ""
+			continue
+		case literal == "" && bytes.HasPrefix(src[i:], []byte("<!--")):
+			out.WriteString("<!--")
+			i += 4
+			literal = "-->"
+			continue
+		case literal == "" && bytes.HasPrefix(src[i:], []byte("<![CDATA[")):
+			out.WriteString("<![CDATA[")
+			i += 9
+			literal = "]]>"
+			continue
+		}
+		r, size := utf8.DecodeRune(src[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b := src[i]
+			if b >= 0x80 && b <= 0x9F {
+				r = windows1252[b-0x80]
+			} else {
+				r = rune(b)
+			}
+			repairs = append(repairs, repair{out.Len(), "invalid UTF-8 read as Windows-1252"})
+			out.WriteRune(r)
+		case !isXMLChar(r):
+			repairs = append(repairs, repair{out.Len(), fmt.Sprintf("dropped invalid character %U", r)})
+		case r == '&' && literal == "":
+			m := reEntity.FindSubmatch(src[i:])
+			name := ""
+			if m != nil {
+				name = string(m[1])
+			}
+			switch {
+			case name == "amp" || name == "lt" || name == "gt" || name == "apos" || name == "quot":
+				out.Write(m[0])
+				size = len(m[0])
+			case strings.HasPrefix(name, "#"):
+				var (
+					n   uint64
+					err error
+				)
+				if strings.HasPrefix(name, "#x") {
+					n, err = strconv.ParseUint(name[2:], 16, 32)
+				} else {
+					n, err = strconv.ParseUint(name[1:], 10, 32)
+				}
+				if err == nil && isXMLChar(rune(n)) {
+					out.Write(m[0])
+				} else {
+					repairs = append(repairs, repair{out.Len(), fmt.Sprintf("dropped invalid character reference &%s;", name)})
+				}
+				size = len(m[0])
+			case xml.HTMLEntity[name] != "":
+				repairs = append(repairs, repair{out.Len(), fmt.Sprintf("replaced entity &%s;", name)})
+				out.WriteString(xml.HTMLEntity[name])
+				size = len(m[0])
+			default:
+				repairs = append(repairs, repair{out.Len(), `escaped "&"`})
+				out.WriteString("&amp;")
+			}
+		default:
+			out.WriteRune(r)
+		}
+		i += size
+	}
+	return out.Bytes(), repairs
+}
+
+// warnings describes the repairs made to src as ParseErrors. Repeats
+// of a repair on the same line are reported once.
+func warnings(src []byte, repairs []repair) []*ParseError {
+	list := []*ParseError{}
+	if len(repairs) == 0 {
+		return list
+	}
+	// Find the elements enclosing each repair
+	d := xml.NewDecoder(bytes.NewReader(src))
+	path, i := []string{}, 0
+	for i < len(repairs) {
+		tok, err := d.Token()
+		offset := int(d.InputOffset())
+		for ; i < len(repairs) && (err != nil || repairs[i].offset < offset); i++ {
+			line, column := position(src, repairs[i].offset)
+			if n := len(list); n > 0 && list[n-1].Line == line && list[n-1].Err.Error() == repairs[i].msg {
+				continue
+			}
+			list = append(list, &ParseError{
+				Line:    line,
+				Column:  column,
+				Path:    strings.Join(path, "/"),
+				Snippet: snippet(src, repairs[i].offset),
+				Err:     errors.New(repairs[i].msg),
+			})
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+		case xml.EndElement:
+			path = path[0 : len(path)-1]
+		}
+	}
+	return list
+}
+
+// ParseLenient parses src like Parse but first repairs common
+// corruptions, invalid UTF-8, characters not allowed in XML, bad
+// entities and truncated files (the open elements are closed after
+// the last complete element). It returns the document, a warning
+// describing each repair and an error if the document still can't be
+// read.
+func ParseLenient(src []byte) (*FinalDraft, []*ParseError, error) {
+	src, repairs := repairSource(src)
+	list := warnings(src, repairs)
+	document, err := Parse(src)
+	if err == nil {
+		return document, list, nil
+	}
+	path, last, offset, scanErr := scanXML(src)
+	if scanErr == nil || len(path) == 0 || offset < len(src) {
+		// Not a truncated file
+		return document, list, err
+	}
+	line, column := position(src, last)
+	list = append(list, &ParseError{
+		Line:    line,
+		Column:  column,
+		Path:    strings.Join(path, "/"),
+		Snippet: snippet(src, last),
+		Err:     fmt.Errorf("file is truncated, closed %d open elements", len(path)),
+	})
+	repaired := bytes.NewBuffer(src[0:last:last])
+	for i := len(path) - 1; i >= 0; i-- {
+		repaired.WriteString("</" + path[i] + ">")
+	}
+	document, err = Parse(repaired.Bytes())
+	return document, list, err
+}
+
+// ParseWithWarnings parses src with ParseLenient when lenient is true,
+// writing each repair to warn (e.g. "warning: line 3, column 12, ...")
+// unless warn is nil, otherwise with Parse.
+func ParseWithWarnings(src []byte, lenient bool, warn io.Writer) (*FinalDraft, error) {
+	if !lenient {
+		return Parse(src)
+	}
+	document, warnings, err := ParseLenient(src)
+	if warn != nil {
+		for _, warning := range warnings {
+			fmt.Fprintf(warn, "warning: %s\n", warning)
+		}
+	}
+	return document, err
+}
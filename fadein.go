@@ -0,0 +1,725 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// OSFType and OSFVersion identify the Open Screenplay Format
+	// documents written by ToOSF
+	OSFType    = "Open Screenplay Format document"
+	OSFVersion = "30"
+
+	// OSFNormalStyle is the OSF style matching Final Draft's General
+	OSFNormalStyle = "Normal Text"
+
+	// TenthsPerInch converts OSF measurements (tenths of a millimeter)
+	// to inches.
+	TenthsPerInch = 254
+
+	// fadeInDocument is the name of the OSF document in a .fadein archive
+	fadeInDocument = "document.xml"
+)
+
+var (
+	// osfKeepWithNext lists the paragraph types Fade In keeps with
+	// the paragraph that follows.
+	osfKeepWithNext = map[string]bool{
+		SceneHeadingType:  true,
+		CharacterType:     true,
+		ParentheticalType: true,
+		ShotType:          true,
+	}
+)
+
+// tenths parses an OSF measurement returning it in inches, fallback
+// is returned when s is empty or invalid.
+func tenths(s string, fallback float64) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return fallback
+	}
+	return f / TenthsPerInch
+}
+
+// toTenths formats a measurement in inches as tenths of a millimeter
+func toTenths(f float64) string {
+	return strconv.Itoa(int(math.Round(f * TenthsPerInch)))
+}
+
+// osfTrue reports if an OSF boolean attribute is set
+func osfTrue(s string) bool {
+	return s == "1" || s == "true"
+}
+
+// osfYesNo converts an OSF boolean to Final Draft's "Yes" or "No"
+func osfYesNo(s string) string {
+	if osfTrue(s) {
+		return "Yes"
+	}
+	return "No"
+}
+
+// osfBool converts Final Draft's "Yes" or "No" to an OSF boolean
+func osfBool(s string) string {
+	if s == "Yes" {
+		return "true"
+	}
+	return "false"
+}
+
+// osfFlag returns "1" when set is true, otherwise an empty string
+// so the attribute is omitted.
+func osfFlag(set bool) string {
+	if set {
+		return "1"
+	}
+	return ""
+}
+
+// osfParagraphType maps an OSF style name to a paragraph type
+func osfParagraphType(name string) string {
+	if name == OSFNormalStyle || name == "" {
+		return GeneralType
+	}
+	return name
+}
+
+// osfStyleName maps a paragraph type to an OSF style name
+func osfStyleName(paragraphType string) string {
+	if paragraphType == GeneralType || paragraphType == "" {
+		return OSFNormalStyle
+	}
+	return paragraphType
+}
+
+// osfAlignment maps an OSF align attribute to a Final Draft alignment
+func osfAlignment(align string) string {
+	switch strings.ToLower(align) {
+	case "center":
+		return CenterAlignment
+	case "right":
+		return RightAlignment
+	case "left":
+		return LeftAlignment
+	}
+	return ""
+}
+
+// osfAlign maps a Final Draft alignment to an OSF align attribute,
+// left alignment is the default and is omitted.
+func osfAlign(alignment string) string {
+	switch alignment {
+	case CenterAlignment, RightAlignment:
+		return strings.ToLower(alignment)
+	}
+	return ""
+}
+
+// fontStyle returns the Final Draft style (e.g. "Bold+Underline")
+// of the style's font attributes.
+func (style *OSFStyle) fontStyle() string {
+	styles := []string{}
+	if osfTrue(style.AllCaps) {
+		styles = append(styles, AllCapsStyle)
+	}
+	if osfTrue(style.Bold) {
+		styles = append(styles, BoldStyle)
+	}
+	if osfTrue(style.Italic) {
+		styles = append(styles, ItalicStyle)
+	}
+	if osfTrue(style.Underline) {
+		styles = append(styles, UnderlineStyle)
+	}
+	return strings.Join(styles, "+")
+}
+
+// ToText converts OSF text to a Final Draft Text element
+func (text *OSFText) ToText() *Text {
+	styles := []string{}
+	if osfTrue(text.AllCaps) {
+		styles = append(styles, AllCapsStyle)
+	}
+	if osfTrue(text.Bold) {
+		styles = append(styles, BoldStyle)
+	}
+	if osfTrue(text.Italic) {
+		styles = append(styles, ItalicStyle)
+	}
+	if osfTrue(text.Underline) {
+		styles = append(styles, UnderlineStyle)
+	}
+	if osfTrue(text.Strikeout) {
+		styles = append(styles, Strikethrough)
+	}
+	return &Text{
+		Font:      text.Font,
+		Size:      text.Size,
+		Style:     strings.Join(styles, "+"),
+		InnerText: text.InnerText,
+	}
+}
+
+// osfText converts a Final Draft Text element to OSF text
+func osfText(text *Text) *OSFText {
+	return &OSFText{
+		AllCaps:   osfFlag(strings.Contains(text.Style, AllCapsStyle)),
+		Bold:      osfFlag(strings.Contains(text.Style, BoldStyle)),
+		Italic:    osfFlag(strings.Contains(text.Style, ItalicStyle)),
+		Underline: osfFlag(strings.Contains(text.Style, UnderlineStyle)),
+		Strikeout: osfFlag(strings.Contains(text.Style, Strikethrough)),
+		InnerText: text.InnerText,
+	}
+}
+
+// styleName returns the name of the style a paragraph uses
+func (para *OSFPara) styleName() string {
+	if para.Style == nil {
+		return OSFNormalStyle
+	}
+	if para.Style.BaseStyleName != "" {
+		return para.Style.BaseStyleName
+	}
+	return para.Style.Name
+}
+
+// ToParagraph converts an OSF paragraph to a Final Draft paragraph.
+// OSF stores parentheticals without their parentheses, they are
+// added back.
+func (para *OSFPara) ToParagraph() *Paragraph {
+	paragraph := new(Paragraph)
+	paragraph.Type = osfParagraphType(para.styleName())
+	paragraph.Number = para.Number
+	if para.Style != nil {
+		paragraph.Alignment = osfAlignment(para.Style.Align)
+		if osfTrue(para.Style.PageBreakBefore) {
+			paragraph.StartsNewPage = "Yes"
+		}
+	}
+	for _, text := range para.Text {
+		paragraph.Text = append(paragraph.Text, text.ToText())
+	}
+	if paragraph.Type == ParentheticalType && len(paragraph.Text) > 0 {
+		plain := strings.TrimSpace(paragraph.PlainText())
+		if plain != "" && !strings.HasPrefix(plain, "(") {
+			paragraph.Text[0].InnerText = "(" + paragraph.Text[0].InnerText
+			paragraph.Text[len(paragraph.Text)-1].InnerText += ")"
+		}
+	}
+	return paragraph
+}
+
+// osfPara converts a Final Draft paragraph to an OSF paragraph
+func osfPara(paragraph *Paragraph, settings *ElementSettings) *OSFPara {
+	para := new(OSFPara)
+	para.Number = paragraph.Number
+	para.Style = new(OSFStyle)
+	para.Style.BaseStyleName = osfStyleName(paragraph.Type)
+	if paragraph.Alignment != "" && (settings == nil || settings.ParagraphSpec == nil || settings.ParagraphSpec.Alignment != paragraph.Alignment) {
+		para.Style.Align = osfAlign(paragraph.Alignment)
+	}
+	para.Style.PageBreakBefore = osfFlag(paragraph.StartsNewPage == "Yes")
+	for _, text := range paragraph.Text {
+		para.Text = append(para.Text, osfText(text))
+	}
+	if len(para.Text) == 0 {
+		para.Text = append(para.Text, new(OSFText))
+	}
+	if paragraph.Type == ParentheticalType {
+		plain := strings.TrimSpace(paragraph.PlainText())
+		if strings.HasPrefix(plain, "(") && strings.HasSuffix(plain, ")") {
+			first, last := para.Text[0], para.Text[len(para.Text)-1]
+			first.InnerText = strings.TrimPrefix(strings.TrimLeft(first.InnerText, " "), "(")
+			last.InnerText = strings.TrimSuffix(strings.TrimRight(last.InnerText, " "), ")")
+		}
+	}
+	return para
+}
+
+// osfHeaderAlignment maps an OSF header alignment to a Final Draft
+// alignment, 1 is left, 2 center and 3 right.
+func osfHeaderAlignment(alignment string) string {
+	switch alignment {
+	case "1":
+		return LeftAlignment
+	case "2":
+		return CenterAlignment
+	}
+	return RightAlignment
+}
+
+// osfHeader converts header or footer paragraphs to an OSF page header
+// (e.g. "#.") and header alignment.
+func osfHeader(paragraphs []Paragraph) (string, string) {
+	if len(paragraphs) == 0 {
+		return "", ""
+	}
+	s := paragraphs[0].headerTemplate("#")
+	switch paragraphs[0].Alignment {
+	case LeftAlignment:
+		return s, "1"
+	case CenterAlignment:
+		return s, "2"
+	}
+	return s, "3"
+}
+
+// osfListItems returns the names held in an OSF list
+func osfListItems(list *OSFList) []string {
+	names := []string{}
+	if list != nil {
+		for _, item := range list.Item {
+			names = append(names, item.Name)
+		}
+	}
+	return names
+}
+
+// osfList builds an OSF list of element name holding names
+func osfList(name string, names []string) *OSFList {
+	if len(names) == 0 {
+		return nil
+	}
+	list := new(OSFList)
+	for _, s := range names {
+		item := new(OSFListItem)
+		item.XMLName.Local = name
+		item.Name = s
+		list.Item = append(list.Item, item)
+	}
+	return list
+}
+
+// elementSettings converts an OSF style to Final Draft element
+// settings. OSF indents are relative to the page margins, marginLeft
+// and rightEdge (in inches) locate them on the page.
+func (style *OSFStyle) elementSettings(marginLeft float64, rightEdge float64) *ElementSettings {
+	elementType := osfParagraphType(style.Name)
+	spaceBefore := 0.0
+	if f, err := strconv.ParseFloat(style.SpaceBefore, 64); err == nil {
+		spaceBefore = f * PointsPerLine
+	}
+	alignment := osfAlignment(style.Align)
+	if alignment == "" {
+		alignment = LeftAlignment
+	}
+	paginateAs := elementType
+	if settings, ok := DefaultElementSettings[elementType]; ok {
+		paginateAs = settings.Behavior.PaginateAs
+	}
+	settings := defaultElementSettings(elementType, alignment,
+		fmt.Sprintf("%.2f", marginLeft+tenths(style.LeftIndent, 0)),
+		fmt.Sprintf("%.2f", rightEdge-tenths(style.RightIndent, 0)),
+		"0.00", strconv.Itoa(int(math.Round(spaceBefore))), style.fontStyle(), paginateAs)
+	settings.FontSpec.Font = style.Font
+	settings.FontSpec.Size = style.Size
+	return settings
+}
+
+// osfStyle converts Final Draft element settings to an OSF style
+func osfStyle(settings *ElementSettings, marginLeft float64, rightEdge float64) *OSFStyle {
+	style := new(OSFStyle)
+	style.Name = osfStyleName(settings.Type)
+	style.Label = style.Name
+	if style.Name != OSFNormalStyle {
+		style.BaseStyleName = OSFNormalStyle
+	}
+	style.Font, style.Size = "Courier", "12"
+	if fontSpec := settings.FontSpec; fontSpec != nil {
+		if fontSpec.Size != "" {
+			style.Size = fontSpec.Size
+		}
+		style.AllCaps = osfFlag(strings.Contains(fontSpec.Style, AllCapsStyle))
+		style.Bold = osfFlag(strings.Contains(fontSpec.Style, BoldStyle))
+		style.Italic = osfFlag(strings.Contains(fontSpec.Style, ItalicStyle))
+		style.Underline = osfFlag(strings.Contains(fontSpec.Style, UnderlineStyle))
+	}
+	style.KeepWithNext = osfFlag(osfKeepWithNext[settings.Type])
+	if spec := settings.ParagraphSpec; spec != nil {
+		style.Align = osfAlign(spec.Alignment)
+		if points, err := strconv.ParseFloat(spec.SpaceBefore, 64); err == nil && points > 0 {
+			style.SpaceBefore = fmt.Sprintf("%.1f", points/PointsPerLine)
+		}
+		if f := measure(spec.LeftIndent, marginLeft) - marginLeft; f > 0 {
+			style.LeftIndent = toTenths(f)
+		}
+		if f := rightEdge - measure(spec.RightIndent, rightEdge); f > 0 {
+			style.RightIndent = toTenths(f)
+		}
+	}
+	return style
+}
+
+// ToFinalDraft converts an OSF document to a FinalDraft struct. Page
+// size and margins, styles (as ElementSettings), the page header,
+// "(MORE)" and "(CONT'D)" settings, the title page and the character,
+// location, scene intro, time and transition lists are converted.
+func (osf *OSFDocument) ToFinalDraft() *FinalDraft {
+	document := NewFinalDraft()
+	settings := osf.Settings
+	if settings == nil {
+		settings = new(OSFSettings)
+	}
+	pageWidth := tenths(settings.PageWidth, DefaultPageWidth*TenthsPerInch)
+	pageHeight := tenths(settings.PageHeight, DefaultPageHeight*TenthsPerInch)
+	document.SetPageSize(pageWidth, pageHeight)
+	marginLeft := tenths(settings.MarginLeft, 1.5)
+	marginRight := tenths(settings.MarginRight, 1)
+	rightEdge := pageWidth - marginRight
+	if settings.MarginTop != "" {
+		document.PageLayout.TopMargin = strconv.Itoa(int(math.Round(tenths(settings.MarginTop, 1) * PointsPerInch)))
+	}
+	if settings.MarginBottom != "" {
+		document.PageLayout.BottomMargin = strconv.Itoa(int(math.Round(tenths(settings.MarginBottom, 1) * PointsPerInch)))
+	}
+	if settings.BreakOnSentences != "" {
+		document.PageLayout.BreakDialogueAndActionAtSentences = osfYesNo(settings.BreakOnSentences)
+	}
+
+	// Styles
+	if osf.Styles != nil {
+		for _, style := range osf.Styles.Style {
+			document.ElementSettings = append(document.ElementSettings, style.elementSettings(marginLeft, rightEdge))
+		}
+	}
+
+	// Page header and footer
+	document.HeaderAndFooter = new(HeaderAndFooter)
+	document.HeaderAndFooter.HeaderVisible = "No"
+	document.HeaderAndFooter.HeaderFirstPage = osfYesNo(settings.HeaderFirstPage)
+	document.HeaderAndFooter.FooterVisible = "No"
+	document.HeaderAndFooter.FooterFirstPage = osfYesNo(settings.FooterFirstPage)
+	document.HeaderAndFooter.StartingPage = settings.PageNumberStart
+	if settings.PageHeader != "" {
+		document.HeaderAndFooter.HeaderVisible = "Yes"
+		document.HeaderAndFooter.Header.Paragraph = []Paragraph{
+			headerParagraph(settings.PageHeader, "#", osfHeaderAlignment(settings.HeaderAlignment), marginLeft, marginRight),
+		}
+	}
+	if settings.PageFooter != "" {
+		document.HeaderAndFooter.FooterVisible = "Yes"
+		document.HeaderAndFooter.Footer.Paragraph = []Paragraph{
+			headerParagraph(settings.PageFooter, "#", osfHeaderAlignment(settings.FooterAlignment), marginLeft, marginRight),
+		}
+	}
+
+	// "(MORE)", "(CONT'D)" and "CONTINUED"
+	if settings.MoreText != "" || settings.ContText != "" || settings.ContinuedText != "" {
+		document.MoresAndContinueds = new(MoresAndContinueds)
+		document.MoresAndContinueds.DialogueBreaks = &DialogueBreaks{
+			AutomaticCharacterContinueds: osfYesNo(settings.DialogueContinues),
+			BottomOfPage:                 osfYesNo(settings.DialoguePageBreak),
+			DialogueBottom:               settings.MoreText,
+			DialogueTop:                  settings.ContText,
+			TopOfNext:                    osfYesNo(settings.DialoguePageBreak),
+		}
+		document.MoresAndContinueds.SceneBreaks = &SceneBreaks{
+			ContinuedNumber:   osfYesNo(settings.NumberContinued),
+			SceneBottom:       "(" + settings.ContinuedText + ")",
+			SceneBottomOfPage: osfYesNo(settings.ScenesContinue),
+			SceneTop:          settings.ContinuedText + ":",
+			SceneTopOfNext:    osfYesNo(settings.ScenesContinue),
+		}
+	}
+	if settings.SceneNumbering != "" {
+		document.SceneNumberOptions = new(SceneNumberOptions)
+		document.SceneNumberOptions.ShowNumbersOnLeft = osfYesNo(settings.SceneNumbering)
+		document.SceneNumberOptions.ShowNumbersOnRight = osfYesNo(settings.SceneNumbering)
+		document.SceneNumberOptions.Locked = osfYesNo(settings.ScenesLocked)
+	}
+
+	// Script and title page
+	document.Content = new(Content)
+	if osf.Paragraphs != nil {
+		for _, para := range osf.Paragraphs.Para {
+			document.Content.Paragraph = append(document.Content.Paragraph, para.ToParagraph())
+		}
+	}
+	if osf.TitlePage != nil && len(osf.TitlePage.Para) > 0 {
+		document.TitlePage = new(TitlePage)
+		document.TitlePage.Content = new(Content)
+		for _, para := range osf.TitlePage.Para {
+			paragraph := para.ToParagraph()
+			paragraph.Type = ""
+			if paragraph.Alignment == "" {
+				paragraph.Alignment = LeftAlignment
+			}
+			paragraph.LeftIndent = fmt.Sprintf("%.2f", marginLeft)
+			paragraph.RightIndent = fmt.Sprintf("%.2f", rightEdge)
+			document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
+		}
+	}
+
+	// SmartType lists
+	if lists := osf.Lists; lists != nil {
+		smartType := new(SmartType)
+		smartType.Characters = new(Characters)
+		for _, name := range osfListItems(lists.Characters) {
+			smartType.Characters.Character = append(smartType.Characters.Character, &Character{InnerText: name})
+		}
+		smartType.Extensions = new(Extensions)
+		for _, name := range osfListItems(lists.Extensions) {
+			smartType.Extensions.Extension = append(smartType.Extensions.Extension, &Extension{InnerText: name})
+		}
+		smartType.SceneIntros = new(SceneIntros)
+		for _, name := range osfListItems(lists.SceneIntros) {
+			smartType.SceneIntros.SceneIntro = append(smartType.SceneIntros.SceneIntro, &SceneIntro{InnerText: name})
+		}
+		smartType.Locations = new(Locations)
+		for _, name := range osfListItems(lists.Locations) {
+			smartType.Locations.Location = append(smartType.Locations.Location, &Location{InnerText: name})
+		}
+		smartType.TimesOfDay = new(TimesOfDay)
+		smartType.TimesOfDay.Separator = settings.SceneTimeSep
+		for _, name := range osfListItems(lists.SceneTimes) {
+			smartType.TimesOfDay.TimeOfDay = append(smartType.TimesOfDay.TimeOfDay, &TimeOfDay{InnerText: name})
+		}
+		smartType.Transitions = new(Transitions)
+		for _, name := range osfListItems(lists.Transitions) {
+			smartType.Transitions.Transition = append(smartType.Transitions.Transition, &Transition{InnerText: name})
+		}
+		document.SmartType = smartType
+	}
+	return document
+}
+
+// ToOSF converts a FinalDraft struct to an Open Screenplay Format
+// document. Dual dialogue is written as consecutive dialogue and script
+// notes, revisions and other Final Draft only features are dropped.
+func (document *FinalDraft) ToOSF() *OSFDocument {
+	osf := new(OSFDocument)
+	osf.Type = OSFType
+	osf.Version = OSFVersion
+	osf.Info = new(OSFInfo)
+	osf.Info.PageCount = strconv.Itoa(len(document.Pages()))
+
+	// Page size, margins and breaks
+	pageWidth, pageHeight := document.PageSize()
+	general := document.ElementSetting(GeneralType)
+	marginLeft := measure(general.ParagraphSpec.LeftIndent, 1.5)
+	rightEdge := measure(general.ParagraphSpec.RightIndent, pageWidth-1)
+	top, bottom, _, _ := document.margins()
+	settings := new(OSFSettings)
+	settings.PageWidth = toTenths(pageWidth)
+	settings.PageHeight = toTenths(pageHeight)
+	settings.MarginTop = toTenths(top / PointsPerInch)
+	settings.MarginBottom = toTenths(bottom / PointsPerInch)
+	settings.MarginLeft = toTenths(marginLeft)
+	settings.MarginRight = toTenths(pageWidth - rightEdge)
+	if document.PageLayout != nil && document.PageLayout.BreakDialogueAndActionAtSentences != "" {
+		settings.BreakOnSentences = osfBool(document.PageLayout.BreakDialogueAndActionAtSentences)
+	}
+	dialogue, scene := document.breakSettings()
+	settings.DialogueContinues = osfBool(dialogue.AutomaticCharacterContinueds)
+	settings.DialoguePageBreak = osfBool(dialogue.BottomOfPage)
+	settings.MoreText = dialogue.DialogueBottom
+	settings.ContText = dialogue.DialogueTop
+	settings.ScenesContinue = osfBool(scene.SceneBottomOfPage)
+	settings.ContinuedText = strings.TrimSuffix(strings.TrimSpace(scene.SceneTop), ":")
+	settings.NumberContinued = osfBool(scene.ContinuedNumber)
+	if options := document.SceneNumberOptions; options != nil {
+		settings.SceneNumbering = osfBool(options.ShowNumbersOnLeft)
+		if options.ShowNumbersOnRight == "Yes" {
+			settings.SceneNumbering = "true"
+		}
+		settings.ScenesLocked = osfBool(options.Locked)
+	}
+	if hf := document.HeaderAndFooter; hf != nil {
+		settings.HeaderFirstPage = osfBool(hf.HeaderFirstPage)
+		settings.FooterFirstPage = osfBool(hf.FooterFirstPage)
+		settings.PageNumberStart = hf.StartingPage
+		if hf.HeaderVisible == "Yes" {
+			settings.PageHeader, settings.HeaderAlignment = osfHeader(hf.Header.Paragraph)
+		}
+		if hf.FooterVisible == "Yes" {
+			settings.PageFooter, settings.FooterAlignment = osfHeader(hf.Footer.Paragraph)
+		}
+	}
+	osf.Settings = settings
+
+	// Styles, General (Normal Text) first
+	types := []string{GeneralType}
+	for _, elementSettings := range document.ElementSettings {
+		if elementSettings.Type != GeneralType {
+			types = append(types, elementSettings.Type)
+		}
+	}
+	if len(types) == 1 {
+		types = append(types, SceneHeadingType, ActionType, CharacterType, ParentheticalType, DialogueType, TransitionType, ShotType)
+	}
+	osf.Styles = new(OSFStyles)
+	for _, elementType := range types {
+		osf.Styles.Style = append(osf.Styles.Style, osfStyle(document.ElementSetting(elementType), marginLeft, rightEdge))
+	}
+	osf.Styles.HeaderStyle = &OSFStyle{BaseStyleName: OSFNormalStyle}
+	osf.Styles.FooterStyle = &OSFStyle{BaseStyleName: OSFNormalStyle}
+
+	// Script and title page
+	osf.Paragraphs = new(OSFParagraphs)
+	if document.Content != nil {
+		for _, paragraph := range document.Content.Paragraph {
+			paragraphs := []*Paragraph{paragraph}
+			if paragraph.DualDialogue != nil {
+				paragraphs = paragraph.DualDialogue.Paragraph
+			}
+			for _, p := range paragraphs {
+				osf.Paragraphs.Para = append(osf.Paragraphs.Para, osfPara(p, document.ElementSetting(p.Type)))
+			}
+		}
+	}
+	if document.TitlePage != nil && document.TitlePage.Content != nil {
+		osf.TitlePage = new(OSFParagraphs)
+		for _, paragraph := range document.TitlePage.Content.Paragraph {
+			osf.TitlePage.Para = append(osf.TitlePage.Para, osfPara(paragraph, nil))
+		}
+	}
+
+	// SmartType lists
+	if smartType := document.SmartType; smartType != nil {
+		osf.Lists = new(OSFLists)
+		names := []string{}
+		if smartType.Characters != nil {
+			for _, item := range smartType.Characters.Character {
+				names = append(names, item.InnerText)
+			}
+		}
+		osf.Lists.Characters = osfList("character", names)
+		names = []string{}
+		if smartType.Locations != nil {
+			for _, item := range smartType.Locations.Location {
+				names = append(names, item.InnerText)
+			}
+		}
+		osf.Lists.Locations = osfList("location", names)
+		names = []string{}
+		if smartType.SceneIntros != nil {
+			for _, item := range smartType.SceneIntros.SceneIntro {
+				names = append(names, item.InnerText)
+			}
+		}
+		osf.Lists.SceneIntros = osfList("scene_intro", names)
+		names = []string{}
+		if smartType.TimesOfDay != nil {
+			settings.SceneTimeSep = smartType.TimesOfDay.Separator
+			for _, item := range smartType.TimesOfDay.TimeOfDay {
+				names = append(names, item.InnerText)
+			}
+		}
+		osf.Lists.SceneTimes = osfList("scene_time", names)
+		names = []string{}
+		if smartType.Extensions != nil {
+			for _, item := range smartType.Extensions.Extension {
+				names = append(names, item.InnerText)
+			}
+		}
+		osf.Lists.Extensions = osfList("extension", names)
+		names = []string{}
+		if smartType.Transitions != nil {
+			for _, item := range smartType.Transitions.Transition {
+				names = append(names, item.InnerText)
+			}
+		}
+		osf.Lists.Transitions = osfList("transition", names)
+	}
+	return osf
+}
+
+// ParseOSF takes []byte holding an Open Screenplay Format document and
+// returns an OSFDocument struct and error
+func ParseOSF(src []byte) (*OSFDocument, error) {
+	osf := new(OSFDocument)
+	err := xml.Unmarshal(src, &osf)
+	return osf, err
+}
+
+// ToXML renders the OSF document as indented XML
+func (osf *OSFDocument) ToXML() ([]byte, error) {
+	src, err := xml.MarshalIndent(osf, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(src, '\n')...), nil
+}
+
+// ParseFadeIn takes []byte holding a Fade In (.fadein) file, a zip
+// archive holding an Open Screenplay Format document.xml, and returns
+// a FinalDraft struct and error
+func ParseFadeIn(src []byte) (*FinalDraft, error) {
+	files, _, err := unzip(src)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := files[fadeInDocument]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in Fade In file", fadeInDocument)
+	}
+	osf, err := ParseOSF(data)
+	if err != nil {
+		return nil, err
+	}
+	return osf.ToFinalDraft(), nil
+}
+
+// ParseFadeInFile takes a filename of a Fade In file and returns a
+// FinalDraft struct and error
+func ParseFadeInFile(fname string) (*FinalDraft, error) {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFadeIn(src)
+}
+
+// WriteFadeIn writes the document as a Fade In (.fadein) file
+func (document *FinalDraft) WriteFadeIn(w io.Writer) error {
+	src, err := document.ToOSF().ToXML()
+	if err != nil {
+		return err
+	}
+	archive := zip.NewWriter(w)
+	f, err := archive.Create(fadeInDocument)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(src); err != nil {
+		return err
+	}
+	return archive.Close()
+}
@@ -68,431 +68,741 @@ const (
 	LastRevisedType = "Last Revised"
 
 	// Tabstop types
-	RightType = "Right"
-	LeftType  = "Left"
+	RightType  = "Right"
+	LeftType   = "Left"
+	CenterType = "Center"
+
+	// Watermark positions
+	HorizontalPosition         = "Horizontal"
+	DiagonalAscendingPosition  = "Diagonal Ascending"
+	DiagonalDescendingPosition = "Diagonal Descending"
 )
 
 var (
 	// MaxLineWidth is the number of characters wide a line can be
 	// based on a monospace font.
 	MaxLineWidth = 80
+
+	// ShowNotes when true includes script notes as Fountain notes
+	// (e.g. "[[a note]]") in the plain text rendered by String()
+	ShowNotes = false
+
+	// CharactersPerInch is the number of monospace characters (e.g.
+	// Courier 12pt) in an inch, it is used to convert Final Draft's
+	// measurements into columns of plain text.
+	CharactersPerInch = 10
 )
 
 type FinalDraft struct {
-	XMLName               xml.Name `json:"-" yaml:"-"`
-	DocumentType          string   `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
-	Template              string   `xml:",attr" json:"template,omitempty" yaml:"template,omitempty"`
-	Version               string   `xml:",attr" json:"version,omitempty" yaml:"version,omitempty"`
-	Content               *Content
-	TitlePage             *TitlePage
-	ElementSettings       []*ElementSettings
-	HeaderAndFooter       *HeaderAndFooter
-	SpellCheckIgnoreLists *SpellCheckIgnoreLists
-	PageLayout            *PageLayout
-	WindowState           *WindowState
-	TextState             *TextState
-	ScriptNoteDefinitions *ScriptNoteDefinitions
-	SmartType             *SmartType
-	MoresAndContinueds    *MoresAndContinueds
-	LockedPages           *LockedPages
-	Revisions             *Revisions
-	SplitState            *SplitState
-	Macros                *Macros
-	Actors                *Actors
-	Cast                  *Cast `xml:"Cast,omitempty" json:"cast,omitempty" yaml:"cast,omitempty"`
-	SceneNumberOptions    *SceneNumberOptions
+	XMLName               xml.Name               `json:"-" yaml:"-"`
+	DocumentType          string                 `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	Template              string                 `xml:",attr" json:"template,omitempty" yaml:"template,omitempty"`
+	Version               string                 `xml:",attr" json:"version,omitempty" yaml:"version,omitempty"`
+	Content               *Content               `json:"content,omitempty" yaml:"content,omitempty"`
+	Watermarking          *Watermarking          `json:"watermarking,omitempty" yaml:"watermarking,omitempty"`
+	TitlePage             *TitlePage             `json:"title_page,omitempty" yaml:"title_page,omitempty"`
+	UnanchoredScriptNotes *UnanchoredScriptNotes `json:"unanchored_script_notes,omitempty" yaml:"unanchored_script_notes,omitempty"`
+	ElementSettings       []*ElementSettings     `json:"element_settings,omitempty" yaml:"element_settings,omitempty"`
+	HeaderAndFooter       *HeaderAndFooter       `json:"header_and_footer,omitempty" yaml:"header_and_footer,omitempty"`
+	SpellCheckIgnoreLists *SpellCheckIgnoreLists `json:"spell_check_ignore_lists,omitempty" yaml:"spell_check_ignore_lists,omitempty"`
+	PageLayout            *PageLayout            `json:"page_layout,omitempty" yaml:"page_layout,omitempty"`
+	WindowState           *WindowState           `json:"window_state,omitempty" yaml:"window_state,omitempty"`
+	TextState             *TextState             `json:"text_state,omitempty" yaml:"text_state,omitempty"`
+	ScriptNoteDefinitions *ScriptNoteDefinitions `json:"script_note_definitions,omitempty" yaml:"script_note_definitions,omitempty"`
+	TagData               *TagData               `json:"tag_data,omitempty" yaml:"tag_data,omitempty"`
+	SmartType             *SmartType             `json:"smart_type,omitempty" yaml:"smart_type,omitempty"`
+	MoresAndContinueds    *MoresAndContinueds    `json:"mores_and_continueds,omitempty" yaml:"mores_and_continueds,omitempty"`
+	LockedPages           *LockedPages           `json:"locked_pages,omitempty" yaml:"locked_pages,omitempty"`
+	Revisions             *Revisions             `json:"revisions,omitempty" yaml:"revisions,omitempty"`
+	SplitState            *SplitState            `json:"split_state,omitempty" yaml:"split_state,omitempty"`
+	Macros                *Macros                `json:"macros,omitempty" yaml:"macros,omitempty"`
+	Actors                *Actors                `json:"actors,omitempty" yaml:"actors,omitempty"`
+	Cast                  *Cast                  `xml:"Cast,omitempty" json:"cast,omitempty" yaml:"cast,omitempty"`
+	SceneNumberOptions    *SceneNumberOptions    `json:"scene_number_options,omitempty" yaml:"scene_number_options,omitempty"`
+	TargetScriptLength    *TargetScriptLength    `json:"target_script_length,omitempty" yaml:"target_script_length,omitempty"`
+	UnknownAttrs          []xml.Attr             `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements       []*UnknownElement      `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+	order                 []string
 }
 
 type Content struct {
-	XMLName   xml.Name     `json:"-" yaml:"-"`
-	Paragraph []*Paragraph `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Paragraph       []*Paragraph      `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Paragraph struct {
-	XMLName         xml.Name `json:"-" yaml:"-"`
-	Type            string   `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
-	Number          string   `xml:",attr,omitempty" json:"number,omitempty" yaml:"number,omitempty"`
-	Alignment       string   `xml:",attr,omitempty" json:"alignment,omitempty" yaml:"alignment,omitempty"`
-	FirstIndent     string   `xml:",attr,omitempty" json:"first_indent,omitempty" yaml:"first_indent,omitempty"`
-	Leading         string   `xml:",attr,omitempty" json:"leading,omitempty" yaml:"leading,omitempty"`
-	LeftIndent      string   `xml:",attr,omitempty" json:"left_indent,omitempty" yaml:"left_indent,omitempty"`
-	RightIndent     string   `xml:",attr,omitempty" json:"right_indent,omitempty" yaml:"right_indent,omitempty"`
-	SpaceBefore     string   `xml:",attr,omitempty" json:"space_before,omitempty" yaml:"space_before,omitempty"`
-	Spacing         string   `xml:",attr,omitempty" json:"spacing,omitempty" yaml:"spacing,omitempty"`
-	StartsNewPage   string   `xml:",attr,omitempty" json:"starts_new_page,omitempty" yaml:"starts_new_page,omitempty"`
-	SceneProperties []*SceneProperties
-	DynamicLabel    []*DynamicLabel
-	Text            []*Text
+	XMLName         xml.Name           `json:"-" yaml:"-"`
+	Type            string             `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	Number          string             `xml:",attr,omitempty" json:"number,omitempty" yaml:"number,omitempty"`
+	Alignment       string             `xml:",attr,omitempty" json:"alignment,omitempty" yaml:"alignment,omitempty"`
+	FirstIndent     string             `xml:",attr,omitempty" json:"first_indent,omitempty" yaml:"first_indent,omitempty"`
+	Leading         string             `xml:",attr,omitempty" json:"leading,omitempty" yaml:"leading,omitempty"`
+	LeftIndent      string             `xml:",attr,omitempty" json:"left_indent,omitempty" yaml:"left_indent,omitempty"`
+	RightIndent     string             `xml:",attr,omitempty" json:"right_indent,omitempty" yaml:"right_indent,omitempty"`
+	SpaceBefore     string             `xml:",attr,omitempty" json:"space_before,omitempty" yaml:"space_before,omitempty"`
+	Spacing         string             `xml:",attr,omitempty" json:"spacing,omitempty" yaml:"spacing,omitempty"`
+	StartsNewPage   string             `xml:",attr,omitempty" json:"starts_new_page,omitempty" yaml:"starts_new_page,omitempty"`
+	SceneProperties []*SceneProperties `json:"scene_properties,omitempty" yaml:"scene_properties,omitempty"`
+	DynamicLabel    []*DynamicLabel    `json:"dynamic_labels,omitempty" yaml:"dynamic_labels,omitempty"`
+	ScriptNote      []*ScriptNote      `json:"script_notes,omitempty" yaml:"script_notes,omitempty"`
+	Text            []*Text            `json:"text,omitempty" yaml:"text,omitempty"`
+	Tabstops        *Tabstops          `json:"tabstops,omitempty" yaml:"tabstops,omitempty"`
+	DualDialogue    *DualDialogue      `json:"dual_dialogue,omitempty" yaml:"dual_dialogue,omitempty"`
+	UnknownAttrs    []xml.Attr         `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement  `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+	order           []string
 }
 
 type SceneProperties struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Length  string   `xml:",attr,omitempty" json:"length,omitempty" yaml:"length,omitempty"`
-	Page    string   `xml:",attr,omitempty" json:"page,omitempty" yaml:"page,omitempty"`
-	Title   string   `xml:",attr,omitempty" json:"title,omitempty" yaml:"title,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Length          string            `xml:",attr,omitempty" json:"length,omitempty" yaml:"length,omitempty"`
+	Page            string            `xml:",attr,omitempty" json:"page,omitempty" yaml:"page,omitempty"`
+	Title           string            `xml:",attr,omitempty" json:"title,omitempty" yaml:"title,omitempty"`
+	SceneArcBeats   *SceneArcBeats    `json:"scene_arc_beats,omitempty" yaml:"scene_arc_beats,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type Watermarking struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Opacity         string            `xml:",attr,omitempty" json:"opacity,omitempty" yaml:"opacity,omitempty"`
+	Position        string            `xml:",attr,omitempty" json:"position,omitempty" yaml:"position,omitempty"`
+	DynamicContent  *DynamicContent   `json:"dynamic_content,omitempty" yaml:"dynamic_content,omitempty"`
+	Distribution    *Distribution     `json:"distribution,omitempty" yaml:"distribution,omitempty"`
+	WatermarkImage  *WatermarkImage   `json:"watermark_image,omitempty" yaml:"watermark_image,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type DynamicContent struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Paragraph       []*Paragraph      `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type Distribution struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type WatermarkImage struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Height          string            `xml:",attr,omitempty" json:"height,omitempty" yaml:"height,omitempty"`
+	Width           string            `xml:",attr,omitempty" json:"width,omitempty" yaml:"width,omitempty"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type SceneArcBeats struct {
+	XMLName          xml.Name            `json:"-" yaml:"-"`
+	CharacterArcBeat []*CharacterArcBeat `json:"character_arc_beats,omitempty" yaml:"character_arc_beats,omitempty"`
+	UnknownAttrs     []xml.Attr          `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements  []*UnknownElement   `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type CharacterArcBeat struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Name            string            `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type DualDialogue struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Paragraph       []*Paragraph      `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type Tabstops struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Tabstop         []*Tabstop        `json:"tabstops,omitempty" yaml:"tabstops,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type Tabstop struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Position        string            `xml:",attr,omitempty" json:"position,omitempty" yaml:"position,omitempty"`
+	Type            string            `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type HeaderAndFooter struct {
-	XMLName         xml.Name `json:"-" yaml:"-"`
-	FooterFirstPage string   `xml:",attr,omitempty" json:"footer_first_page,omitempty" yaml:"footer_first_page,omitempty"`
-	FooterVisible   string   `xml:",attr,omitempty" json:"footer_visible,omitempty" yaml:"footer_visible,omitempty"`
-	HeaderFirstPage string   `xml:",attr,omitempty" json:"header_first_page,omitempty" yaml:"header_first_page,omitempty"`
-	HeaderVisible   string   `xml:",attr,omitempty" json:"header_visible,omitempty" yaml:"header_visible,omitempty"`
-	StartingPage    string   `xml:",attr,omitempty" json:"starting_page,omitempty" yaml:"starting_page,omitempty"`
-	Header          Header
-	Footer          Footer
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	FooterFirstPage string            `xml:",attr,omitempty" json:"footer_first_page,omitempty" yaml:"footer_first_page,omitempty"`
+	FooterVisible   string            `xml:",attr,omitempty" json:"footer_visible,omitempty" yaml:"footer_visible,omitempty"`
+	HeaderFirstPage string            `xml:",attr,omitempty" json:"header_first_page,omitempty" yaml:"header_first_page,omitempty"`
+	HeaderVisible   string            `xml:",attr,omitempty" json:"header_visible,omitempty" yaml:"header_visible,omitempty"`
+	StartingPage    string            `xml:",attr,omitempty" json:"starting_page,omitempty" yaml:"starting_page,omitempty"`
+	Header          Header            `json:"header,omitempty" yaml:"header,omitempty"`
+	Footer          Footer            `json:"footer,omitempty" yaml:"footer,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Header struct {
-	XMLName   xml.Name    `json:"-" yaml:"-"`
-	Paragraph []Paragraph `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Paragraph       []Paragraph       `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type DynamicLabel struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Type    string   `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Type            string            `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Footer struct {
-	XMLName   xml.Name    `json:"-" yaml:"-"`
-	Paragraph []Paragraph `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Paragraph       []Paragraph       `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Text struct {
-	XMLName        xml.Name `json:"-" yaml:"-"`
-	AdornmentStyle string   `xml:",attr,omitempty" json:"adornment_type,omitempty" yaml:"adornment_type,omitempty"`
-	Background     string   `xml:",attr,omitempty" json:"background,omitempty" yaml:"background,omitempty"`
-	Color          string   `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
-	Font           string   `xml:",attr,omitempty" json:"font,omitempty" yaml:"font,omitempty"`
-	RevisionID     string   `xml:",attr,omitempty" json:"revision_id,omitempty" yaml:"revision_id,omitempty"`
-	Size           string   `xml:",attr,omitempty" json:"size,omitempty" yaml:"size,omitempty"`
-	Style          string   `xml:",attr,omitempty" json:"style,omitempty" yaml:"style,omitempty"`
-	InnerText      string   `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	AdornmentStyle  string            `xml:",attr,omitempty" json:"adornment_style,omitempty" yaml:"adornment_style,omitempty"`
+	Background      string            `xml:",attr,omitempty" json:"background,omitempty" yaml:"background,omitempty"`
+	Color           string            `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
+	Font            string            `xml:",attr,omitempty" json:"font,omitempty" yaml:"font,omitempty"`
+	RevisionID      string            `xml:",attr,omitempty" json:"revision_id,omitempty" yaml:"revision_id,omitempty"`
+	Size            string            `xml:",attr,omitempty" json:"size,omitempty" yaml:"size,omitempty"`
+	Style           string            `xml:",attr,omitempty" json:"style,omitempty" yaml:"style,omitempty"`
+	TagNumber       string            `xml:",attr,omitempty" json:"tag_number,omitempty" yaml:"tag_number,omitempty"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type TitlePage struct {
-	XMLName         xml.Name `json:"-" yaml:"-"`
-	HeaderAndFooter *HeaderAndFooter
-	Content         *Content
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	HeaderAndFooter *HeaderAndFooter  `json:"header_and_footer,omitempty" yaml:"header_and_footer,omitempty"`
+	Content         *Content          `json:"content,omitempty" yaml:"content,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+	order           []string
 }
 
 type Revisions struct {
-	XMLName        xml.Name `json:"-" yaml:"-"`
-	ActiveSet      string   `xml:",attr,omitempty" json:"active_set,omitempty" yaml:"active_set,omitempty"`
-	Location       string   `xml:",attr,omitempty" json:"location,omitempty" yaml:"location,omitempty"`
-	RevisionMode   string   `xml:",attr,omitempty" json:"revision_mode,omitempty" yaml:"revision_mode,omitempty"`
-	RevisionsShown string   `xml:",attr,omitempty" json:"revisions_shown,omitempty" yaml:"revisions_shown,omitempty"`
-	ShowAllMarks   string   `xml:",attr,omitempty" json:"show_all_marks,omitempty" yaml:"show_all_marks,omitempty"`
-	ShowAllSets    string   `xml:",attr,omitempty" json:"show_all_sets,omitempty" yaml:"show_all_sets,omitempty"`
-	Revision       []Revision
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	ActiveSet       string            `xml:",attr,omitempty" json:"active_set,omitempty" yaml:"active_set,omitempty"`
+	Location        string            `xml:",attr,omitempty" json:"location,omitempty" yaml:"location,omitempty"`
+	RevisionMode    string            `xml:",attr,omitempty" json:"revision_mode,omitempty" yaml:"revision_mode,omitempty"`
+	RevisionsShown  string            `xml:",attr,omitempty" json:"revisions_shown,omitempty" yaml:"revisions_shown,omitempty"`
+	ShowAllMarks    string            `xml:",attr,omitempty" json:"show_all_marks,omitempty" yaml:"show_all_marks,omitempty"`
+	ShowAllSets     string            `xml:",attr,omitempty" json:"show_all_sets,omitempty" yaml:"show_all_sets,omitempty"`
+	Revision        []Revision        `json:"revisions,omitempty" yaml:"revisions,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Revision struct {
-	XMLName      xml.Name `json:"-" yaml:"-"`
-	Color        string   `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
-	FullRevision string   `xml:",attr,omitempty" json:"full_revision,omitempty" yaml:"full_revision,omitempty"`
-	ID           string   `xml:",attr,omitempty" json:"id,omitempty" yaml:"id,omitempty"`
-	Mark         string   `xml:",attr,omitempty" json:"mark,omitempty" yaml:"mark,omitempty"`
-	Name         string   `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
-	Style        string   `xml:",attr,omitempty" json:"style,omitempty" yaml:"style,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Color           string            `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
+	FullRevision    string            `xml:",attr,omitempty" json:"full_revision,omitempty" yaml:"full_revision,omitempty"`
+	ID              string            `xml:",attr,omitempty" json:"id,omitempty" yaml:"id,omitempty"`
+	Mark            string            `xml:",attr,omitempty" json:"mark,omitempty" yaml:"mark,omitempty"`
+	Name            string            `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Style           string            `xml:",attr,omitempty" json:"style,omitempty" yaml:"style,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type ElementSettings struct {
-	XMLName       xml.Name `json:"-" yaml:"-"`
-	Type          string   `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
-	FontSpec      *FontSpec
-	ParagraphSpec *ParagraphSpec
-	Behavior      *Behavior
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Type            string            `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	FontSpec        *FontSpec         `json:"font_spec,omitempty" yaml:"font_spec,omitempty"`
+	ParagraphSpec   *ParagraphSpec    `json:"paragraph_spec,omitempty" yaml:"paragraph_spec,omitempty"`
+	Behavior        *Behavior         `json:"behavior,omitempty" yaml:"behavior,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type FontSpec struct {
-	XMLName        xml.Name `json:"-" yaml:"-"`
-	AdornmentStyle string   `xml:",attr,omitempty" json:"adornment_style,omitempty" yaml:"adornment_type,omitempty"`
-	Background     string   `xml:",attr,omitempty" json:"background,omitempty" yaml:"background,omitempty"`
-	Color          string   `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
-	Font           string   `xml:",attr,omitempty" json:"font,omitempty" yaml:"font,omitempty"`
-	RevisionID     string   `xml:",attr,omitempty" json:"revision_id,omitempty" yaml:"revision_id,omitempty"`
-	Size           string   `xml:",attr,omitempty" json:"size,omitempty" yaml:"size,omitempty"`
-	Style          string   `xml:",attr,omitempty" json:"style,omitempty" yaml:"style,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	AdornmentStyle  string            `xml:",attr,omitempty" json:"adornment_style,omitempty" yaml:"adornment_style,omitempty"`
+	Background      string            `xml:",attr,omitempty" json:"background,omitempty" yaml:"background,omitempty"`
+	Color           string            `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
+	Font            string            `xml:",attr,omitempty" json:"font,omitempty" yaml:"font,omitempty"`
+	RevisionID      string            `xml:",attr,omitempty" json:"revision_id,omitempty" yaml:"revision_id,omitempty"`
+	Size            string            `xml:",attr,omitempty" json:"size,omitempty" yaml:"size,omitempty"`
+	Style           string            `xml:",attr,omitempty" json:"style,omitempty" yaml:"style,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type ParagraphSpec struct {
-	XMLName       xml.Name `json:"-"`
-	Alignment     string   `xml:",attr,omitempty" json:"alignment,omitempty" yaml:"alignment,omitempty"`
-	FirstIndent   string   `xml:",attr,omitempty" json:"first_indent,omitempty" yaml:"first_indent,omitempty"`
-	Leading       string   `xml:",attr,omitempty" json:"leading,omitempty" yaml:"leading,omitempty"`
-	LeftIndent    string   `xml:",attr,omitempty" json:"left_indent,omitempty" yaml:"left_indent,omitempty"`
-	RightIndent   string   `xml:",attr,omitempty" json:"right_indent,omitempty" yaml:"right_indent,omitempty"`
-	SpaceBefore   string   `xml:",attr,omitempty" json:"space_before,omitempty" yaml:"space_before,omitempty"`
-	Spacing       string   `xml:",attr,omitempty" json:"spacing,omitempty" yaml:"spacing,omitempty"`
-	StartsNewPage string   `xml:",attr,omitempty" json:"starts_new_page,omitempty" yaml:"starts_new_page,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Alignment       string            `xml:",attr,omitempty" json:"alignment,omitempty" yaml:"alignment,omitempty"`
+	FirstIndent     string            `xml:",attr,omitempty" json:"first_indent,omitempty" yaml:"first_indent,omitempty"`
+	Leading         string            `xml:",attr,omitempty" json:"leading,omitempty" yaml:"leading,omitempty"`
+	LeftIndent      string            `xml:",attr,omitempty" json:"left_indent,omitempty" yaml:"left_indent,omitempty"`
+	RightIndent     string            `xml:",attr,omitempty" json:"right_indent,omitempty" yaml:"right_indent,omitempty"`
+	SpaceBefore     string            `xml:",attr,omitempty" json:"space_before,omitempty" yaml:"space_before,omitempty"`
+	Spacing         string            `xml:",attr,omitempty" json:"spacing,omitempty" yaml:"spacing,omitempty"`
+	StartsNewPage   string            `xml:",attr,omitempty" json:"starts_new_page,omitempty" yaml:"starts_new_page,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Behavior struct {
-	XMLName    xml.Name `json:"-" yaml:"-"`
-	PaginateAs string   `xml:",attr,omitempty" json:"paginate_as,omitempty" yaml:"paginate_as,omitempty"`
-	ReturnKey  string   `xml:",attr,omitempty" json:"return_key,omitempty" yaml:"return_key,omitempty"`
-	Shortcut   string   `xml:",attr,omitempty" json:"shortcut,omitempty" yaml:"shortcut,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	PaginateAs      string            `xml:",attr,omitempty" json:"paginate_as,omitempty" yaml:"paginate_as,omitempty"`
+	ReturnKey       string            `xml:",attr,omitempty" json:"return_key,omitempty" yaml:"return_key,omitempty"`
+	Shortcut        string            `xml:",attr,omitempty" json:"shortcut,omitempty" yaml:"shortcut,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type SpellCheckIgnoreLists struct {
-	XMLName       xml.Name `json:"-" yaml:"-"`
-	IgnoredRanges *IgnoredRanges
-	IgnoredWords  []*IgnoredWords
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	IgnoredRanges   *IgnoredRanges    `json:"ignored_ranges,omitempty" yaml:"ignored_ranges,omitempty"`
+	IgnoredWords    []*IgnoredWords   `json:"ignored_words,omitempty" yaml:"ignored_words,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type IgnoredRanges struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type IgnoredWords struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Word    []*Word
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Word            []*Word           `json:"words,omitempty" yaml:"words,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Word struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	InnerText string   `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type PageLayout struct {
-	XMLName                           xml.Name `json:"-" yaml:"-"`
-	BackgroundColor                   string   `xml:",attr,omitempty" json:"background_color,omitempty" yaml:"background_color,omitempty"`
-	BottomMargin                      string   `xml:",attr,omitempty" json:"bottom_margin,omitempty" yaml:"bottom_margin,omitempty"`
-	BreakDialogueAndActionAtSentences string   `xml:",attr,omitempty" json:"break_dialogue_and_action_at_sentences,omitempty" yaml:"break_dialogue_and_action_at_sentences,omitempty"`
-	DocumentLeading                   string   `xml:",attr,omitempty" json:"document_leading,omitempty" yaml:"document_leading,omitempty"`
-	FooterMargin                      string   `xml:",attr,omitempty" json:"footer_margin,omitempty" yaml:"footer_margin,omitempty"`
-	ForegroundColor                   string   `xml:",attr,omitempty" json:"foreground_color,omitempty" yaml:"foreground_color,omitempty"`
-	HeaderMargin                      string   `xml:",attr,omitempty" json:"header_margin,omitempty" yaml:"header_margin,omitempty"`
-	InvisiblesColor                   string   `xml:",attr,omitempty" json:"invisible_colors,omitempty" yaml:"invisible_colors,omitempty"`
-	TopMargin                         string   `xml:",attr,omitempty" json:"top_margin,omitempty" yaml:"top_margin,omitempty"`
-	UsesSmartQuotes                   string   `xml:",attr,omitempty" json:"uses_smart_quotes,omitempty" yaml:"uses_smart_quotes,omitempty"`
-	AutoCastList                      *AutoCastList
+	XMLName                           xml.Name          `json:"-" yaml:"-"`
+	BackgroundColor                   string            `xml:",attr,omitempty" json:"background_color,omitempty" yaml:"background_color,omitempty"`
+	BottomMargin                      string            `xml:",attr,omitempty" json:"bottom_margin,omitempty" yaml:"bottom_margin,omitempty"`
+	BreakDialogueAndActionAtSentences string            `xml:",attr,omitempty" json:"break_dialogue_and_action_at_sentences,omitempty" yaml:"break_dialogue_and_action_at_sentences,omitempty"`
+	DocumentLeading                   string            `xml:",attr,omitempty" json:"document_leading,omitempty" yaml:"document_leading,omitempty"`
+	FooterMargin                      string            `xml:",attr,omitempty" json:"footer_margin,omitempty" yaml:"footer_margin,omitempty"`
+	ForegroundColor                   string            `xml:",attr,omitempty" json:"foreground_color,omitempty" yaml:"foreground_color,omitempty"`
+	HeaderMargin                      string            `xml:",attr,omitempty" json:"header_margin,omitempty" yaml:"header_margin,omitempty"`
+	InvisiblesColor                   string            `xml:",attr,omitempty" json:"invisibles_color,omitempty" yaml:"invisibles_color,omitempty"`
+	TopMargin                         string            `xml:",attr,omitempty" json:"top_margin,omitempty" yaml:"top_margin,omitempty"`
+	UsesSmartQuotes                   string            `xml:",attr,omitempty" json:"uses_smart_quotes,omitempty" yaml:"uses_smart_quotes,omitempty"`
+	PageSize                          *PageSize         `json:"page_size,omitempty" yaml:"page_size,omitempty"`
+	AutoCastList                      *AutoCastList     `json:"auto_cast_list,omitempty" yaml:"auto_cast_list,omitempty"`
+	UnknownAttrs                      []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements                   []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+	order                             []string
+}
+
+type PageSize struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Height          string            `xml:",attr,omitempty" json:"height,omitempty" yaml:"height,omitempty"`
+	Width           string            `xml:",attr,omitempty" json:"width,omitempty" yaml:"width,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type AutoCastList struct {
-	XMLName               xml.Name `json:"-" yaml:"-"`
-	AddParentheses        string   `xml:",attr,omitempty" json:"add_parentheses,omitempty" yaml:"add_parentheses,omitempty"`
-	AutomaticallyGenerate string   `xml:",attr,omitempty" json:"automatically_generate,omitempty" yaml:"automatically_generate,omitempty"`
-	CastListElement       string   `xml:",attr,omitempty" json:"cast_list_element,omitempty" yaml:"cast_list_element,omitempty"`
+	XMLName               xml.Name          `json:"-" yaml:"-"`
+	AddParentheses        string            `xml:",attr,omitempty" json:"add_parentheses,omitempty" yaml:"add_parentheses,omitempty"`
+	AutomaticallyGenerate string            `xml:",attr,omitempty" json:"automatically_generate,omitempty" yaml:"automatically_generate,omitempty"`
+	CastListElement       string            `xml:",attr,omitempty" json:"cast_list_element,omitempty" yaml:"cast_list_element,omitempty"`
+	UnknownAttrs          []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements       []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type WindowState struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Height  string   `xml:",attr,omitempty" json:"height,omitempty" yaml:"height,omitempty"`
-	Left    string   `xml:",attr,omitempty" json:"left,omitempty" yaml:"left,omitempty"`
-	Mode    string   `xml:",attr,omitempty" json:"mode,omitempty" yaml:"mode,omitempty"`
-	Top     string   `xml:",attr,omitempty" json:"top,omitempty" yaml:"top,omitempty"`
-	Width   string   `xml:",attr,omitempty" json:"width,omitempty" yaml:"width,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Height          string            `xml:",attr,omitempty" json:"height,omitempty" yaml:"height,omitempty"`
+	Left            string            `xml:",attr,omitempty" json:"left,omitempty" yaml:"left,omitempty"`
+	Mode            string            `xml:",attr,omitempty" json:"mode,omitempty" yaml:"mode,omitempty"`
+	Top             string            `xml:",attr,omitempty" json:"top,omitempty" yaml:"top,omitempty"`
+	Width           string            `xml:",attr,omitempty" json:"width,omitempty" yaml:"width,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type TextState struct {
-	XMLName        xml.Name `json:"-" yaml:"-"`
-	Scaling        string   `xml:",attr,omitempty" json:"scaling,omitempty" yaml:"scaling,omitempty"`
-	Selection      string   `xml:",attr,omitempty" json:"selection,omitempty" yaml:"selection,omitempty"`
-	ShowInvisibles string   `xml:",attr,omitempty" json:"show_invisibles,omitempty" yaml:"show_invisibles,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Scaling         string            `xml:",attr,omitempty" json:"scaling,omitempty" yaml:"scaling,omitempty"`
+	Selection       string            `xml:",attr,omitempty" json:"selection,omitempty" yaml:"selection,omitempty"`
+	ShowInvisibles  string            `xml:",attr,omitempty" json:"show_invisibles,omitempty" yaml:"show_invisibles,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type ScriptNoteDefinitions struct {
-	XMLName              xml.Name `json:"-" yaml:"-"`
-	Active               string   `xml:",attr,omitempty" json:"active,omitempty" yaml:"active,omitempty"`
-	ScriptNoteDefinition []*ScriptNoteDefinition
+	XMLName              xml.Name                `json:"-" yaml:"-"`
+	Active               string                  `xml:",attr,omitempty" json:"active,omitempty" yaml:"active,omitempty"`
+	ScriptNoteDefinition []*ScriptNoteDefinition `json:"script_note_definitions,omitempty" yaml:"script_note_definitions,omitempty"`
+	UnknownAttrs         []xml.Attr              `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements      []*UnknownElement       `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type ScriptNoteDefinition struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Color   string   `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
-	ID      string   `xml:",attr,omitempty" json:"id,omitempty" yaml:"id,omitempty"`
-	Marker  string   `xml:",attr,omitempty" json:"marker,omitempty" yaml:"marker,omitempty"`
-	Name    string   `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Color           string            `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
+	ID              string            `xml:",attr,omitempty" json:"id,omitempty" yaml:"id,omitempty"`
+	Marker          string            `xml:",attr,omitempty" json:"marker,omitempty" yaml:"marker,omitempty"`
+	Name            string            `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type ScriptNote struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Color           string            `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
+	DefinitionID    string            `xml:",attr,omitempty" json:"definition_id,omitempty" yaml:"definition_id,omitempty"`
+	ID              string            `xml:",attr,omitempty" json:"id,omitempty" yaml:"id,omitempty"`
+	Marker          string            `xml:",attr,omitempty" json:"marker,omitempty" yaml:"marker,omitempty"`
+	Range           string            `xml:",attr,omitempty" json:"range,omitempty" yaml:"range,omitempty"`
+	Paragraph       []*Paragraph      `json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type UnanchoredScriptNotes struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	ScriptNote      []*ScriptNote     `json:"script_notes,omitempty" yaml:"script_notes,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type SmartType struct {
-	XMLName     xml.Name `json:"-" yaml:"-"`
-	Characters  *Characters
-	Extensions  *Extensions
-	SceneIntros *SceneIntros
-	Locations   *Locations
-	TimesOfDay  *TimesOfDay
-	Transitions *Transitions
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Characters      *Characters       `json:"characters,omitempty" yaml:"characters,omitempty"`
+	Extensions      *Extensions       `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	SceneIntros     *SceneIntros      `json:"scene_intros,omitempty" yaml:"scene_intros,omitempty"`
+	Locations       *Locations        `json:"locations,omitempty" yaml:"locations,omitempty"`
+	TimesOfDay      *TimesOfDay       `json:"times_of_day,omitempty" yaml:"times_of_day,omitempty"`
+	Transitions     *Transitions      `json:"transitions,omitempty" yaml:"transitions,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Characters struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	Character []*Character
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Character       []*Character      `json:"characters,omitempty" yaml:"characters,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Character struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	InnerText string   `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Extensions struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	Extension []*Extension
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Extension       []*Extension      `json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Extension struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	InnerText string   `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type SceneIntros struct {
-	XMLName    xml.Name `json:"-" yaml:"-"`
-	SceneIntro []*SceneIntro
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	SceneIntro      []*SceneIntro     `json:"scene_intros,omitempty" yaml:"scene_intros,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type SceneIntro struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	InnerText string   `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Locations struct {
-	XMLName  xml.Name `json:"-" yaml:"-"`
-	Location []*Location
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Location        []*Location       `json:"locations,omitempty" yaml:"locations,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Location struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	InnerText string   `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type TimesOfDay struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	Separator string   `xml:",attr,omitempty" json:"times_of_day,omitempty" yaml:"times_of_day,omitempty"`
-	TimeOfDay []*TimeOfDay
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Separator       string            `xml:",attr,omitempty" json:"separator,omitempty" yaml:"separator,omitempty"`
+	TimeOfDay       []*TimeOfDay      `json:"times_of_day,omitempty" yaml:"times_of_day,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type TimeOfDay struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	InnerText string   `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Transitions struct {
-	XMLName    xml.Name `json:"-" yaml:"-"`
-	Transition []*Transition
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Transition      []*Transition     `json:"transitions,omitempty" yaml:"transitions,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Transition struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	InnerText string   `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type MoresAndContinueds struct {
-	XMLName        xml.Name `json:"-" yaml:"-"`
-	FontSpec       *FontSpec
-	DialogueBreaks *DialogueBreaks
-	SceneBreaks    *SceneBreaks
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	FontSpec        *FontSpec         `json:"font_spec,omitempty" yaml:"font_spec,omitempty"`
+	DialogueBreaks  *DialogueBreaks   `json:"dialogue_breaks,omitempty" yaml:"dialogue_breaks,omitempty"`
+	SceneBreaks     *SceneBreaks      `json:"scene_breaks,omitempty" yaml:"scene_breaks,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type DialogueBreaks struct {
-	XMLNAme        xml.Name `json:"-" yaml:"-"`
-	BottomOfPage   string   `xml:",attr,omitempty" json:"bottom_of_page,omitempty" yaml:"bottom_of_page,omitempty"`
-	DialogueBottom string   `xml:",attr,omitempty" json:"dialogue_bottom,omitempty" yaml:"dialogue_bottom,omitempty"`
-	DialogueTop    string   `xml:",attr,omitempty" json:"dialogue_top,omitempty" yaml:"dialogue_top,omitempty"`
-	TopOfNext      string   `xml:",attr,omitempty" json:"top_of_next,omitempty" yaml:"top_of_next,omitempty"`
+	XMLName                      xml.Name          `json:"-" yaml:"-"`
+	AutomaticCharacterContinueds string            `xml:",attr,omitempty" json:"automatic_character_continueds,omitempty" yaml:"automatic_character_continueds,omitempty"`
+	BottomOfPage                 string            `xml:",attr,omitempty" json:"bottom_of_page,omitempty" yaml:"bottom_of_page,omitempty"`
+	DialogueBottom               string            `xml:",attr,omitempty" json:"dialogue_bottom,omitempty" yaml:"dialogue_bottom,omitempty"`
+	DialogueTop                  string            `xml:",attr,omitempty" json:"dialogue_top,omitempty" yaml:"dialogue_top,omitempty"`
+	TopOfNext                    string            `xml:",attr,omitempty" json:"top_of_next,omitempty" yaml:"top_of_next,omitempty"`
+	UnknownAttrs                 []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements              []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type SceneBreaks struct {
-	XMLName           xml.Name `json:"-" yaml:"-"`
-	ContinuedNumber   string   `xml:",attr,omitempty" json:"continued_number,omitempty" yaml:"continued_number,omitempty"`
-	SceneBottom       string   `xml:",attr,omitempty" json:"scene_bottom,omitempty" yaml:"scene_buttom,omitempty"`
-	SceneBottomOfPage string   `xml:",attr,omitempty" json:"scene_bottom_of_page,omitempty" yaml:"scene_bottom_of_page,omitempty"`
-	SceneTop          string   `xml:",attr,omitempty" json:"scene_top,omitempty" yaml:"scene_top,omitempty"`
-	SceneTopOfNext    string   `xml:",attr,omitempty" json:"scene_top_of_next,omitempty" yaml:"scene_top_of_next,omitempty"`
+	XMLName           xml.Name          `json:"-" yaml:"-"`
+	ContinuedNumber   string            `xml:",attr,omitempty" json:"continued_number,omitempty" yaml:"continued_number,omitempty"`
+	SceneBottom       string            `xml:",attr,omitempty" json:"scene_bottom,omitempty" yaml:"scene_bottom,omitempty"`
+	SceneBottomOfPage string            `xml:",attr,omitempty" json:"scene_bottom_of_page,omitempty" yaml:"scene_bottom_of_page,omitempty"`
+	SceneTop          string            `xml:",attr,omitempty" json:"scene_top,omitempty" yaml:"scene_top,omitempty"`
+	SceneTopOfNext    string            `xml:",attr,omitempty" json:"scene_top_of_next,omitempty" yaml:"scene_top_of_next,omitempty"`
+	UnknownAttrs      []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements   []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type LockedPages struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Macros struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Macro   []*Macro
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Macro           []*Macro          `json:"macros,omitempty" yaml:"macros,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Macro struct {
-	XMLName    xml.Name `json:"-" yaml:"-"`
-	Element    string   `xml:",attr,omitempty" json:"element,omitempty" yaml:"element,omitempty"`
-	Name       string   `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
-	Shortcut   string   `xml:",attr,omitempty" json:"shortcut,omitempty" yaml:"shortcut,omitempty"`
-	Text       string   `xml:",attr,omitempty" json:"text,omitempty" yaml:"text,omitempty"`
-	Transition string   `xml:",attr,omitempty" json:"transition,omitempty" yaml:"transition,omitempty"`
-	Alias      []*Alias
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Element         string            `xml:",attr,omitempty" json:"element,omitempty" yaml:"element,omitempty"`
+	Name            string            `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Shortcut        string            `xml:",attr,omitempty" json:"shortcut,omitempty" yaml:"shortcut,omitempty"`
+	Text            string            `xml:",attr,omitempty" json:"text,omitempty" yaml:"text,omitempty"`
+	Transition      string            `xml:",attr,omitempty" json:"transition,omitempty" yaml:"transition,omitempty"`
+	Alias           []*Alias          `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Alias struct {
-	XMLName      xml.Name `json:"-" yaml:"-"`
-	Confirm      string   `xml:",attr,omitempty" json:"confirm,omitempty" yaml:"confirm,omitempty"`
-	MatchCase    string   `xml:",attr,omitempty" json:"match_case,omitempty" yaml:"match_case,omitempty"`
-	SmartReplace string   `xml:",attr,omitempty" json:"smart_replace,omitempty" yaml:"smart_replace,omitempty"`
-	Text         string   `xml:",attr,omitempty" json:"text,omitempty" yaml:"text,omitempty"`
-	WordOnly     string   `xml:",attr,omitempty" json:"word_only,omitempty" yaml:"word_only,omitempty"`
-	ActivateIn   []*ActivateIn
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Confirm         string            `xml:",attr,omitempty" json:"confirm,omitempty" yaml:"confirm,omitempty"`
+	MatchCase       string            `xml:",attr,omitempty" json:"match_case,omitempty" yaml:"match_case,omitempty"`
+	SmartReplace    string            `xml:",attr,omitempty" json:"smart_replace,omitempty" yaml:"smart_replace,omitempty"`
+	Text            string            `xml:",attr,omitempty" json:"text,omitempty" yaml:"text,omitempty"`
+	WordOnly        string            `xml:",attr,omitempty" json:"word_only,omitempty" yaml:"word_only,omitempty"`
+	ActivateIn      []*ActivateIn     `json:"activate_in,omitempty" yaml:"activate_in,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type ActivateIn struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Element string   `xml:",attr,omitempty" json:"element,omitempty" yaml:"element,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Element         string            `xml:",attr,omitempty" json:"element,omitempty" yaml:"element,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Actors struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Actor   []*Actor
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Actor           []*Actor          `json:"actors,omitempty" yaml:"actors,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Actor struct {
-	XMLName  xml.Name `json:"-" yaml:"-"`
-	MacVoice string   `xml:",attr,omitempty" json:"mac_voice,omitempty" yaml:"mac_voice,omitempty"`
-	Name     string   `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
-	Pitch    string   `xml:",attr,omitempty" json:"pitch,omitempty" yaml:"pitch,omitempty"`
-	Speed    string   `xml:",attr,omitempty" json:"speed,omitempty" yaml:"speed,omitempty"`
-	WinVoice string   `xml:",attr,omitempty" json:"win_voice,omitempty" yaml:"win_voice,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	MacVoice        string            `xml:",attr,omitempty" json:"mac_voice,omitempty" yaml:"mac_voice,omitempty"`
+	Name            string            `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Pitch           string            `xml:",attr,omitempty" json:"pitch,omitempty" yaml:"pitch,omitempty"`
+	Speed           string            `xml:",attr,omitempty" json:"speed,omitempty" yaml:"speed,omitempty"`
+	WinVoice        string            `xml:",attr,omitempty" json:"win_voice,omitempty" yaml:"win_voice,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Cast struct {
-	XMLName  xml.Name `json:"-" yaml:"-"`
-	Narrator *Narrator
-	Member   []*Member
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Narrator        *Narrator         `json:"narrator,omitempty" yaml:"narrator,omitempty"`
+	Member          []*Member         `json:"members,omitempty" yaml:"members,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Narrator struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Element []*Element
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Element         []*Element        `json:"elements,omitempty" yaml:"elements,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Element struct {
-	XMLName xml.Name `json:"-" yaml:"-"`
-	Type    string   `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Type            string            `xml:",attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type Member struct {
-	XMLName   xml.Name `json:"-" yaml:"-"`
-	Actor     string   `xml:",attr,omitempty" json:"actor,omitempty" yaml:"actor,omitempty"`
-	Character string   `xml:",attr,omitempty" json:"character,omitempty" yaml:"character,omitempty"`
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Actor           string            `xml:",attr,omitempty" json:"actor,omitempty" yaml:"actor,omitempty"`
+	Character       string            `xml:",attr,omitempty" json:"character,omitempty" yaml:"character,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type SplitState struct {
-	XMLName          xml.Name `json:"-" yaml:"-"`
-	ActivePanel      string   `xml:",attr,omitempty" json:"active_panel,omitempty" yaml:"active_panel,omitempty"`
-	SplitMode        string   `xml:",attr,omitempty" json:"split_mode,omitempty" yaml:"split_mode,omitempty"`
-	SplitterPosition string   `xml:",attr,omitempty" json:"splitter_position,omitempty" yaml:"splitter_position,omitempty"`
-	ScriptPanel      *ScriptPanel
+	XMLName          xml.Name          `json:"-" yaml:"-"`
+	ActivePanel      string            `xml:",attr,omitempty" json:"active_panel,omitempty" yaml:"active_panel,omitempty"`
+	SplitMode        string            `xml:",attr,omitempty" json:"split_mode,omitempty" yaml:"split_mode,omitempty"`
+	SplitterPosition string            `xml:",attr,omitempty" json:"splitter_position,omitempty" yaml:"splitter_position,omitempty"`
+	ScriptPanel      *ScriptPanel      `json:"script_panel,omitempty" yaml:"script_panel,omitempty"`
+	UnknownAttrs     []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements  []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type ScriptPanel struct {
-	XMLName     xml.Name `json:"-" yaml:"-"`
-	DisplayMode string   `xml:",attr,omitempty" json:"display_mode,omitempty" yaml:"display_mode,omitempty"`
-	FontSpec    *FontSpec
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	DisplayMode     string            `xml:",attr,omitempty" json:"display_mode,omitempty" yaml:"display_mode,omitempty"`
+	FontSpec        *FontSpec         `json:"font_spec,omitempty" yaml:"font_spec,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 type SceneNumberOptions struct {
-	XMLName            xml.Name `json:"-" yaml:"-"`
-	LeftLocation       string   `xml:",attr,omitempty" json:"left_location,omitempty" yaml:"left_location,omitempty"`
-	RightLocation      string   `xml:",attr,omitempty" json:"right_location,omitempty" yaml:"right_location,omitempty"`
-	ShowNumbersOnLeft  string   `xml:",attr,omitempty" json:"show_numbers_on_left,omitempty" yaml:"show_numbers_on_left,omitempty"`
-	ShowNumbersOnRight string   `xml:",attr,omitempty" json:"show_numbers_on_right,omitempty" yaml:"show_numbers_on_right,omitempty"`
-	FontSpec           *FontSpec
+	XMLName            xml.Name          `json:"-" yaml:"-"`
+	LeftLocation       string            `xml:",attr,omitempty" json:"left_location,omitempty" yaml:"left_location,omitempty"`
+	Locked             string            `xml:",attr,omitempty" json:"locked,omitempty" yaml:"locked,omitempty"`
+	NumberScheme       string            `xml:",attr,omitempty" json:"number_scheme,omitempty" yaml:"number_scheme,omitempty"`
+	RightLocation      string            `xml:",attr,omitempty" json:"right_location,omitempty" yaml:"right_location,omitempty"`
+	ShowNumbersOnLeft  string            `xml:",attr,omitempty" json:"show_numbers_on_left,omitempty" yaml:"show_numbers_on_left,omitempty"`
+	ShowNumbersOnRight string            `xml:",attr,omitempty" json:"show_numbers_on_right,omitempty" yaml:"show_numbers_on_right,omitempty"`
+	FontSpec           *FontSpec         `json:"font_spec,omitempty" yaml:"font_spec,omitempty"`
+	UnknownAttrs       []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements    []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type TargetScriptLength struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+// TagData holds the breakdown tagging of a script. Text is tagged by
+// its TagNumber naming a Tag, a Tag lists the TagDefinitions (e.g.
+// "REVOLVER") that apply and each TagDefinition belongs to a
+// TagCategory (e.g. "Props").
+type TagData struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	TagCategories   *TagCategories    `json:"tag_categories,omitempty" yaml:"tag_categories,omitempty"`
+	TagDefinitions  *TagDefinitions   `json:"tag_definitions,omitempty" yaml:"tag_definitions,omitempty"`
+	Tags            *Tags             `json:"tags,omitempty" yaml:"tags,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type TagCategories struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	TagCategory     []*TagCategory    `json:"tag_categories,omitempty" yaml:"tag_categories,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type TagCategory struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Color           string            `xml:",attr,omitempty" json:"color,omitempty" yaml:"color,omitempty"`
+	ID              string            `xml:"Id,attr,omitempty" json:"id,omitempty" yaml:"id,omitempty"`
+	Name            string            `xml:",attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	Number          string            `xml:",attr,omitempty" json:"number,omitempty" yaml:"number,omitempty"`
+	Style           string            `xml:",attr,omitempty" json:"style,omitempty" yaml:"style,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type TagDefinitions struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	TagDefinition   []*TagDefinition  `json:"tag_definitions,omitempty" yaml:"tag_definitions,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type TagDefinition struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	CategoryID      string            `xml:"CatId,attr,omitempty" json:"category_id,omitempty" yaml:"category_id,omitempty"`
+	ID              string            `xml:"Id,attr,omitempty" json:"id,omitempty" yaml:"id,omitempty"`
+	Label           string            `xml:",attr,omitempty" json:"label,omitempty" yaml:"label,omitempty"`
+	Number          string            `xml:",attr,omitempty" json:"number,omitempty" yaml:"number,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type Tags struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Tag             []*Tag            `json:"tags,omitempty" yaml:"tags,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type Tag struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Number          string            `xml:",attr,omitempty" json:"number,omitempty" yaml:"number,omitempty"`
+	DefinitionID    []string          `xml:"DefId" json:"definition_ids,omitempty" yaml:"definition_ids,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
 }
 
 // NewFinalDraft returns a new FinalDraft struct
@@ -500,6 +810,10 @@ func NewFinalDraft() *FinalDraft {
 	document := new(FinalDraft)
 	document.DocumentType = "Script"
 	document.Version = "1"
+	document.Watermarking = NewWatermarking()
+	document.PageLayout = new(PageLayout)
+	document.PageLayout.PageSize = NewPageSize()
+	document.TargetScriptLength = NewTargetScriptLength()
 	return document
 }
 
@@ -529,13 +843,22 @@ func (text *Text) String() string {
 
 // String (of Paragraph) returns plain text in Fountain format for a single paragraph
 func (paragraph *Paragraph) String() string {
+	if paragraph != nil && paragraph.DualDialogue != nil {
+		return paragraph.DualDialogue.String()
+	}
 	if paragraph != nil {
 		src := []string{}
 		if paragraph.StartsNewPage == "Yes" {
 			src = append(src, "===\n\n")
 		}
-		for _, text := range paragraph.Text {
-			s := text.String()
+		if len(paragraph.Text) > 0 {
+			// NOTE: a paragraph holds a Text element for each
+			// change in style, they render as a single line.
+			runs := []string{}
+			for _, text := range paragraph.Text {
+				runs = append(runs, text.String())
+			}
+			s := strings.Join(runs, "")
 			switch paragraph.Type {
 			case SceneHeadingType:
 				s = strings.ToUpper(s)
@@ -559,6 +882,14 @@ func (paragraph *Paragraph) String() string {
 			}
 			src = append(src, s, "\n")
 		}
+		if ShowNotes && len(paragraph.ScriptNote) > 0 {
+			notes := fountainNotes(paragraph.ScriptNote)
+			if len(src) > 0 && src[len(src)-1] == "\n" {
+				src = append(src[0:len(src)-1], " "+notes, "\n")
+			} else {
+				src = append(src, notes, "\n")
+			}
+		}
 		switch paragraph.Type {
 		/*
 		   case GeneralType:
@@ -590,6 +921,18 @@ func (paragraph *Paragraph) String() string {
 	return ""
 }
 
+// PlainText (of Paragraph) returns the paragraph's text without any styling
+func (paragraph *Paragraph) PlainText() string {
+	if paragraph == nil {
+		return ""
+	}
+	src := []string{}
+	for _, text := range paragraph.Text {
+		src = append(src, text.InnerText)
+	}
+	return strings.Join(src, "")
+}
+
 // String (of Content) returns plain text in Fountain format for Content
 func (c *Content) String() string {
 	if c != nil && c.Paragraph != nil && len(c.Paragraph) > 0 {
@@ -614,21 +957,18 @@ func (tp *TitlePage) String() string {
 
 // String (of FinalDraft) returns a plan text in Fountain format for FinalDraft
 func (doc *FinalDraft) String() string {
-	if doc != nil {
-		src := []string{}
-		if doc.TitlePage != nil {
-			s := doc.TitlePage.String()
-			// FIXME: Apply screen playwide settings (e.g. PageLayout, HeaderAndFooter, etc)
-			src = append(src, s, "\n")
-		}
-		if doc.Content != nil {
-			s := doc.Content.String()
-			// FIXME: Apply screen playwide settings (e.g. PageLayout, HeaderAndFooter, etc)
-			src = append(src, s)
+	if doc == nil {
+		return ""
+	}
+	var buf strings.Builder
+	tw := NewTextWriter(&buf, doc, false)
+	if doc.Content != nil {
+		for _, paragraph := range doc.Content.Paragraph {
+			tw.WriteParagraph(paragraph)
 		}
-		return strings.Join(src, "")
 	}
-	return ""
+	tw.Close()
+	return buf.String()
 }
 
 /*
@@ -647,11 +987,15 @@ func (doc *FinalDraft) String() string {
 }
 */
 
-// Parse takes []byte and returns a FinalDraft struct and error
+// Parse takes []byte and returns a FinalDraft struct and error. A
+// document that can't be read returns a *ParseError.
 func Parse(src []byte) (*FinalDraft, error) {
 	document := new(FinalDraft)
-	err := xml.Unmarshal(src, &document)
-	return document, err
+	d := xml.NewDecoder(bytes.NewReader(src))
+	if err := d.Decode(&document); err != nil {
+		return document, newParseError(src, err, int(d.InputOffset()))
+	}
+	return document, nil
 }
 
 // ParseFile takes a filename and returns a FinalDraft struct and error
@@ -684,6 +1028,17 @@ func CleanupSelfClosingElements(src []byte) []byte {
 		"ActivateIn",
 		"Actor",
 		"Element",
+		"PageSize",
+		"Distribution",
+		"UnanchoredScriptNotes",
+		"CharacterNavigatorPreferences",
+		"AltCollection",
+		"ListItems",
+		"DisplayBoard",
+		"Tabstop",
+		"CharacterArcBeat",
+		"TagCategory",
+		"TagDefinition",
 	}
 	for _, elem := range selfClosing {
 		src = bytes.Replace(src, []byte("></"+elem+">"), []byte("/>"), -1)
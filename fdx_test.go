@@ -30,14 +30,25 @@
 package fdx
 
 import (
+	"archive/zip"
 	"bytes"
+	"compress/zlib"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+
+	// 3rd Party packages
+	"github.com/rsdoiel/fountain"
 )
 
 var (
@@ -227,13 +238,2572 @@ func TestToString(t *testing.T) {
 	doc := new(FinalDraft)
 	doc.TitlePage = titlePage
 	doc.Content = content
-	expected = fmt.Sprintf("%s\n%s", expected, expected)
+	// The title page renders as a Fountain title page block
+	expected = fmt.Sprintf("Title: %s\n\n%s", strings.TrimSpace(expected), expected)
 	result = doc.String()
 	if expected != result {
 		t.Errorf("expected %q, got %q for %T", expected, result, doc)
 	}
 }
 
+// xmlNode is a generic XML tree used to compare a source document
+// with the document rendered by ToXML().
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	CharData string     `xml:",chardata"`
+	Nodes    []xmlNode  `xml:",any"`
+}
+
+// compareXMLNodes reports differences between two trees. Attributes are
+// compared by name (empty values are treated as missing since ToXML()
+// omits some empty attributes), character data is compared with
+// surrounding whitespace trimmed and child elements are compared in order.
+func compareXMLNodes(t *testing.T, fname string, elemPath string, expected xmlNode, got xmlNode) {
+	elemPath = elemPath + "/" + expected.XMLName.Local
+	if expected.XMLName.Local != got.XMLName.Local {
+		t.Errorf("%s %s: expected element %q, got %q", fname, elemPath, expected.XMLName.Local, got.XMLName.Local)
+		return
+	}
+	attrs := map[string]string{}
+	for _, attr := range got.Attrs {
+		if attr.Value != "" {
+			attrs[attr.Name.Local] = attr.Value
+		}
+	}
+	for _, attr := range expected.Attrs {
+		if attr.Value == "" {
+			continue
+		}
+		if val, ok := attrs[attr.Name.Local]; !ok {
+			t.Errorf("%s %s: missing attribute %q", fname, elemPath, attr.Name.Local)
+		} else if val != attr.Value {
+			t.Errorf("%s %s: attribute %q expected %q, got %q", fname, elemPath, attr.Name.Local, attr.Value, val)
+		}
+		delete(attrs, attr.Name.Local)
+	}
+	for k := range attrs {
+		t.Errorf("%s %s: unexpected attribute %q", fname, elemPath, k)
+	}
+	if strings.TrimSpace(expected.CharData) != strings.TrimSpace(got.CharData) {
+		t.Errorf("%s %s: expected text %q, got %q", fname, elemPath, expected.CharData, got.CharData)
+	}
+	if len(expected.Nodes) != len(got.Nodes) {
+		t.Errorf("%s %s: expected %d child elements, got %d", fname, elemPath, len(expected.Nodes), len(got.Nodes))
+		return
+	}
+	for i := range expected.Nodes {
+		compareXMLNodes(t, fname, elemPath, expected.Nodes[i], got.Nodes[i])
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	fileList, err := filepath.Glob(path.Join("testdata", "*.fdx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileList) == 0 {
+		t.Fatalf("no fdx files found in testdata")
+	}
+	for _, fname := range fileList {
+		src, err := ioutil.ReadFile(fname)
+		if err != nil {
+			t.Errorf("%s, %s", fname, err)
+			continue
+		}
+		document, err := Parse(src)
+		if err != nil {
+			t.Errorf("%s, %s", fname, err)
+			continue
+		}
+		src2, err := document.ToXML()
+		if err != nil {
+			t.Errorf("%s, %s", fname, err)
+			continue
+		}
+		expected, got := xmlNode{}, xmlNode{}
+		if err := xml.Unmarshal(src, &expected); err != nil {
+			t.Errorf("%s, %s", fname, err)
+			continue
+		}
+		if err := xml.Unmarshal(src2, &got); err != nil {
+			t.Errorf("%s (round trip), %s", fname, err)
+			continue
+		}
+		compareXMLNodes(t, fname, "", expected, got)
+	}
+
+	// Mixed content keeps its order and empty attributes are kept
+	document, err := Parse([]byte(`<FinalDraft Foo="" Template="" Version="5">
+  <Content>
+    <Paragraph Type="Action" Alignment=""><Text>a</Text></Paragraph>
+  </Content>
+  <Unknown Q="">one<x>in<z/>side</x>two<y/>three</Unknown>
+</FinalDraft>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		src, err := document.ToXML()
+		if err != nil {
+			t.Fatal(err)
+		}
+		compact := regexp.MustCompile(`\n\s*`).ReplaceAllString(string(src), "")
+		for _, expected := range []string{
+			`<FinalDraft Template="" Version="5" Foo="">`,
+			`<Paragraph Type="Action" Alignment="">`,
+			`<Unknown Q="">one<x>in<z></z>side</x>two<y></y>three</Unknown>`,
+		} {
+			if !strings.Contains(compact, expected) {
+				t.Errorf("expected %s in\n%s", expected, src)
+			}
+		}
+		if document, err = Parse(src); err != nil {
+			t.Fatal(err)
+		}
+	}
+	document.Content.Paragraph[0].Alignment = "Center"
+	if src, err := document.ToXML(); err != nil || !bytes.Contains(src, []byte(`<Paragraph Type="Action" Alignment="Center">`)) {
+		t.Errorf("expected Alignment to be written once, got %s, %v", src, err)
+	}
+
+	// Character data is written in order ahead of the children
+	type mixed struct {
+		Text1 string `xml:",chardata"`
+		A     string `xml:"a"`
+		Text2 string `xml:",chardata"`
+	}
+	buf := new(bytes.Buffer)
+	e := xml.NewEncoder(buf)
+	if err := encodeOrdered(e, xml.StartElement{Name: xml.Name{Local: "m"}}, &mixed{Text1: "one", A: "x", Text2: "two"}, []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	e.Flush()
+	if buf.String() != "<m>onetwo<a>x</a></m>" {
+		t.Errorf("expected <m>onetwo<a>x</a></m>, got %s", buf.String())
+	}
+}
+
+func TestPageSetup(t *testing.T) {
+	fname := path.Join("testdata", "sample-01.fdx")
+	document, err := ParseFile(fname)
+	if err != nil {
+		t.Fatalf("%s, %s", fname, err)
+	}
+	if document.Watermarking == nil {
+		t.Fatalf("%s, expected Watermarking", fname)
+	}
+	if got := document.Watermarking.OpacityPercent(); got != 70 {
+		t.Errorf("%s, expected opacity 70, got %d", fname, got)
+	}
+	if document.Watermarking.Position != DiagonalDescendingPosition {
+		t.Errorf("%s, expected position %q, got %q", fname, DiagonalDescendingPosition, document.Watermarking.Position)
+	}
+	if width, height := document.PageSize(); width != 8.5 || height != 11 {
+		t.Errorf("%s, expected 8.5 x 11 page, got %g x %g", fname, width, height)
+	}
+	if got := document.TargetPages(); got != 120 {
+		t.Errorf("%s, expected target length 120, got %d", fname, got)
+	}
+
+	document.SetWatermark("REVIEW DRAFT\nDo not copy", 40, HorizontalPosition)
+	document.SetPageSize(8.27, 11.69)
+	document.SetTargetPages(90)
+	src, err := document.ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err = Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := "REVIEW DRAFT\nDo not copy", document.WatermarkText(); expected != got {
+		t.Errorf("expected watermark %q, got %q", expected, got)
+	}
+	if got := document.Watermarking.OpacityPercent(); got != 40 {
+		t.Errorf("expected opacity 40, got %d", got)
+	}
+	if document.Watermarking.Position != HorizontalPosition {
+		t.Errorf("expected position %q, got %q", HorizontalPosition, document.Watermarking.Position)
+	}
+	if width, height := document.PageSize(); width != 8.27 || height != 11.69 {
+		t.Errorf("expected 8.27 x 11.69 page, got %g x %g", width, height)
+	}
+	if got := document.TargetPages(); got != 90 {
+		t.Errorf("expected target length 90, got %d", got)
+	}
+
+	document = NewFinalDraft()
+	if document.Watermarking == nil || document.PageLayout == nil || document.PageLayout.PageSize == nil || document.TargetScriptLength == nil {
+		t.Fatalf("expected NewFinalDraft() to populate Watermarking, PageSize and TargetScriptLength")
+	}
+	src, err = document.ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`<PageSize Height="11.00" Width="8.50"/>`, `<TargetScriptLength>120</TargetScriptLength>`, `<Watermarking Opacity="70" Position="Diagonal Descending">`} {
+		if !bytes.Contains(src, []byte(expected)) {
+			t.Errorf("expected %s in\n%s", expected, src)
+		}
+	}
+}
+
+func TestArcBeats(t *testing.T) {
+	fname := path.Join("testdata", "sample-03.fdx")
+	document, err := ParseFile(fname)
+	if err != nil {
+		t.Fatalf("%s, %s", fname, err)
+	}
+	scenes := document.ArcBeats()
+	if len(scenes) != 1 {
+		t.Fatalf("%s, expected 1 scene, got %d", fname, len(scenes))
+	}
+	if scenes[0].Heading != "INT. STUDIO APARTMENT - NIGHT" {
+		t.Errorf("%s, unexpected heading %q", fname, scenes[0].Heading)
+	}
+	if len(scenes[0].Characters) != 1 || scenes[0].Characters[0] != "AUTHOR" {
+		t.Errorf("%s, expected [AUTHOR], got %+v", fname, scenes[0].Characters)
+	}
+	paragraph := scenes[0].Paragraph
+	paragraph.AddArcBeat("EDITOR")
+	paragraph.AddArcBeat("author")
+	if got := paragraph.ArcBeats(); len(got) != 2 || got[1] != "EDITOR" {
+		t.Errorf("expected [AUTHOR EDITOR], got %+v", got)
+	}
+	if paragraph.RemoveArcBeat("Author") == false {
+		t.Errorf("expected to remove AUTHOR")
+	}
+	if got := paragraph.ArcBeats(); len(got) != 1 || got[0] != "EDITOR" {
+		t.Errorf("expected [EDITOR], got %+v", got)
+	}
+}
+
+func TestHeaderTabstops(t *testing.T) {
+	fname := path.Join("testdata", "sample-03.fdx")
+	document, err := ParseFile(fname)
+	if err != nil {
+		t.Fatalf("%s, %s", fname, err)
+	}
+	paragraph := &document.HeaderAndFooter.Header.Paragraph[0]
+	if paragraph.Tabstops == nil || len(paragraph.Tabstops.Tabstop) != 2 {
+		t.Fatalf("%s, expected two header tabstops", fname)
+	}
+	if got := document.HeaderText(1, ""); got != "" {
+		t.Errorf("%s, expected no header on first page, got %q", fname, got)
+	}
+	// Right aligned, no tabs.
+	expected := strings.Repeat(" ", 61) + "2."
+	if got := document.HeaderText(2, ""); got != expected {
+		t.Errorf("%s, expected header %q, got %q", fname, expected, got)
+	}
+	// Labels (Last Revised, Page #) are followed by the text,
+	// center tabstop at 4.50 and right tabstop at 7.25, left indent 1.25
+	paragraph.Text[0].InnerText = "\tDRAFT\t."
+	expected = "3/3/203" + strings.Repeat(" ", 24) + "DRAFT" + strings.Repeat(" ", 23) + "."
+	if got := document.HeaderText(3, "3/3/20"); got != expected {
+		t.Errorf("%s, expected header %q, got %q", fname, expected, got)
+	}
+	if got := document.FooterText(2, ""); got != "" {
+		t.Errorf("%s, expected no footer, got %q", fname, got)
+	}
+}
+
+func TestNotes(t *testing.T) {
+	fname := path.Join("testdata", "sample-03.fdx")
+	document, err := ParseFile(fname)
+	if err != nil {
+		t.Fatalf("%s, %s", fname, err)
+	}
+	if notes := document.Notes(); len(notes) != 0 {
+		t.Errorf("%s, expected no notes, got %d", fname, len(notes))
+	}
+	document.ScriptNoteDefinitions = &ScriptNoteDefinitions{
+		ScriptNoteDefinition: []*ScriptNoteDefinition{
+			&ScriptNoteDefinition{ID: "1", Name: "Plot", Color: "#FFFF00000000", Marker: "P"},
+		},
+	}
+	// Paragraph 2 is the action "The AUTHOR sits at a desk."
+	anchor := document.Content.Paragraph[2]
+	document.AddNote(anchor, 4, 6, "1", "Who is the author?")
+	document.AddUnanchoredNote("", "Check the running time")
+
+	src, err := document.ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err = Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := document.Notes()
+	if len(notes) != 2 {
+		t.Fatalf("expected 2 notes, got %d", len(notes))
+	}
+	note := notes[0]
+	if note.Index != 2 || note.Paragraph != document.Content.Paragraph[2] {
+		t.Errorf("expected note anchored to paragraph 2, got %d", note.Index)
+	}
+	if note.Name != "Plot" || note.Color != "#FFFF00000000" || note.Start != 4 || note.Length != 6 || note.Text != "Who is the author?" {
+		t.Errorf("unexpected note %+v", note)
+	}
+	if notes[1].Index != -1 || notes[1].ID == note.ID || notes[1].Text != "Check the running time" {
+		t.Errorf("unexpected unanchored note %+v", notes[1])
+	}
+
+	ShowNotes = true
+	txt := document.String()
+	ShowNotes = false
+	for _, expected := range []string{"The AUTHOR sits at a desk. [[Who is the author?]]\n", "\n[[Check the running time]]\n"} {
+		if strings.Contains(txt, expected) == false {
+			t.Errorf("expected %q in\n%s", expected, txt)
+		}
+	}
+	if strings.Contains(document.String(), "[[") {
+		t.Errorf("expected notes to be hidden when ShowNotes is false")
+	}
+
+	if document.RemoveNote(note.ScriptNote) == false {
+		t.Errorf("expected to remove note %s", note.ID)
+	}
+	if notes = document.Notes(); len(notes) != 1 || notes[0].Index != -1 {
+		t.Errorf("expected only the unanchored note to remain, got %+v", notes)
+	}
+
+	// Notes on dual dialogue speeches
+	document, err = Parse([]byte(`<FinalDraft>
+  <Content>
+    <Paragraph Type="Action"><Text>They argue.</Text></Paragraph>
+    <Paragraph>
+      <DualDialogue>
+        <Paragraph Type="Character"><Text>ANNA</Text></Paragraph>
+        <Paragraph Type="Dialogue"><Text>Yes.</Text></Paragraph>
+        <Paragraph Type="Character"><Text>BEN</Text></Paragraph>
+        <Paragraph Type="Dialogue">
+          <ScriptNote ID="3" Range="0,3">
+            <Paragraph><Text>Louder</Text></Paragraph>
+          </ScriptNote>
+          <Text>No.</Text>
+        </Paragraph>
+      </DualDialogue>
+    </Paragraph>
+  </Content>
+</FinalDraft>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes = document.Notes()
+	if len(notes) != 1 || notes[0].Index != 1 || notes[0].Text != "Louder" || notes[0].Paragraph.PlainText() != "No." {
+		t.Fatalf("expected the dual dialogue note, got %+v", notes)
+	}
+	if added := document.AddNote(document.Content.Paragraph[0], 0, 4, "", "Who?"); added.ID != "4" {
+		t.Errorf("expected note ID 4, got %s", added.ID)
+	}
+	if document.RemoveNote(notes[0].ScriptNote) == false {
+		t.Errorf("expected to remove the dual dialogue note")
+	}
+	if notes = document.Notes(); len(notes) != 1 || notes[0].Text != "Who?" {
+		t.Errorf("expected only the action note to remain, got %+v", notes)
+	}
+}
+
+func TestStringToTextArray(t *testing.T) {
+	type run struct {
+		text  string
+		style string
+	}
+	testData := map[string][]run{
+		"Hello World!":                        []run{{"Hello World!", ""}},
+		"*italic*":                            []run{{"italic", "Italic"}},
+		"**bold** text":                       []run{{"bold", "Bold"}, {" text", ""}},
+		"a ***bold italic*** b":               []run{{"a ", ""}, {"bold italic", "Bold+Italic"}, {" b", ""}},
+		"_**bold underline**_":                []run{{"bold underline", "Bold+Underline"}},
+		"*italic **bold italic** italic*":     []run{{"italic ", "Italic"}, {"bold italic", "Bold+Italic"}, {" italic", "Italic"}},
+		"~~struck~~ out":                      []run{{"struck", "Strikethrough"}, {" out", ""}},
+		`\*not italic\*`:                      []run{{"*not italic*", ""}},
+		"2 * 3 * 4":                           []run{{"2 * 3 * 4", ""}},
+		"an *unclosed marker":                 []run{{"an *unclosed marker", ""}},
+		"~lyric":                              []run{{"~lyric", ""}},
+		"":                                    []run{{"", ""}},
+		"_under *both*_ **bold \\_ escaped**": []run{{"under ", "Underline"}, {"both", "Italic+Underline"}, {" ", ""}, {"bold _ escaped", "Bold"}},
+		"NAME: ______":                        []run{{"NAME: ______", ""}},
+		"a__b":                                []run{{"a__b", ""}},
+		"__init__":                            []run{{"__init__", ""}},
+		"snake_case_name":                     []run{{"snake_case_name", ""}},
+		"_a_ and _b_":                         []run{{"a", "Underline"}, {" and ", ""}, {"b", "Underline"}},
+		"(_aside_)":                           []run{{"(", ""}, {"aside", "Underline"}, {")", ""}},
+		"**_**":                               []run{{"**_**", ""}},
+		"***bold italic** italic*":            []run{{"bold italic", "Bold+Italic"}, {" italic", "Italic"}},
+		"***bold italic* bold**":              []run{{"bold italic", "Bold+Italic"}, {" bold", "Bold"}},
+		"**bold *bold italic***":              []run{{"bold ", "Bold"}, {"bold italic", "Bold+Italic"}},
+		"*italic **bold italic***":            []run{{"italic ", "Italic"}, {"bold italic", "Bold+Italic"}},
+	}
+	for src, expected := range testData {
+		a := StringToTextArray(src)
+		if len(a) != len(expected) {
+			t.Errorf("%q, expected %d runs, got %d", src, len(expected), len(a))
+			continue
+		}
+		for i, text := range a {
+			if text.InnerText != expected[i].text || text.Style != expected[i].style {
+				t.Errorf("%q, run %d expected (%q, %q), got (%q, %q)", src, i, expected[i].text, expected[i].style, text.InnerText, text.Style)
+			}
+		}
+	}
+
+	// Styled runs render back to Fountain on a single line
+	paragraph := new(Paragraph)
+	paragraph.Type = ActionType
+	paragraph.Text = StringToTextArray("She is **very** _tired_.")
+	expected := "She is **very** _tired_.\n\n"
+	if got := paragraph.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestFountainTitlePage(t *testing.T) {
+	src := []byte(`Title: _**SAMPLE**_
+Author: Jane Doe
+Draft date: 2018-01-01
+Copyright: (c) 2018
+Genre: Drama
+Contact:
+	ACME
+	1234 5th Avenue
+
+INT. HOUSE - DAY
+
+She sits.
+`)
+	screenplay, err := fountain.Parse(src)
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	document := NewFinalDraft()
+	document.FromFountain(screenplay)
+	if document.TitlePage == nil || document.TitlePage.Content == nil {
+		t.Errorf("expected a title page")
+		t.FailNow()
+	}
+	expected := []struct {
+		text, alignment, style, spaceBefore string
+	}{
+		{"SAMPLE", CenterAlignment, "Bold+Underline", titleSpaceBefore},
+		{DefaultCredit, CenterAlignment, "", creditSpaceBefore},
+		{"Jane Doe", CenterAlignment, "", authorSpaceBefore},
+		{"2018-01-01", RightAlignment, "", bottomBlockSpaceBefore},
+		{"(c) 2018", LeftAlignment, "", bottomLineSpaceBefore},
+		{"Genre: Drama", LeftAlignment, "", bottomLineSpaceBefore},
+		{"ACME\n1234 5th Avenue", LeftAlignment, "", bottomLineSpaceBefore},
+	}
+	paragraphs := document.TitlePage.Content.Paragraph
+	if len(paragraphs) != len(expected) {
+		t.Errorf("expected %d paragraphs, got %d", len(expected), len(paragraphs))
+		t.FailNow()
+	}
+	for i, e := range expected {
+		p := paragraphs[i]
+		if p.PlainText() != e.text || p.Alignment != e.alignment || p.SpaceBefore != e.spaceBefore || p.Text[0].Style != e.style {
+			t.Errorf("paragraph %d expected (%q, %q, %q, %q), got (%q, %q, %q, %q)", i, e.text, e.alignment, e.style, e.spaceBefore, p.PlainText(), p.Alignment, p.Text[0].Style, p.SpaceBefore)
+		}
+	}
+}
+
+func TestTitlePageFields(t *testing.T) {
+	document, err := ParseFile(path.Join("testdata", "sample-03.fdx"))
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	expected := map[string]string{
+		"Title":      "_SAMPLE 03_",
+		"Credit":     "Written by",
+		"Author":     "Jane Doe",
+		"Draft date": "2018-01-01",
+		"Copyright":  "Copyright (c) 2018",
+		"Contact":    "ACME Examples Productions\n1234 5th Avenue\nAnytown, Planet Earth, 12345-7890",
+	}
+	fields := document.TitlePage.Fields()
+	if len(fields) != len(expected) {
+		t.Errorf("expected %d fields, got %+v", len(expected), fields)
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("%q expected %q, got %q", key, value, fields[key])
+		}
+	}
+	if s := document.String(); strings.HasPrefix(s, "Title: _SAMPLE 03_\nCredit: Written by\n") == false {
+		t.Errorf("expected a Fountain title page block, got %q", s)
+	}
+
+	// Fountain to Final Draft and back again
+	src := []byte(`Title: The Long Way
+Credit: Screenplay by
+Author: Jane Doe
+Source: Based on the novel
+Draft date: March 3, 2018
+Notes: Second pass
+Genre: Drama
+Contact:
+	ACME
+	1234 5th Avenue
+
+INT. HOUSE - DAY
+
+She sits.
+`)
+	screenplay, err := fountain.Parse(src)
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	document = NewFinalDraft()
+	document.FromFountain(screenplay)
+	expected = map[string]string{
+		"Title":      "The Long Way",
+		"Credit":     "Screenplay by",
+		"Author":     "Jane Doe",
+		"Source":     "Based on the novel",
+		"Draft date": "March 3, 2018",
+		"Notes":      "Second pass",
+		"Genre":      "Drama",
+		"Contact":    "ACME\n1234 5th Avenue",
+	}
+	fields = document.TitlePage.Fields()
+	if len(fields) != len(expected) {
+		t.Errorf("expected %d fields, got %+v", len(expected), fields)
+	}
+	for key, value := range expected {
+		if fields[key] != value {
+			t.Errorf("%q expected %q, got %q", key, value, fields[key])
+		}
+	}
+	block := FountainTitlePage(fields)
+	if strings.HasSuffix(block, "Contact:\n\tACME\n\t1234 5th Avenue\nGenre: Drama\n") == false {
+		t.Errorf("unexpected title page block %q", block)
+	}
+}
+
+// fountainType returns the Fountain element type name expected
+// for a Final Draft paragraph.
+func fountainType(paragraph *Paragraph) string {
+	switch paragraph.Type {
+	case SceneHeadingType, CharacterType, ParentheticalType, DialogueType, TransitionType:
+		if paragraph.Type == TransitionType && strings.HasSuffix(paragraph.PlainText(), ":") {
+			// NOTE: fountain v1.0.1 reads transitions ending in
+			// ":" (e.g. "CUT TO:") as action.
+			return ActionType
+		}
+		return paragraph.Type
+	case SingingType:
+		return "Lyric"
+	}
+	return ActionType
+}
+
+func TestToFountain(t *testing.T) {
+	fileList, err := filepath.Glob(path.Join("testdata", "*.fdx"))
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	for _, fname := range fileList {
+		document, err := ParseFile(fname)
+		if err != nil {
+			t.Errorf("%s, %s", fname, err)
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if err := document.ToFountain(buf); err != nil {
+			t.Errorf("%s, %s", fname, err)
+			continue
+		}
+		screenplay, err := fountain.Parse(buf.Bytes())
+		if err != nil {
+			t.Errorf("%s, %s", fname, err)
+			continue
+		}
+		expected := []string{}
+		for _, paragraph := range document.Content.Paragraph {
+			if strings.TrimSpace(paragraph.PlainText()) != "" {
+				expected = append(expected, fountainType(paragraph))
+			}
+		}
+		got := []string{}
+		for _, elem := range screenplay.Elements {
+			switch {
+			case elem.Type == fountain.EmptyType || elem.Type == fountain.PageFeed:
+			case strings.HasPrefix(elem.Content, ">") && strings.HasSuffix(elem.Content, "<"):
+				// Centered text is action
+				got = append(got, ActionType)
+			default:
+				got = append(got, elem.TypeName())
+			}
+		}
+		if strings.Join(expected, ", ") != strings.Join(got, ", ") {
+			t.Errorf("%s, expected types\n%s\ngot\n%s\n%s", fname, expected, got, buf.String())
+		}
+
+		// Fountain back to Final Draft keeps the types and text
+		document2 := NewFinalDraft()
+		document2.FromFountain(screenplay)
+		types, texts := []string{}, []string{}
+		for _, paragraph := range document.Content.Paragraph {
+			if s := strings.TrimSpace(paragraph.PlainText()); s != "" {
+				if paragraph.Type == SceneHeadingType || paragraph.Type == TransitionType {
+					s = strings.ToUpper(s)
+				}
+				types = append(types, fountainType(paragraph))
+				texts = append(texts, s)
+			}
+		}
+		types2, texts2 := []string{}, []string{}
+		for _, paragraph := range document2.Content.Paragraph {
+			if s := strings.TrimSpace(paragraph.PlainText()); s != "" {
+				types2 = append(types2, fountainType(paragraph))
+				texts2 = append(texts2, s)
+			}
+		}
+		if strings.Join(types, ", ") != strings.Join(types2, ", ") {
+			t.Errorf("%s, expected paragraph types\n%s\ngot\n%s", fname, types, types2)
+		}
+		if strings.Join(texts, "\n") != strings.Join(texts2, "\n") {
+			t.Errorf("%s, expected text\n%s\ngot\n%s", fname, strings.Join(texts, "\n"), strings.Join(texts2, "\n"))
+		}
+	}
+
+	// Forced markers
+	document := NewFinalDraft()
+	document.Content = new(Content)
+	for _, p := range [][]string{
+		{ActionType, "Opening credits."},
+		{SceneHeadingType, "the lab"},
+		{ShotType, "close on the beaker"},
+		{ActionType, "BOOM."},
+		{CharacterType, "McGee"},
+		{DialogueType, "It *worked*, 2 * 3!"},
+		{TransitionType, "Fade out"},
+		{GeneralType, "INT. is how a heading starts"},
+	} {
+		paragraph := new(Paragraph)
+		paragraph.Type = p[0]
+		paragraph.Text = StringToTextArray(p[1])
+		document.Content.Paragraph = append(document.Content.Paragraph, paragraph)
+	}
+	expected := `===
+
+Opening credits.
+
+.THE LAB
+
+!CLOSE ON THE BEAKER
+
+!BOOM.
+
+@McGee
+It *worked*, 2 \* 3!
+
+>FADE OUT
+
+!INT. is how a heading starts
+`
+	buf := new(bytes.Buffer)
+	if err := document.ToFountain(buf); err != nil {
+		t.Errorf("%s", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestDualDialogue(t *testing.T) {
+	src := []byte(`<?xml version="1.0" encoding="UTF-8" standalone="no" ?>
+<FinalDraft DocumentType="Script" Template="No" Version="5">
+  <Content>
+    <Paragraph Type="Scene Heading">
+      <Text>INT. GARAGE - DAY</Text>
+    </Paragraph>
+    <Paragraph>
+      <DualDialogue>
+        <Paragraph Type="Character">
+          <Text>BRICK</Text>
+        </Paragraph>
+        <Paragraph Type="Dialogue">
+          <Text>Screw retirement.</Text>
+        </Paragraph>
+        <Paragraph Type="Character">
+          <Text>STEEL</Text>
+        </Paragraph>
+        <Paragraph Type="Parenthetical">
+          <Text>(grinning)</Text>
+        </Paragraph>
+        <Paragraph Type="Dialogue">
+          <Text>Screw retirement.</Text>
+        </Paragraph>
+      </DualDialogue>
+    </Paragraph>
+    <Paragraph Type="Action">
+      <Text>They high five.</Text>
+    </Paragraph>
+  </Content>
+</FinalDraft>`)
+	document, err := Parse(src)
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	dd := document.Content.Paragraph[1].DualDialogue
+	if dd == nil {
+		t.Errorf("expected DualDialogue in second paragraph")
+		t.FailNow()
+	}
+	if speeches := dd.Speeches(); len(speeches) != 2 || len(speeches[0]) != 2 || len(speeches[1]) != 3 {
+		t.Errorf("expected two speeches of 2 and 3 paragraphs, got %+v", speeches)
+	}
+	if out, err := document.ToXML(); err != nil {
+		t.Errorf("%s", err)
+	} else if bytes.Count(out, []byte("<DualDialogue>")) != 1 {
+		t.Errorf("expected DualDialogue in XML, got\n%s", out)
+	}
+
+	// Side by side text columns
+	width := (MaxLineWidth-DualDialogueGutter)/2 + DualDialogueGutter
+	expected := strings.Join([]string{
+		"BRICK" + strings.Repeat(" ", width-5) + "STEEL",
+		"Screw retirement." + strings.Repeat(" ", width-17) + "(grinning)",
+		strings.Repeat(" ", width) + "Screw retirement.",
+		"",
+		"",
+	}, "\n")
+	if s := dd.String(); s != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, s)
+	}
+
+	// Fountain and back again
+	buf := new(bytes.Buffer)
+	if err := document.ToFountain(buf); err != nil {
+		t.Errorf("%s", err)
+	}
+	expected = `INT. GARAGE - DAY
+
+BRICK
+Screw retirement.
+
+STEEL ^
+(grinning)
+Screw retirement.
+
+They high five.
+`
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+	screenplay, err := fountain.Parse(buf.Bytes())
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	document = NewFinalDraft()
+	document.FromFountain(screenplay)
+	types := []string{}
+	for _, paragraph := range document.Content.Paragraph {
+		if paragraph.DualDialogue != nil {
+			for _, p := range paragraph.DualDialogue.Paragraph {
+				types = append(types, "^"+p.Type+":"+p.PlainText())
+			}
+		} else if paragraph.Type != "Empty" {
+			types = append(types, paragraph.Type)
+		}
+	}
+	got := strings.Join(types, ", ")
+	if got != "Scene Heading, ^Character:BRICK, ^Dialogue:Screw retirement., ^Character:STEEL, ^Parenthetical:(grinning), ^Dialogue:Screw retirement., Action" {
+		t.Errorf("unexpected paragraphs from Fountain, %s", got)
+	}
+	// The blank line before "STEEL ^" is kept after the dual dialogue
+	empty := 0
+	for _, elem := range screenplay.Elements {
+		if elem.TypeName() == "Empty" {
+			empty++
+		}
+	}
+	types = []string{}
+	for _, paragraph := range document.Content.Paragraph {
+		if paragraph.DualDialogue != nil {
+			types = append(types, "^")
+		} else {
+			types = append(types, paragraph.Type)
+		}
+	}
+	if got := strings.Join(types, ", "); strings.Count(got, "Empty") != empty || !strings.Contains(got, "^, Empty") {
+		t.Errorf("expected %d Empty paragraphs, one following the dual dialogue, got %s", empty, got)
+	}
+}
+
+func TestSceneNumbers(t *testing.T) {
+	document, err := ParseFile(path.Join("testdata", "sample-02.fdx"))
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	numbers := func() string {
+		a := []string{}
+		for _, paragraph := range document.SceneHeadings() {
+			a = append(a, paragraph.Number)
+		}
+		return strings.Join(a, ",")
+	}
+	document.NumberScenes()
+	if s := numbers(); s != "1,2,3" {
+		t.Errorf("expected 1,2,3, got %s", s)
+	}
+
+	// Add scenes to a locked script
+	document.LockSceneNumbers()
+	if document.SceneNumbersLocked() == false {
+		t.Errorf("expected scene numbers to be locked")
+	}
+	heading := func(s string) *Paragraph {
+		paragraph := new(Paragraph)
+		paragraph.Type = SceneHeadingType
+		paragraph.Text = StringToTextArray(s)
+		return paragraph
+	}
+	paragraphs := []*Paragraph{heading("EXT. STREET - DAY")}
+	for _, paragraph := range document.Content.Paragraph {
+		paragraphs = append(paragraphs, paragraph)
+		if paragraph.PlainText() == "CROSS FADE TO:" {
+			paragraphs = append(paragraphs, heading("INT. LOBBY - NIGHT"), heading("INT. ELEVATOR - NIGHT"))
+		}
+	}
+	document.Content.Paragraph = paragraphs
+	document.NumberScenes()
+	if s := numbers(); s != "A1,1,1A,1B,2,3" {
+		t.Errorf("expected A1,1,1A,1B,2,3, got %s", s)
+	}
+
+	// Numbers out of order or typed by hand are not reused
+	locked := NewFinalDraft()
+	locked.Content = new(Content)
+	locked.Content.Paragraph = []*Paragraph{heading("INT. ONE - DAY"), heading("INT. TWO - DAY"), heading("INT. THREE - DAY"), heading("INT. FOUR - DAY")}
+	for i, number := range []string{"5", "3", "", "3a"} {
+		locked.Content.Paragraph[i].Number = number
+	}
+	locked.SceneNumberOptions = new(SceneNumberOptions)
+	locked.SceneNumberOptions.Locked = "Yes"
+	locked.NumberScenes()
+	if s := locked.SceneHeadings()[2].Number; s != "3B" {
+		t.Errorf("expected 3B, got %s", s)
+	}
+
+	// Render on the left and right in text output
+	line := padTo("1A", SceneNumberWidth) + "INT. LOBBY - NIGHT"
+	line = padTo(line, MaxLineWidth-2) + "1A\n"
+	if s := document.String(); strings.Contains(s, line) == false {
+		t.Errorf("expected %q in\n%s", line, s)
+	}
+	document.SceneNumberOptions.ShowNumbersOnLeft = "No"
+	document.SceneNumberOptions.ShowNumbersOnRight = "No"
+	if s := document.String(); strings.Contains(s, "\nINT. LOBBY - NIGHT\n") == false {
+		t.Errorf("expected an unnumbered scene heading in\n%s", s)
+	}
+
+	// Fountain scene numbers and back again
+	buf := new(bytes.Buffer)
+	if err := document.ToFountain(buf); err != nil {
+		t.Errorf("%s", err)
+	}
+	if strings.Contains(buf.String(), "\nINT. LOBBY - NIGHT #1A#\n") == false {
+		t.Errorf("expected a Fountain scene number in\n%s", buf.String())
+	}
+	screenplay, err := fountain.Parse(buf.Bytes())
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	document2 := NewFinalDraft()
+	document2.FromFountain(screenplay)
+	for i, paragraph := range document2.SceneHeadings() {
+		expected := document.SceneHeadings()[i]
+		if paragraph.Number != expected.Number || paragraph.PlainText() != expected.PlainText() {
+			t.Errorf("expected (%q, %q), got (%q, %q)", expected.Number, expected.PlainText(), paragraph.Number, paragraph.PlainText())
+		}
+	}
+
+	// Unlocked scripts are renumbered
+	document.UnlockSceneNumbers()
+	document.NumberScenes()
+	if s := numbers(); s != "1,2,3,4,5,6" {
+		t.Errorf("expected 1,2,3,4,5,6, got %s", s)
+	}
+
+	// Letters run A to Z then AA, AB, etc.
+	for prev, expected := range map[string]string{
+		"12":   "12A",
+		"12A":  "12B",
+		"12Y":  "12Z",
+		"12Z":  "12AA",
+		"12AA": "12AB",
+		"12AZ": "12BA",
+		"12ZZ": "12AAA",
+		"A1":   "A1A",
+	} {
+		if s := insertNumber(prev, map[string]bool{}); s != expected {
+			t.Errorf("expected %s after %s, got %s", expected, prev, s)
+		}
+	}
+	if s := insertNumber("12Z", map[string]bool{"12AA": true, "12AB": true}); s != "12AC" {
+		t.Errorf("expected 12AC after 12Z when 12AA and 12AB are used, got %s", s)
+	}
+	if s := insertNumber("12", map[string]bool{"12A": true}); s != "12B" {
+		t.Errorf("expected 12B after 12 when 12A is used, got %s", s)
+	}
+}
+
+func TestPages(t *testing.T) {
+	document, err := ParseFile(path.Join("testdata", "sample-06.fdx"))
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	if n := document.LinesPerPage(); n != 53 {
+		t.Errorf("expected 53 lines per page, got %d", n)
+	}
+	pages := document.Pages()
+	if len(pages) != 2 {
+		t.Errorf("expected 2 pages, got %d", len(pages))
+		t.FailNow()
+	}
+	// The third scene starts a new page
+	line := pages[1].Lines[0]
+	if line.Type != SceneHeadingType || line.Text != "INT. STUDIO APARTMENT - NEXT DAY" || line.Y != 0 || pages[1].Number != 2 {
+		t.Errorf("unexpected first line of page 2, %+v", line)
+	}
+	// Action follows the scene heading after SpaceBefore (12pt), at
+	// the Action LeftIndent
+	line = pages[0].Lines[1]
+	if line.Type != ActionType || line.Y != 2 || line.X != 1.25 {
+		t.Errorf("unexpected second line of page 1, %+v", line)
+	}
+
+	// A long script
+	document = NewFinalDraft()
+	document.Content = new(Content)
+	add := func(elementType string, s string) {
+		paragraph := new(Paragraph)
+		paragraph.Type = elementType
+		paragraph.Text = StringToTextArray(s)
+		document.Content.Paragraph = append(document.Content.Paragraph, paragraph)
+	}
+	sentence := "The programmer types and types."
+	for i := 0; i < 12; i++ {
+		add(SceneHeadingType, fmt.Sprintf("INT. OFFICE %d - NIGHT", i))
+		add(ActionType, strings.Repeat(sentence+" ", 2+i%4*5))
+		add(CharacterType, "PROGRAMMER")
+		add(ParentheticalType, "(drowsy)")
+		add(DialogueType, strings.Repeat("What algorithm is this? ", 3+i%3*3))
+	}
+	linesPerPage := float64(document.LinesPerPage())
+	pages = document.Pages()
+	if len(pages) < 2 {
+		t.Errorf("expected several pages, got %d", len(pages))
+		t.FailNow()
+	}
+	seen := map[int]int{}
+	for _, page := range pages {
+		last := page.Lines[len(page.Lines)-1]
+		if last.Y >= linesPerPage {
+			t.Errorf("page %d overflows, %+v", page.Number, last)
+		}
+		switch last.Type {
+		case SceneHeadingType, CharacterType, ParentheticalType:
+			t.Errorf("page %d ends with a %s", page.Number, last.Type)
+		}
+		for _, line := range page.Lines {
+			seen[line.Paragraph]++
+		}
+		// Split paragraphs leave at least MinSplitLines on each page
+		for _, i := range page.Paragraphs() {
+			n := 0
+			for _, line := range page.Lines {
+				if line.Paragraph == i {
+					n++
+				}
+			}
+			if total := len(document.layoutParagraph(document.Content.Paragraph[i], i).lines); n < total && n < MinSplitLines {
+				t.Errorf("page %d holds %d of %d lines of paragraph %d", page.Number, n, total, i)
+			}
+		}
+	}
+	if len(seen) != len(document.Content.Paragraph) {
+		t.Errorf("expected all %d paragraphs laid out, got %d", len(document.Content.Paragraph), len(seen))
+	}
+	split := 0
+	for i := 1; i < len(pages); i++ {
+		if pages[i].Lines[0].Paragraph == pages[i-1].Lines[len(pages[i-1].Lines)-1].Paragraph {
+			split++
+		}
+	}
+	if split == 0 {
+		t.Errorf("expected paragraphs split across pages")
+	}
+
+	// Breaking at sentences
+	document.PageLayout.BreakDialogueAndActionAtSentences = "Yes"
+	for _, page := range document.Pages() {
+		last := page.Lines[len(page.Lines)-1]
+		if page != pages[len(pages)-1] && (last.Type == ActionType || last.Type == DialogueType) && endsSentence(last.Text) == false {
+			t.Errorf("page %d breaks mid sentence, %q", page.Number, last.Text)
+		}
+	}
+
+	// A paragraph longer than a page without a sentence to break at
+	// is broken where it must, without an empty page after it
+	long := strings.TrimSpace(strings.Repeat("word ", 1200))
+	for _, layout := range []string{
+		`<PageLayout BreakDialogueAndActionAtSentences="Yes"/>`,
+		`<PageLayout TopMargin="700" BottomMargin="60"/>`,
+	} {
+		document, err = Parse([]byte(`<FinalDraft><Content>
+  <Paragraph Type="Action"><Text>Before.</Text></Paragraph>
+  <Paragraph Type="Action" Spacing="3"><Text>` + long + `</Text></Paragraph>
+</Content>` + layout + `</FinalDraft>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows := document.layoutParagraph(document.Content.Paragraph[1], 1).rows
+		placed := 0
+		for _, page := range document.Pages() {
+			if len(page.Lines) == 0 {
+				t.Errorf("%s, unexpected empty page %d", layout, page.Number)
+			}
+			for _, line := range page.Lines {
+				if line.Paragraph == 1 {
+					placed++
+				}
+			}
+		}
+		if placed != rows {
+			t.Errorf("%s, expected %d rows placed, got %d", layout, rows, placed)
+		}
+	}
+}
+
+func TestMoresAndContinueds(t *testing.T) {
+	document := NewFinalDraft()
+	document.Content = new(Content)
+	add := func(elementType string, s string) {
+		paragraph := new(Paragraph)
+		paragraph.Type = elementType
+		paragraph.Text = StringToTextArray(s)
+		document.Content.Paragraph = append(document.Content.Paragraph, paragraph)
+	}
+	add(SceneHeadingType, "INT. OFFICE - NIGHT")
+	add(ActionType, strings.Repeat("The programmer types and types. ", 30))
+	add(CharacterType, "Programmer")
+	add(ParentheticalType, "(drowsy)")
+	add(DialogueType, strings.Repeat("What algorithm is this? ", 70))
+	add(ActionType, "The programmer yawns.")
+	add(CharacterType, "Programmer")
+	add(DialogueType, "Bedtime.")
+
+	// Final Draft's defaults, (MORE) and (CONT'D) without CONTINUED
+	pages := document.Pages()
+	if len(pages) != 2 {
+		t.Errorf("expected 2 pages, got %d", len(pages))
+		t.FailNow()
+	}
+	last := pages[0].Lines[len(pages[0].Lines)-1]
+	if last.Type != DialogueBottomType || last.Text != "(MORE)" {
+		t.Errorf("expected (MORE) at the bottom of page 1, got %+v", last)
+	}
+	first := pages[1].Lines[0]
+	if first.Type != DialogueTopType || first.Text != "PROGRAMMER (CONT'D)" || first.Y != 0 {
+		t.Errorf("expected PROGRAMMER (CONT'D) at the top of page 2, got %+v", first)
+	}
+	// The same character speaking again gets an automatic (CONT'D)
+	found := false
+	for _, line := range pages[1].Lines {
+		if line.Type == CharacterType {
+			found = true
+			if line.Text != "PROGRAMMER (CONT'D)" {
+				t.Errorf("expected an automatic continued, got %q", line.Text)
+			}
+		}
+	}
+	if found == false {
+		t.Errorf("expected the second speech on page 2")
+	}
+
+	// Settings from the document, break at sentences
+	document.PageLayout.BreakDialogueAndActionAtSentences = "Yes"
+	document.MoresAndContinueds = &MoresAndContinueds{
+		DialogueBreaks: &DialogueBreaks{
+			AutomaticCharacterContinueds: "No",
+			BottomOfPage:                 "Yes",
+			DialogueBottom:               "(more)",
+			DialogueTop:                  "(cont'd)",
+			TopOfNext:                    "Yes",
+		},
+		SceneBreaks: &SceneBreaks{
+			ContinuedNumber:   "Yes",
+			SceneBottom:       "(CONTINUED)",
+			SceneBottomOfPage: "Yes",
+			SceneTop:          "CONTINUED:",
+			SceneTopOfNext:    "Yes",
+		},
+	}
+	pages = document.Pages()
+	if len(pages) != 2 {
+		t.Errorf("expected 2 pages, got %d", len(pages))
+		t.FailNow()
+	}
+	n := len(pages[0].Lines)
+	if n < 3 || pages[0].Lines[n-2].Text != "(more)" || pages[0].Lines[n-1].Text != "(CONTINUED)" {
+		t.Errorf("expected (more) and (CONTINUED) at the bottom of page 1")
+	} else if s := pages[0].Lines[n-3].Text; endsSentence(s) == false {
+		t.Errorf("expected the dialogue to break at a sentence, got %q", s)
+	}
+	if pages[1].Lines[0].Text != "CONTINUED: (2)" || pages[1].Lines[1].Text != "PROGRAMMER (cont'd)" {
+		t.Errorf("expected CONTINUED: (2) and PROGRAMMER (cont'd) at the top of page 2, got %q, %q", pages[1].Lines[0].Text, pages[1].Lines[1].Text)
+	}
+	for _, line := range pages[1].Lines {
+		if line.Type == CharacterType && line.Text != "PROGRAMMER" {
+			t.Errorf("expected no automatic continued, got %q", line.Text)
+		}
+	}
+	linesPerPage := float64(document.LinesPerPage())
+	for _, page := range pages {
+		if last := page.Lines[len(page.Lines)-1]; last.Y >= linesPerPage {
+			t.Errorf("page %d overflows, %+v", page.Number, last)
+		}
+	}
+}
+
+func TestToPDF(t *testing.T) {
+	src := []byte(`Title: Sample
+Author: Jane Doe
+Draft date: 2018-01-01
+
+INT. HOUSE - DAY
+
+She sits (quietly) at the desk.
+
+JANE
+Café?
+`)
+	screenplay, err := fountain.Parse(src)
+	if err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	document := NewFinalDraft()
+	document.FromFountain(screenplay)
+	document.SetWatermark("DRAFT", 30, DiagonalAscendingPosition)
+	document.HeaderAndFooter = new(HeaderAndFooter)
+	document.HeaderAndFooter.HeaderVisible = "Yes"
+	paragraph := Paragraph{Alignment: RightAlignment, LeftIndent: "1.25", RightIndent: "-1.25"}
+	paragraph.DynamicLabel = []*DynamicLabel{{Type: LastRevisedType}, {Type: PageNoType}}
+	paragraph.Text = StringToTextArray(".")
+	document.HeaderAndFooter.Header.Paragraph = []Paragraph{paragraph}
+
+	buf := new(bytes.Buffer)
+	if err := document.ToPDF(buf, "3/3/20"); err != nil {
+		t.Errorf("%s", err)
+		t.FailNow()
+	}
+	out := buf.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Errorf("expected a PDF file")
+	}
+	for _, expected := range []string{"/Count 2 ", "/FontFile2 ", "/BaseFont /GoMono ", "/ca 0.3 ", "/Title (Sample)"} {
+		if !bytes.Contains(out, []byte(expected)) {
+			t.Errorf("expected PDF to contain %q", expected)
+		}
+	}
+	// Decompress the content streams
+	reStream := regexp.MustCompile(`<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`)
+	content := []string{}
+	for _, m := range reStream.FindAllSubmatchIndex(out, -1) {
+		l, _ := strconv.Atoi(string(out[m[2]:m[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(out[m[1] : m[1]+l]))
+		if err != nil {
+			t.Errorf("%s", err)
+			continue
+		}
+		data, _ := ioutil.ReadAll(r)
+		content = append(content, string(data))
+	}
+	if len(content) != 2 {
+		t.Errorf("expected two pages of content, got %d", len(content))
+		t.FailNow()
+	}
+	for i, expected := range [][]string{
+		{"(Sample) Tj", "(DRAFT) Tj"},
+		{"(INT. HOUSE - DAY) Tj", "(She sits \\(quietly\\) at the desk.) Tj", "(Caf\\351?) Tj", "(3/3/201.) Tj", "(DRAFT) Tj"},
+	} {
+		for _, s := range expected {
+			if !strings.Contains(content[i], s) {
+				t.Errorf("page %d, expected %q in\n%s", i+1, s, content[i])
+			}
+		}
+	}
+	if strings.Contains(content[0], "3/3/20") {
+		t.Errorf("expected no header on the title page")
+	}
+	if r, g, b := pdfColor("#FFFF00000000"); r != 1 || g != 0 || b != 0 {
+		t.Errorf("expected red, got %f %f %f", r, g, b)
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	fname := path.Join("testdata", "sample-03.fdx")
+	document, err := ParseFile(fname)
+	if err != nil {
+		t.Fatalf("%s, %s", fname, err)
+	}
+	document.Content.Paragraph[2].Text = StringToTextArray("The *AUTHOR* sits at a **desk** & _waits_.")
+	buf := new(bytes.Buffer)
+	if err := document.ToHTML(buf, false, true); err != nil {
+		t.Fatalf("%s, %s", fname, err)
+	}
+	src := buf.String()
+	for _, expected := range []string{
+		"<!DOCTYPE html>",
+		"<title>SAMPLE 03</title>",
+		"<style>\n.scrippet {",
+		`<li><a href="#scene-1">INT. STUDIO APARTMENT - NIGHT</a></li>`,
+		`<h1 class="title"><u>SAMPLE 03</u></h1>`,
+		`<p id="scene-1" class="sceneheader">INT. STUDIO APARTMENT - NIGHT</p>`,
+		`<p class="action">The <em>AUTHOR</em> sits at a <strong>desk</strong> &amp; <u>waits</u>.</p>`,
+		`<p class="character">AUTHOR</p>`,
+		`<p class="parenthetical">(anguished)</p>`,
+		`<p class="dialogue">Writers block again!</p>`,
+		`<p class="transition">DISSOLVES TO:</p>`,
+	} {
+		if !strings.Contains(src, expected) {
+			t.Errorf("expected %q in\n%s", expected, src)
+		}
+	}
+
+	// Fragments are only the scrippet, numbered scenes use their number
+	document.NumberScenes()
+	document.SceneHeadings()[0].Number = "12A"
+	buf.Reset()
+	if err := document.ToHTML(buf, true, false); err != nil {
+		t.Fatalf("%s, %s", fname, err)
+	}
+	src = buf.String()
+	if !strings.HasPrefix(src, `<div class="scrippet">`) || !strings.HasSuffix(src, "</div>\n") {
+		t.Errorf("expected a scrippet div, got\n%s", src)
+	}
+	if !strings.Contains(src, `<p id="scene-12A" class="sceneheader">`) {
+		t.Errorf("expected scene-12A anchor in\n%s", src)
+	}
+
+	// Scene ids are unique and only use characters allowed in ids
+	used := map[string]int{}
+	for i, expected := range []struct {
+		number string
+		id     string
+	}{
+		{"12", "scene-12"},
+		{"12", "scene-12-2"},
+		{"", "scene-12-3"},
+		{"12-2", "scene-12-2-2"},
+		{"7 A", "scene-7-A"},
+		{"7\"><b>", "scene-7---b-"},
+	} {
+		if id := sceneAnchor(&Paragraph{Number: expected.number}, 12, used); id != expected.id {
+			t.Errorf("%d: expected %q for scene number %q, got %q", i, expected.id, expected.number, id)
+		}
+	}
+}
+
+// checkSchema reports keys in v not described by the schema definition def
+func checkSchema(t *testing.T, defs map[string]interface{}, def map[string]interface{}, v interface{}, at string) {
+	if ref, ok := def["$ref"].(string); ok {
+		def = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		properties, _ := def["properties"].(map[string]interface{})
+		for key, child := range val {
+			prop, ok := properties[key].(map[string]interface{})
+			if !ok {
+				t.Errorf("%s.%s is not in the schema", at, key)
+				continue
+			}
+			checkSchema(t, defs, prop, child, at+"."+key)
+		}
+	case []interface{}:
+		items, _ := def["items"].(map[string]interface{})
+		for i, child := range val {
+			checkSchema(t, defs, items, child, fmt.Sprintf("%s[%d]", at, i))
+		}
+	case string:
+		if def["type"] != "string" {
+			t.Errorf("%s is a string, schema expects %v", at, def["type"])
+		}
+	}
+}
+
+func TestJSONAndYAML(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	src, err := ioutil.ReadFile("fdx.schema.json")
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(src), bytes.TrimSpace(schema)) {
+		t.Errorf("fdx.schema.json is out of date, regenerate it with fdx2json -schema")
+	}
+	m := map[string]interface{}{}
+	if err := json.Unmarshal(schema, &m); err != nil {
+		t.Fatalf("%s", err)
+	}
+	defs := m["$defs"].(map[string]interface{})
+	root := map[string]interface{}{"$ref": m["$ref"]}
+
+	fileList := []string{
+		"sample-01.fdx",
+		"sample-03.fdx",
+		"sample-06.fdx",
+	}
+	for _, name := range fileList {
+		fname := path.Join("testdata", name)
+		document, err := ParseFile(fname)
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		expected, _ := document.ToXML()
+
+		src, err := document.ToJSON()
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		var v interface{}
+		if err := json.Unmarshal(src, &v); err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		checkSchema(t, defs, root, v, "$")
+		if bytes.Contains(src, []byte("scene_buttom")) {
+			t.Errorf("%s, expected scene_bottom", fname)
+		}
+		fromJSON, err := ParseJSON(src)
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		if got, _ := fromJSON.ToXML(); !bytes.Equal(expected, got) {
+			t.Errorf("%s, JSON did not round trip to the same XML", fname)
+		}
+
+		src, err = document.ToYAML()
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		fromYAML, err := ParseYAML(src)
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		if got, _ := fromYAML.ToXML(); !bytes.Equal(expected, got) {
+			t.Errorf("%s, YAML did not round trip to the same XML", fname)
+		}
+	}
+
+	// Header labels after the text keep their order
+	document := NewFinalDraft()
+	src = []byte(`<FinalDraft><HeaderAndFooter><Header><Paragraph><Text>Page </Text><DynamicLabel Type="Page #"/><Text>.</Text></Paragraph></Header></HeaderAndFooter></FinalDraft>`)
+	if err := xml.Unmarshal(src, document); err != nil {
+		t.Fatalf("%s", err)
+	}
+	for _, encode := range []func() ([]byte, error){document.ToJSON, document.ToYAML} {
+		out, err := encode()
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		decoded, err := ParseJSON(out)
+		if err != nil {
+			decoded, err = ParseYAML(out)
+		}
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		if got := decoded.HeaderText(2, ""); got != "Page 2." {
+			t.Errorf("expected header label order to be kept, got %q", got)
+		}
+	}
+}
+
+func TestFadeIn(t *testing.T) {
+	for i := 1; i <= 6; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fadein", i))
+		document, err := ParseFadeInFile(fname)
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		expected, err := ParseFile(strings.TrimSuffix(fname, ".fadein") + ".fdx")
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		got, want := document.Content.Paragraph, expected.Content.Paragraph
+		if len(got) != len(want) {
+			t.Errorf("%s, expected %d paragraphs, got %d", fname, len(want), len(got))
+			continue
+		}
+		for j := range want {
+			if got[j].Type != want[j].Type || got[j].PlainText() != want[j].PlainText() {
+				t.Errorf("%s, paragraph %d expected %s %q, got %s %q", fname, j, want[j].Type, want[j].PlainText(), got[j].Type, got[j].PlainText())
+			}
+		}
+		if document.Content.String() != expected.Content.String() {
+			t.Errorf("%s, expected\n%s\ngot\n%s", fname, expected.Content.String(), document.Content.String())
+		}
+		// NOTE: Fade In's template adds a title page to samples
+		// whose .fdx has none.
+		if expected.TitlePage != nil && expected.TitlePage.Content != nil && len(expected.TitlePage.Content.Paragraph) > 0 {
+			if got, want := fmt.Sprintf("%q", document.TitlePage.Fields()), fmt.Sprintf("%q", expected.TitlePage.Fields()); got != want {
+				t.Errorf("%s, expected title page %s, got %s", fname, want, got)
+			}
+		}
+		if got, want := len(document.Pages()), len(expected.Pages()); got != want {
+			t.Errorf("%s, expected %d pages, got %d", fname, want, got)
+		}
+		if got := strings.TrimSpace(document.HeaderText(2, "")); got != "2." {
+			t.Errorf("%s, expected header \"2.\", got %q", fname, got)
+		}
+
+		// Write the .fdx as .fadein and read it back
+		buf := new(bytes.Buffer)
+		if err := expected.WriteFadeIn(buf); err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		roundTrip, err := ParseFadeIn(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		if roundTrip.Content.String() != expected.Content.String() {
+			t.Errorf("%s, WriteFadeIn expected\n%s\ngot\n%s", fname, expected.Content.String(), roundTrip.Content.String())
+		}
+		if got, want := fmt.Sprintf("%q", roundTrip.TitlePage.Fields()), fmt.Sprintf("%q", expected.TitlePage.Fields()); got != want {
+			t.Errorf("%s, WriteFadeIn expected title page %s, got %s", fname, want, got)
+		}
+	}
+	if _, err := ParseFadeIn([]byte("not a zip file")); err == nil {
+		t.Errorf("expected an error parsing a file that isn't a zip archive")
+	}
+}
+
+func TestTrelby(t *testing.T) {
+	src := []byte("\xef\xbb\xbf#Version 3\n" +
+		"#Begin-Config \n" +
+		"Paper/Type:Letter\n" +
+		"#End-Config \n" +
+		"#Title-String 0.000000,105.000000,12,cu,Courier,MY SCRIPT\n" +
+		"#Title-String 0.000000,115.000000,12,c,Courier,by\n" +
+		"#Title-String 0.000000,125.000000,12,c,Courier,Jane Doe\n" +
+		"#Title-String 25.000000,230.000000,12,,Courier,1234 5th Avenue\\nAnytown\n" +
+		"#Title-Page \n" +
+		"#Title-String 0.000000,105.000000,12,c,Courier,A C:\\\\ NOTE\n" +
+		"#Header-String 1,0,1,,${PAGE}.\n" +
+		"#Header-Empty-Lines 1\n" +
+		"#Start-Script \n" +
+		".\\INT. HOUSE - DAY\n" +
+		">.A long line of action wrapped by\n" +
+		"&.Trel\n" +
+		"+.by.\n" +
+		"|.Two spaces follow, then a forced\n" +
+		"..break.\n" +
+		"._JANE\n" +
+		".((quietly)\n" +
+		".:Hello.\n" +
+		".%A note to self.\n" +
+		"./CUT TO:\n" +
+		".=CLOSE ON JANE\n" +
+		".@END OF ACT ONE\n")
+	document, err := ParseTrelby(src)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	expected := []struct {
+		Type string
+		Text string
+	}{
+		{SceneHeadingType, "INT. HOUSE - DAY"},
+		{ActionType, "A long line of action wrapped by Trelby.  Two spaces follow, then a forced\nbreak."},
+		{CharacterType, "JANE"},
+		{ParentheticalType, "(quietly)"},
+		{DialogueType, "Hello."},
+		{NoteType, "A note to self."},
+		{TransitionType, "CUT TO:"},
+		{ShotType, "CLOSE ON JANE"},
+		{ActBreakType, "END OF ACT ONE"},
+	}
+	paragraphs := document.Content.Paragraph
+	if len(paragraphs) != len(expected) {
+		t.Fatalf("expected %d paragraphs, got %d", len(expected), len(paragraphs))
+	}
+	for i, e := range expected {
+		if paragraphs[i].Type != e.Type || paragraphs[i].PlainText() != e.Text {
+			t.Errorf("paragraph %d expected %s %q, got %s %q", i, e.Type, e.Text, paragraphs[i].Type, paragraphs[i].PlainText())
+		}
+	}
+	fields := document.TitlePage.Fields()
+	for key, value := range map[string]string{"Title": "_MY SCRIPT_", "Credit": "by", "Author": "Jane Doe", "Contact": "1234 5th Avenue\nAnytown"} {
+		if fields[key] != value {
+			t.Errorf("expected title page %s %q, got %q", key, value, fields[key])
+		}
+	}
+	last := document.TitlePage.Content.Paragraph[len(document.TitlePage.Content.Paragraph)-1]
+	if last.StartsNewPage != "Yes" || last.PlainText() != `A C:\ NOTE` {
+		t.Errorf("expected the second title page to start a new page, got %q %q", last.StartsNewPage, last.PlainText())
+	}
+	if got := strings.TrimSpace(document.HeaderText(2, "")); got != "2." {
+		t.Errorf("expected header \"2.\", got %q", got)
+	}
+
+	// Writing and reading back a Trelby file keeps the script
+	buf := new(bytes.Buffer)
+	if err := document.ToTrelby(buf); err != nil {
+		t.Fatalf("%s", err)
+	}
+	roundTrip, err := ParseTrelby(buf.Bytes())
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if got, want := roundTrip.Content.String(), document.Content.String(); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+	if got, want := fmt.Sprintf("%q", roundTrip.TitlePage.Fields()), fmt.Sprintf("%q", fields); got != want {
+		t.Errorf("expected title page %s, got %s", want, got)
+	}
+	for i := 1; i <= 6; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fdx", i))
+		document, err := ParseFile(fname)
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		buf := new(bytes.Buffer)
+		if err := document.ToTrelby(buf); err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		roundTrip, err := ParseTrelby(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		got, want := roundTrip.Content.Paragraph, document.Content.Paragraph
+		if len(got) != len(want) {
+			t.Errorf("%s, expected %d paragraphs, got %d", fname, len(want), len(got))
+			continue
+		}
+		for j := range want {
+			wantType := want[j].Type
+			if wantType == GeneralType {
+				// Trelby has no General element
+				wantType = ActionType
+			}
+			if got[j].Type != wantType || got[j].PlainText() != want[j].PlainText() {
+				t.Errorf("%s, paragraph %d expected %s %q, got %s %q", fname, j, wantType, want[j].PlainText(), got[j].Type, got[j].PlainText())
+			}
+		}
+		if got, want := fmt.Sprintf("%q", roundTrip.TitlePage.Fields()), fmt.Sprintf("%q", document.TitlePage.Fields()); got != want {
+			t.Errorf("%s, expected title page %s, got %s", fname, want, got)
+		}
+	}
+
+	for _, bad := range []string{"", "#Version 3\n#Title-String 1,2\n#Start-Script \n", "#Version 3\n#Start-Script \n.?Oops\n", "#Version 3\n"} {
+		if _, err := ParseTrelby([]byte(bad)); err == nil {
+			t.Errorf("expected an error parsing %q", bad)
+		}
+	}
+}
+
+// makeZip returns a zip archive holding files, written in the order of names
+func makeZip(t *testing.T, names []string, files map[string]string) []byte {
+	buf := new(bytes.Buffer)
+	archive := zip.NewWriter(buf)
+	for _, name := range names {
+		f, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(files[name]))
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCeltxAndHighland(t *testing.T) {
+	// Highland 2 is a zipped TextBundle holding Fountain
+	fname := path.Join("testdata", "sample-03.fountain")
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ParseFountain(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	highland := makeZip(t, []string{"sample-03.textbundle/", "sample-03.textbundle/info.json", "sample-03.textbundle/text.fountain"}, map[string]string{
+		"sample-03.textbundle/info.json":     `{"version": 2, "type": "com.quoteunquoteapps.highland.fountain"}`,
+		"sample-03.textbundle/text.fountain": string(src),
+	})
+	document, err := ParseHighland(highland)
+	if err != nil {
+		t.Fatalf("ParseHighland, %s", err)
+	}
+	if document.Content.String() != expected.Content.String() {
+		t.Errorf("ParseHighland expected\n%s\ngot\n%s", expected.Content.String(), document.Content.String())
+	}
+	if got, want := fmt.Sprintf("%q", document.TitlePage.Fields()), fmt.Sprintf("%q", expected.TitlePage.Fields()); got != want {
+		t.Errorf("ParseHighland expected title page %s, got %s", want, got)
+	}
+	if _, err := ParseHighland(makeZip(t, []string{"info.json"}, map[string]string{"info.json": "{}"})); err == nil {
+		t.Errorf("expected an error for a Highland file without text")
+	}
+
+	// Celtx is a zip holding project.rdf and the script as HTML
+	celtx := makeZip(t, []string{"project.rdf", "script-1.html", "script-2.html"}, map[string]string{
+		"project.rdf": `<?xml version="1.0"?>
+<RDF:RDF xmlns:cx="http://celtx.com/NS/v1/" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:RDF="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <RDF:Description RDF:about="http://celtx.com/res/1" dc:title="Notes" cx:localFile="script-1.html">
+    <cx:doctype RDF:resource="http://celtx.com/NS/v1/TextDocument"/>
+  </RDF:Description>
+  <RDF:Description RDF:about="http://celtx.com/res/2" dc:title="MY SCRIPT" cx:localFile="script-2.html">
+    <dc:creator>Jane Doe</dc:creator>
+    <cx:doctype RDF:resource="http://celtx.com/NS/v1/ScriptDocument"/>
+  </RDF:Description>
+</RDF:RDF>`,
+		"script-1.html": `<html><body><p class="action">Not the script</p></body></html>`,
+		"script-2.html": `<html><head><title>MY SCRIPT</title></head><body>
+<p class="sceneheading">INT. KITCHEN - DAY</p>
+<p class="action">Jane <b>stirs</b> the
+  soup&nbsp;&amp; waits.<br>Then waits <i><u>more</u></i>.</p>
+<p class="character">JANE</p>
+<p class="parenthetical">(softly)</p>
+<p class="dialog">Done.</p>
+<p class="transition">CUT TO:</p>
+<p class="shot">CLOSE ON THE SOUP</p>
+<p class="act">END OF ACT ONE</p>
+<p>Something else</p>
+</body></html>`,
+	})
+	document, err = ParseCeltx(celtx)
+	if err != nil {
+		t.Fatalf("ParseCeltx, %s", err)
+	}
+	expectedTypes := []string{SceneHeadingType, ActionType, CharacterType, ParentheticalType, DialogueType, TransitionType, ShotType, ActBreakType, GeneralType}
+	expectedText := []string{"INT. KITCHEN - DAY", "Jane stirs the soup & waits.\nThen waits more.", "JANE", "(softly)", "Done.", "CUT TO:", "CLOSE ON THE SOUP", "END OF ACT ONE", "Something else"}
+	if len(document.Content.Paragraph) != len(expectedTypes) {
+		t.Fatalf("ParseCeltx expected %d paragraphs, got %d", len(expectedTypes), len(document.Content.Paragraph))
+	}
+	for i, paragraph := range document.Content.Paragraph {
+		if paragraph.Type != expectedTypes[i] || paragraph.PlainText() != expectedText[i] {
+			t.Errorf("ParseCeltx paragraph %d expected %s %q, got %s %q", i, expectedTypes[i], expectedText[i], paragraph.Type, paragraph.PlainText())
+		}
+	}
+	styles := []string{}
+	for _, text := range document.Content.Paragraph[1].Text {
+		styles = append(styles, text.Style)
+	}
+	if got, want := strings.Join(styles, ","), ",Bold,,Italic+Underline,"; got != want {
+		t.Errorf("ParseCeltx expected styles %q, got %q", want, got)
+	}
+	if got, want := fmt.Sprintf("%q", document.TitlePage.Fields()), `map["Author":"Jane Doe" "Credit":"Written by" "Title":"MY SCRIPT"]`; got != want {
+		t.Errorf("ParseCeltx expected title page %s, got %s", want, got)
+	}
+	if _, err := ParseCeltx(makeZip(t, []string{"project.rdf"}, map[string]string{"project.rdf": "<RDF/>"})); err == nil {
+		t.Errorf("expected an error for a Celtx file without a script")
+	}
+
+	// Import picks the parser by extension
+	dir := t.TempDir()
+	for name, src := range map[string][]byte{"sample.highland": highland, "sample.celtx": celtx} {
+		fname := path.Join(dir, name)
+		if err := ioutil.WriteFile(fname, src, 0666); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Import(fname); err != nil {
+			t.Errorf("Import(%q), %s", name, err)
+		}
+	}
+	for _, name := range []string{"sample-01.fdx", "sample-01.fadein", "sample-01.fountain"} {
+		document, err := Import(path.Join("testdata", name))
+		if err != nil {
+			t.Errorf("Import(%q), %s", name, err)
+		} else if len(document.Content.Paragraph) == 0 {
+			t.Errorf("Import(%q), expected paragraphs", name)
+		}
+	}
+	fname = path.Join(dir, "sample.doc")
+	if err := ioutil.WriteFile(fname, src, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(fname); err == nil {
+		t.Errorf("expected an error importing an unsupported file type")
+	}
+}
+
+func TestDecoderAndEncoder(t *testing.T) {
+	for i := 1; i <= 6; i++ {
+		fname := path.Join("testdata", fmt.Sprintf("sample-%02d.fdx", i))
+		expected, err := ParseFile(fname)
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		want, err := expected.ToXML()
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+
+		// Stream the paragraphs, settings following Content are
+		// only collected at the end.
+		in, err := os.Open(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := NewDecoder(in)
+		count := 0
+		for paragraph, err := range dec.Paragraphs() {
+			if err != nil {
+				t.Fatalf("%s, %s", fname, err)
+			}
+			if count == 0 && dec.Document().PageLayout != nil {
+				t.Errorf("%s, expected PageLayout to be read after the paragraphs", fname)
+			}
+			if got, want := paragraph.String(), expected.Content.Paragraph[count].String(); got != want {
+				t.Errorf("%s, paragraph %d expected %q, got %q", fname, count, want, got)
+			}
+			count++
+		}
+		in.Close()
+		if count != len(expected.Content.Paragraph) {
+			t.Errorf("%s, expected %d paragraphs, got %d", fname, len(expected.Content.Paragraph), count)
+		}
+		if dec.Document().PageLayout == nil || len(dec.Document().ElementSettings) != len(expected.ElementSettings) {
+			t.Errorf("%s, expected document settings to be read", fname)
+		}
+		if _, err := dec.Next(); err != io.EOF {
+			t.Errorf("%s, expected io.EOF, got %v", fname, err)
+		}
+
+		// Skip reads only the settings
+		src, err := ioutil.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec = NewDecoder(bytes.NewReader(src))
+		if err := dec.Skip(); err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		if settings := dec.Document(); len(settings.Content.Paragraph) != 0 ||
+			!reflect.DeepEqual(settings.TitlePage, expected.TitlePage) ||
+			!reflect.DeepEqual(settings.SceneNumberOptions, expected.SceneNumberOptions) {
+			t.Errorf("%s, expected Skip to read the settings without paragraphs", fname)
+		}
+
+		// Decode matches Parse
+		document, err := NewDecoder(bytes.NewReader(src)).Decode()
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		got, err := document.ToXML()
+		if err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s, Decode expected\n%s\ngot\n%s", fname, want, got)
+		}
+
+		// Encode matches ToXML
+		buf := new(bytes.Buffer)
+		if err := NewEncoder(buf).Encode(expected); err != nil {
+			t.Fatalf("%s, %s", fname, err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s, Encode expected\n%s\ngot\n%s", fname, want, buf.Bytes())
+		}
+
+		// Streamed text matches String and ToFountain
+		for _, fountain := range []bool{false, true} {
+			buf.Reset()
+			tw := NewTextWriter(buf, dec.Document(), fountain)
+			for paragraph, err := range NewDecoder(bytes.NewReader(src)).Paragraphs() {
+				if err != nil {
+					t.Fatalf("%s, %s", fname, err)
+				}
+				if err := tw.WriteParagraph(paragraph); err != nil {
+					t.Fatalf("%s, %s", fname, err)
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatalf("%s, %s", fname, err)
+			}
+			want := expected.String()
+			if fountain {
+				out := new(bytes.Buffer)
+				expected.ToFountain(out)
+				want = out.String()
+			}
+			if buf.String() != want {
+				t.Errorf("%s, TextWriter (fountain %t) expected\n%s\ngot\n%s", fname, fountain, want, buf.String())
+			}
+		}
+
+		// Truncated files are an error
+		if _, err := NewDecoder(bytes.NewReader(src[0 : len(src)/2])).Decode(); err == nil {
+			t.Errorf("%s, expected an error decoding a truncated file", fname)
+		}
+	}
+
+	// New documents, with and without paragraphs
+	for _, paragraphs := range [][]*Paragraph{nil, {{Type: ActionType, Text: StringToTextArray("Hello")}}} {
+		document := NewFinalDraft()
+		document.Content = &Content{Paragraph: paragraphs}
+		want, err := document.ToXML()
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := new(bytes.Buffer)
+		enc := NewEncoder(buf)
+		if err := enc.Start(document); err != nil {
+			t.Fatal(err)
+		}
+		if err := enc.Start(document); err == nil {
+			t.Errorf("expected an error starting a document twice")
+		}
+		for _, paragraph := range paragraphs {
+			if err := enc.EncodeParagraph(paragraph); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != string(want) {
+			t.Errorf("Encoder expected\n%s\ngot\n%s", want, buf.String())
+		}
+		if err := enc.EncodeParagraph(nil); err == nil {
+			t.Errorf("expected an error encoding a paragraph after Close")
+		}
+	}
+	if _, err := NewDecoder(strings.NewReader("")).Next(); err == nil {
+		t.Errorf("expected an error decoding an empty file")
+	}
+
+	// Repeated and unknown elements around Content decode as Parse
+	// decodes them
+	src := []byte(`<?xml version="1.0" encoding="UTF-8" standalone="no" ?>
+<FinalDraft DocumentType="Script" Template="No" Version="5">
+    <Extra A="1">before</Extra>
+    <TitlePage>
+        <Content>
+            <Paragraph Alignment="Center"><Text>ONE</Text></Paragraph>
+        </Content>
+    </TitlePage>
+    <ElementSettings Type="Action"/>
+    <Content>
+        <Paragraph Type="Action"><Text>Hello</Text></Paragraph>
+        <Odd/>
+    </Content>
+    <Extra A="2">after</Extra>
+    <TitlePage>
+        <Content>
+            <Paragraph Alignment="Center"><Text>TWO</Text></Paragraph>
+        </Content>
+    </TitlePage>
+    <ElementSettings Type="Dialogue"/>
+    <Other/>
+</FinalDraft>`)
+	expected, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := NewDecoder(bytes.NewReader(src)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(document, expected) {
+		want, _ := expected.ToXML()
+		got, _ := document.ToXML()
+		t.Errorf("Decode expected\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	fname := path.Join("testdata", "sample-03.fdx")
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := Parse(src)
+	if err != nil {
+		t.Fatalf("%s, %s", fname, err)
+	}
+	text := expected.Content.Paragraph[2].Text[0].InnerText
+	i := bytes.Index(src, []byte(">"+text+"<")) + 1
+	line, _ := position(src, i)
+	corrupt := func(s string) []byte {
+		return bytes.Join([][]byte{src[0:i], []byte(s), src[i+len(text):]}, nil)
+	}
+
+	// Bad entities are reported with their position and path
+	_, err = Parse(corrupt("Tom&nbsp;&amp; Jerry"))
+	parseError, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected a *ParseError, got %T %s", err, err)
+	}
+	if parseError.Line != line || parseError.Path != "FinalDraft/Content/Paragraph/Text" || !strings.Contains(parseError.Snippet, "Tom&nbsp;") {
+		t.Errorf("expected line %d in FinalDraft/Content/Paragraph/Text near \"Tom&nbsp;\", got %s", line, err)
+	}
+	if _, err := NewDecoder(bytes.NewReader(corrupt("Tom&nbsp;"))).Decode(); err == nil || err.(*ParseError).Line != line || err.(*ParseError).Path != "FinalDraft/Content/Paragraph" || !strings.Contains(err.(*ParseError).Snippet, "Tom&nbsp;") {
+		t.Errorf("expected Decoder error on line %d in FinalDraft/Content/Paragraph near \"Tom&nbsp;\", got %v", line, err)
+	}
+
+	long := "<FinalDraft><!--" + strings.Repeat("x", 100<<10) + "-->\n<Content><Paragraph><Text>Tom&nbsp;</Text></Paragraph></Content></FinalDraft>"
+	if _, err := NewDecoder(strings.NewReader(long)).Decode(); err == nil || err.(*ParseError).Line != 2 || !strings.Contains(err.(*ParseError).Snippet, "Tom&nbsp;") {
+		t.Errorf("expected Decoder error on line 2 near \"Tom&nbsp;\", got %.200v", err)
+	}
+
+	// ParseWithWarnings writes the repairs when lenient
+	warn := new(bytes.Buffer)
+	if _, err := ParseWithWarnings(corrupt("Tom&nbsp;"), false, warn); err == nil {
+		t.Errorf("expected ParseWithWarnings to fail when not lenient")
+	}
+	if _, err := ParseWithWarnings(corrupt("Tom&nbsp;"), true, warn); err != nil || !strings.HasPrefix(warn.String(), "warning: ") || strings.Count(warn.String(), "\n") != 1 {
+		t.Errorf("expected one warning, got %q, %v", warn.String(), err)
+	}
+	if _, err := ParseWithWarnings(corrupt("Tom&nbsp;"), true, nil); err != nil {
+		t.Errorf("expected warnings to be discarded, got %v", err)
+	}
+
+	// Errors decoding well formed XML have a position too
+	_, err = Parse([]byte("<?xml version=\"1.0\"?>\n<!-- no document -->\n"))
+	if parseError, ok = err.(*ParseError); !ok || parseError.Line != 3 || parseError.Column != 1 || parseError.Snippet != "" {
+		t.Errorf("expected an error at the end of the file (line 3, column 1), got %v", err)
+	}
+	_, err = Parse([]byte("<?xml version=\"1.0\"?>\n<!-- no document -->"))
+	if parseError, ok = err.(*ParseError); !ok || parseError.Line != 2 || !strings.Contains(parseError.Snippet, "no document") {
+		t.Errorf("expected an error on line 2 near \"no document\", got %v", err)
+	}
+
+	// Lenient parsing repairs them
+	for _, test := range []struct {
+		src      string
+		text     string
+		warnings int
+	}{
+		{"Tom &amp; Jerry", "Tom & Jerry", 0},
+		{"Tom&nbsp;&amp; Jerry", "Tom\u00a0& Jerry", 1},
+		{"Tom & Jerry &#0;", "Tom & Jerry ", 2},
+		{"\x93Tom\x94 \x01Jerry\xe9", "\u201cTom\u201d Jerry\u00e9", 3},
+	} {
+		document, warnings, err := ParseLenient(corrupt(test.src))
+		if err != nil {
+			t.Errorf("ParseLenient(%q), %s", test.src, err)
+			continue
+		}
+		if got := document.Content.Paragraph[2].Text[0].InnerText; got != test.text {
+			t.Errorf("ParseLenient(%q) expected %q, got %q", test.src, test.text, got)
+		}
+		if document.Content.String() != strings.Replace(expected.Content.String(), text, test.text, 1) {
+			t.Errorf("ParseLenient(%q) expected the rest of the document unchanged", test.src)
+		}
+		if len(warnings) != test.warnings {
+			t.Errorf("ParseLenient(%q) expected %d warnings, got %q", test.src, test.warnings, warnings)
+		}
+		for _, warning := range warnings {
+			if warning.Line != line || warning.Path != "FinalDraft/Content/Paragraph/Text" {
+				t.Errorf("ParseLenient(%q) expected warnings on line %d in FinalDraft/Content/Paragraph/Text, got %s", test.src, line, warning)
+			}
+		}
+	}
+
+	// Truncated files are closed after the last complete element
+	truncated := src[0:i]
+	if _, err := Parse(truncated); err == nil {
+		t.Errorf("expected an error parsing a truncated file")
+	}
+	document, warnings, err := ParseLenient(truncated)
+	if err != nil {
+		t.Fatalf("ParseLenient of a truncated file, %s", err)
+	}
+	if len(document.Content.Paragraph) != 3 || len(warnings) != 1 || !strings.Contains(warnings[0].Error(), "truncated") {
+		t.Errorf("expected 3 paragraphs and a truncation warning, got %d paragraphs and %q", len(document.Content.Paragraph), warnings)
+	}
+	if got, want := document.Content.Paragraph[1].String(), expected.Content.Paragraph[1].String(); got != want {
+		t.Errorf("expected paragraph %q, got %q", want, got)
+	}
+	if _, _, err := ParseLenient([]byte("<FinalDraft><Content></Paragraph></FinalDraft>")); err == nil {
+		t.Errorf("expected an error for mismatched elements")
+	}
+}
+
+func TestScenes(t *testing.T) {
+	document, err := ParseFile(path.Join("testdata", "sample-04.fdx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenes := document.Scenes()
+	if len(scenes) != 2 {
+		t.Fatalf("expected 2 scenes, got %d", len(scenes))
+	}
+	expected := []string{
+		`1 "INT. STUDIO APARTMENT - NIGHT" "INT." "STUDIO APARTMENT" "NIGHT" "1" "2/8" ["AUTHOR"] 17`,
+		`7 "EXT. PARK - DAY" "EXT." "PARK" "DAY" "1" "4/8" ["DOG" "AUTHOR"] 58`,
+	}
+	for i, scene := range scenes {
+		got := fmt.Sprintf("%d %q %q %q %q %q %q %q %d", scene.Index, scene.Heading, scene.IntExt, scene.Location, scene.TimeOfDay, scene.Page, scene.Length, scene.Characters, scene.Words)
+		if got != expected[i] {
+			t.Errorf("scene %d expected %s, got %s", i, expected[i], got)
+		}
+		if scene.Paragraphs[0] != document.Content.Paragraph[scene.Index] {
+			t.Errorf("scene %d expected to start with its scene heading", i)
+		}
+	}
+	if n := len(scenes[0].Paragraphs) + len(scenes[1].Paragraphs); n != len(document.Content.Paragraph)-1 {
+		t.Errorf("expected the scenes to hold %d paragraphs, got %d", len(document.Content.Paragraph)-1, n)
+	}
+
+	// Without scene properties the page and length come from Pages
+	for _, paragraph := range document.SceneHeadings() {
+		paragraph.SceneProperties = nil
+	}
+	for i, scene := range document.Scenes() {
+		if scene.Page != "1" || scene.Eighths != scenes[i].Eighths {
+			t.Errorf("scene %d expected page 1 and %s, got %q and %s", i, scenes[i].Length, scene.Page, scene.Length)
+		}
+	}
+
+	// Scene intros and the time of day separator come from SmartType,
+	// speakers in dual dialogue are included.
+	document, err = Parse([]byte(`<FinalDraft>
+  <Content>
+    <Paragraph Type="Scene Heading" Number="12A">
+      <Text>Int Garage / Day</Text>
+    </Paragraph>
+    <Paragraph>
+      <DualDialogue>
+        <Paragraph Type="Character"><Text>BRICK (V.O.)</Text></Paragraph>
+        <Paragraph Type="Dialogue"><Text>Screw retirement.</Text></Paragraph>
+        <Paragraph Type="Character"><Text>STEEL</Text></Paragraph>
+        <Paragraph Type="Dialogue"><Text>Screw retirement.</Text></Paragraph>
+      </DualDialogue>
+    </Paragraph>
+    <Paragraph Type="Scene Heading">
+      <Text>BACKSTAGE - LATER #3#</Text>
+    </Paragraph>
+    <Paragraph Type="Character"><Text>BRICK</Text></Paragraph>
+  </Content>
+  <SmartType>
+    <SceneIntros><SceneIntro>BACKSTAGE</SceneIntro></SceneIntros>
+    <TimesOfDay Separator=" / "/>
+  </SmartType>
+</FinalDraft>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scenes = document.Scenes()
+	expected = []string{
+		`"12A" "Int" "Garage" "Day" ["BRICK" "STEEL"] 10`,
+		`"3" "BACKSTAGE" "- LATER" "" ["BRICK"] 3`,
+	}
+	for i, scene := range scenes {
+		got := fmt.Sprintf("%q %q %q %q %q %d", scene.Number, scene.IntExt, scene.Location, scene.TimeOfDay, scene.Characters, scene.Words)
+		if got != expected[i] {
+			t.Errorf("scene %d expected %s, got %s", i, expected[i], got)
+		}
+	}
+
+	for eighths, s := range map[int]string{0: "0", 3: "3/8", 8: "1", 11: "1 3/8"} {
+		if got := FormatEighths(eighths); got != s {
+			t.Errorf("FormatEighths(%d) expected %q, got %q", eighths, s, got)
+		}
+		if got := parseEighths(s); got != eighths {
+			t.Errorf("parseEighths(%q) expected %d, got %d", s, eighths, got)
+		}
+	}
+
+	// Reports
+	buf := new(bytes.Buffer)
+	if err := document.WriteSceneReport(buf, CSVFormat); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.SplitN(buf.String(), "\n", 3)[1], `12A,1,1/8,Int,Garage,Day,10,"BRICK, STEEL"`; got != want {
+		t.Errorf("expected CSV row %q, got %q", want, got)
+	}
+	buf.Reset()
+	if err := document.WriteSceneReport(buf, JSONFormat); err != nil {
+		t.Fatal(err)
+	}
+	list := []*Scene{}
+	if err := json.Unmarshal(buf.Bytes(), &list); err != nil || len(list) != 2 || list[1].Heading != "BACKSTAGE - LATER" {
+		t.Errorf("expected JSON scenes, got %s, %v", buf.Bytes(), err)
+	}
+	buf.Reset()
+	if err := document.WriteSceneReport(buf, TableFormat); err != nil || !strings.HasPrefix(buf.String(), "Scene  Page  Length") {
+		t.Errorf("expected a table, got %q, %v", buf.String(), err)
+	}
+	if err := document.WriteSceneReport(buf, "xml"); err == nil {
+		t.Errorf("expected an error for an unknown report format")
+	}
+}
+
+func TestCharacterStats(t *testing.T) {
+	document, err := ParseFile(path.Join("testdata", "sample-06.fdx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`"AUTHOR" 3 9 3 3 1 2 map[]`,
+		`"DOG" 2 30 6 1 1 1 map[]`,
+	}
+	list := document.CharacterStats()
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d characters, got %d", len(expected), len(list))
+	}
+	for i, stats := range list {
+		got := fmt.Sprintf("%q %d %d %d %d %d %d %v", stats.Name, stats.Speeches, stats.Words, stats.Lines, stats.Scenes, stats.FirstPage, stats.LastPage, stats.Extensions)
+		if got != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], got)
+		}
+	}
+
+	// Extensions are normalized using SmartType, dual dialogue and
+	// singing are counted
+	document, err = Parse([]byte(`<FinalDraft>
+  <Content>
+    <Paragraph Type="Scene Heading"><Text>INT. GARAGE - DAY</Text></Paragraph>
+    <Paragraph Type="Character"><Text>Brick (vo)</Text></Paragraph>
+    <Paragraph Type="Parenthetical"><Text>(quietly)</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Screw retirement.</Text></Paragraph>
+    <Paragraph Type="Action"><Text>Steel shrugs.</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Not a speech.</Text></Paragraph>
+    <Paragraph>
+      <DualDialogue>
+        <Paragraph Type="Character"><Text>BRICK (CONT'D)</Text></Paragraph>
+        <Paragraph Type="Dialogue"><Text>Screw it.</Text></Paragraph>
+        <Paragraph Type="Character"><Text>STEEL (O.S.)</Text></Paragraph>
+        <Paragraph Type="Dialogue"><Text>Screw it all, every last bit of it, from the top of the garage to the bottom.</Text></Paragraph>
+      </DualDialogue>
+    </Paragraph>
+    <Paragraph Type="Scene Heading"><Text>EXT. STREET - NIGHT</Text></Paragraph>
+    <Paragraph Type="Character"><Text>BRICK (V.O.)</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Gone.</Text></Paragraph>
+    <Paragraph Type="Singing"><Text>Gone for good.</Text></Paragraph>
+  </Content>
+  <SmartType>
+    <Extensions>
+      <Extension>(V.O.)</Extension>
+      <Extension>(O.S.)</Extension>
+    </Extensions>
+  </SmartType>
+</FinalDraft>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{
+		`"BRICK" 3 8 4 2 1 1 map[CONT'D:1 V.O.:2]`,
+		`"STEEL" 1 17 3 1 1 1 map[O.S.:1]`,
+	}
+	list = document.CharacterStats()
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d characters, got %d", len(expected), len(list))
+	}
+	for i, stats := range list {
+		got := fmt.Sprintf("%q %d %d %d %d %d %d %v", stats.Name, stats.Speeches, stats.Words, stats.Lines, stats.Scenes, stats.FirstPage, stats.LastPage, stats.Extensions)
+		if got != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], got)
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := document.WriteCharacterReport(buf, CSVFormat); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.SplitN(buf.String(), "\n", 3)[1], `BRICK,3,8,4,2,1,1,"CONT'D 1, V.O. 2"`; got != want {
+		t.Errorf("expected CSV row %q, got %q", want, got)
+	}
+}
+
+func TestLocationStats(t *testing.T) {
+	document, err := Parse([]byte(`<FinalDraft>
+  <Content>
+    <Paragraph Type="Scene Heading" Number="1">
+      <SceneProperties Length="1 2/8" Page="1"/>
+      <Text>INT. HOUSE - KITCHEN - NIGHT</Text>
+    </Paragraph>
+    <Paragraph Type="Scene Heading" Number="2">
+      <SceneProperties Length="3/8" Page="2"/>
+      <Text>EXT. HOUSE - DAY</Text>
+    </Paragraph>
+    <Paragraph Type="Scene Heading" Number="3">
+      <SceneProperties Length="1/8" Page="2"/>
+      <Text>INT. HOUSE - KITCHEN - CONTINUOUS</Text>
+    </Paragraph>
+    <Paragraph Type="Scene Heading" Number="4">
+      <SceneProperties Length="2/8" Page="2"/>
+      <Text>INT. HOUSE - BEDROOM</Text>
+    </Paragraph>
+    <Paragraph Type="Scene Heading">
+      <SceneProperties Length="4/8" Page="3"/>
+      <Text>EXT. PARK - EVENING</Text>
+    </Paragraph>
+    <Paragraph Type="Scene Heading" Number="6">
+      <SceneProperties Length="1/8" Page="3"/>
+      <Text>INT. DINER - CONTINUOUS</Text>
+    </Paragraph>
+  </Content>
+  <SmartType>
+    <Locations>
+      <Location>HOUSE</Location>
+      <Location>HOUSE - BEDROOM</Location>
+      <Location>PARK</Location>
+    </Locations>
+    <TimesOfDay Separator=" - ">
+      <TimeOfDay>DAY</TimeOfDay>
+      <TimeOfDay>NIGHT</TimeOfDay>
+      <TimeOfDay>CONTINUOUS</TimeOfDay>
+    </TimesOfDay>
+  </SmartType>
+</FinalDraft>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scene := document.Scenes()[3]; scene.Location != "HOUSE - BEDROOM" || scene.TimeOfDay != "" {
+		t.Errorf("expected location \"HOUSE - BEDROOM\" without a time of day, got %q %q", scene.Location, scene.TimeOfDay)
+	}
+	buf := new(bytes.Buffer)
+	if err := document.WriteLocationReport(buf, CSVFormat); err != nil {
+		t.Fatal(err)
+	}
+	// DINER doesn't follow the day or night of PARK
+	expected := `Location,Sub-location,Int/Ext,Scenes,Day,Night,Length,Scene Numbers
+DINER,,INT.,1,0,0,1/8,6
+HOUSE,,"INT., EXT.",4,3,1,2,"1, 2, 3, 4"
+HOUSE,BEDROOM,INT.,1,1,0,2/8,4
+HOUSE,KITCHEN,INT.,2,1,1,1 3/8,"1, 3"
+PARK,,EXT.,1,0,1,4/8,5
+`
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+	buf.Reset()
+	if err := document.WriteLocationReport(buf, JSONFormat); err != nil {
+		t.Fatal(err)
+	}
+	list := []*LocationStats{}
+	if err := json.Unmarshal(buf.Bytes(), &list); err != nil || len(list) != 3 || len(list[1].SubLocations) != 2 || list[1].SubLocations[1].Name != "KITCHEN" {
+		t.Errorf("expected JSON locations, got %s, %v", buf.Bytes(), err)
+	}
+}
+
+func TestTags(t *testing.T) {
+	src := []byte(`<?xml version="1.0" encoding="UTF-8" standalone="no" ?>
+<FinalDraft DocumentType="Script" Template="No" Version="5">
+    <Content>
+        <Paragraph Type="Scene Heading" Number="1">
+            <Text>INT. GARAGE - NIGHT</Text>
+        </Paragraph>
+        <Paragraph Type="Action">
+            <Text>MAX loads a </Text>
+            <Text TagNumber="1">shotgun</Text>
+            <Text> into the </Text>
+            <Text Style="Bold">old truck</Text>
+            <Text>.</Text>
+        </Paragraph>
+        <Paragraph Type="Character">
+            <Text>MAX</Text>
+        </Paragraph>
+        <Paragraph Type="Dialogue">
+            <Text>Let's go.</Text>
+        </Paragraph>
+        <Paragraph Type="Scene Heading" Number="2">
+            <Text>EXT. HIGHWAY - DAY</Text>
+        </Paragraph>
+        <Paragraph Type="Action">
+            <Text>The truck explodes.</Text>
+        </Paragraph>
+    </Content>
+    <TagData>
+        <TagCategories>
+            <TagCategory Color="#000000000000" Id="C1" Name="Props" Number="1" Style="Bold"/>
+        </TagCategories>
+        <TagDefinitions>
+            <TagDefinition CatId="C1" Id="D1" Label="SHOTGUN" Number="1"/>
+        </TagDefinitions>
+        <Tags>
+            <Tag Number="1">
+                <DefId>D1</DefId>
+            </Tag>
+        </Tags>
+    </TagData>
+</FinalDraft>`)
+	document, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := document.ToXML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte(`<Text TagNumber="1">shotgun</Text>`)) ||
+		!bytes.Contains(out, []byte(`<TagDefinition CatId="C1" Id="D1" Label="SHOTGUN" Number="1"/>`)) ||
+		!bytes.Contains(out, []byte(`<DefId>D1</DefId>`)) {
+		t.Errorf("expected tags to be kept, got\n%s", out)
+	}
+
+	action := document.Content.Paragraph[1]
+	if _, err := document.AddTag(action, 29, 9, VehiclesCategory, "TRUCK"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := document.AddTag(action, 12, 7, SpecialEffectsCategory, "SHOTGUN BLAST"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := document.AddTag(document.Content.Paragraph[5], 4, 5, VehiclesCategory, "truck"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := document.AddTag(document.Content.Paragraph[5], 10, 8, SpecialEffectsCategory, "EXPLOSION"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := document.AddTag(action, 36, 5, PropsCategory, "X"); err == nil {
+		t.Errorf("expected an error tagging past the end of the text")
+	}
+	if len(action.Text) != 5 || action.Text[3].InnerText != "old truck" || action.Text[3].Style != "Bold" || action.Text[3].TagNumber != "2" {
+		t.Errorf("expected \"old truck\" to keep its style with tag 2, got %+v", action.Text[3])
+	}
+	if tag := document.Tag("1"); tag == nil || len(tag.DefinitionID) != 2 {
+		t.Errorf("expected tag 1 to have two definitions, got %+v", tag)
+	}
+	if len(document.TagData.TagDefinitions.TagDefinition) != 4 {
+		t.Errorf("expected 4 tag definitions, got %d", len(document.TagData.TagDefinitions.TagDefinition))
+	}
+
+	buf := new(bytes.Buffer)
+	if err := document.WriteBreakdownReport(buf, CSVFormat); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Scene,Page,Length,Int/Ext,Location,Time,Cast Members,Extras,Props,Wardrobe,Vehicles,Special Effects
+1,1,2/8,INT.,GARAGE,NIGHT,MAX,,SHOTGUN,,TRUCK,SHOTGUN BLAST
+2,1,1/8,EXT.,HIGHWAY,DAY,,,,,TRUCK,EXPLOSION
+`
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	if err := document.RemoveTag(action, 12, 7); err != nil {
+		t.Fatal(err)
+	}
+	if len(action.Text) != 3 || action.Text[0].InnerText != "MAX loads a shotgun into the " {
+		t.Errorf("expected the untagged runs to be merged, got %d runs", len(action.Text))
+	}
+	if document.Tag("1") != nil || document.TagDefinition(PropsCategory, "shotgun") == nil {
+		t.Errorf("expected tag 1 to be removed and its definition kept")
+	}
+	buf.Reset()
+	if err := document.WriteBreakdownReport(buf, HTMLFormat); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); strings.Count(s, `<section class="breakdown-sheet">`) != 2 ||
+		!strings.Contains(s, "<h2>Scene 2: EXT. HIGHWAY - DAY</h2>") ||
+		!strings.Contains(s, "<tr><th>Vehicles</th><td>TRUCK</td></tr>") {
+		t.Errorf("expected two breakdown sheets, got\n%s", s)
+	}
+
+	// Tagging inside tagged text keeps the earlier definitions
+	document, err = Parse([]byte(`<FinalDraft>
+  <Content>
+    <Paragraph Type="Scene Heading" Number="1"><Text>INT. SALOON - NIGHT</Text></Paragraph>
+    <Paragraph Type="Action"><Text>He draws a RED REVOLVER.</Text></Paragraph>
+  </Content>
+</FinalDraft>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	action = document.Content.Paragraph[1]
+	if _, err := document.AddTag(action, 11, 12, PropsCategory, "REVOLVER"); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := document.AddTag(action, 11, 3, WardrobeCategory, "RED")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(action.Text) != 4 || action.Text[1].InnerText != "RED" || action.Text[1].TagNumber != tag.Number ||
+		action.Text[2].InnerText != " REVOLVER" || action.Text[2].TagNumber != "1" {
+		t.Errorf("expected \"RED\" to get its own tag, got %+v", action.Text)
+	}
+	if len(tag.DefinitionID) != 2 || tag.DefinitionID[0] != document.TagDefinition(PropsCategory, "REVOLVER").ID {
+		t.Errorf("expected \"RED\" to keep the REVOLVER definition, got %+v", tag)
+	}
+	buf.Reset()
+	if err := document.WriteBreakdownReport(buf, CSVFormat); err != nil {
+		t.Fatal(err)
+	}
+	expected = `Scene,Page,Length,Int/Ext,Location,Time,Cast Members,Extras,Props,Wardrobe,Vehicles,Special Effects
+1,1,1/8,INT.,SALOON,NIGHT,,,REVOLVER,RED,,
+`
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	document, err := Parse([]byte(`<FinalDraft>
+  <Content>
+    <Paragraph Type="Scene Heading" Number="1">
+      <SceneProperties Length="2" Page="1"/>
+      <Text>INT. HOUSE - KITCHEN - DAY</Text>
+    </Paragraph>
+    <Paragraph Type="Character"><Text>ANNA</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Morning.</Text></Paragraph>
+    <Paragraph Type="Character"><Text>BEN</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Is it?</Text></Paragraph>
+    <Paragraph Type="Scene Heading" Number="2">
+      <SceneProperties Length="3" Page="1"/>
+      <Text>EXT. PARK - NIGHT</Text>
+    </Paragraph>
+    <Paragraph Type="Character"><Text>BEN (V.O.)</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>It was.</Text></Paragraph>
+    <Paragraph Type="Scene Heading" Number="3">
+      <SceneProperties Length="1" Page="2"/>
+      <Text>INT. HOUSE - BEDROOM - NIGHT</Text>
+    </Paragraph>
+    <Paragraph Type="Character"><Text>ANNA</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Goodnight.</Text></Paragraph>
+    <Paragraph Type="Scene Heading" Number="4">
+      <SceneProperties Length="4" Page="3"/>
+      <Text>INT. HOUSE - KITCHEN - DAY</Text>
+    </Paragraph>
+    <Paragraph Type="Character"><Text>ANNA</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Again.</Text></Paragraph>
+    <Paragraph Type="Scene Heading" Number="5">
+      <SceneProperties Length="2" Page="4"/>
+      <Text>EXT. PARK - DAY</Text>
+    </Paragraph>
+    <Paragraph Type="Character"><Text>CARL</Text></Paragraph>
+    <Paragraph Type="Dialogue"><Text>Hey!</Text></Paragraph>
+  </Content>
+</FinalDraft>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule, err := document.Schedule(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := schedule.WriteStripboard(buf, CSVFormat); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Day,Scene,Int/Ext,Location,Day/Night,Page,Length,Cast
+1,1,INT.,HOUSE - KITCHEN,Day,1,2,"1, 2"
+End of Day 1,,,,,,2,
+2,4,INT.,HOUSE - KITCHEN,Day,3,4,1
+2,3,INT.,HOUSE - BEDROOM,Night,2,1,1
+End of Day 2,,,,,,5,
+3,5,EXT.,PARK,Day,4,2,3
+3,2,EXT.,PARK,Night,1,3,2
+End of Day 3,,,,,,5,
+`
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+	buf.Reset()
+	if err := schedule.WriteDayOutOfDays(buf, CSVFormat); err != nil {
+		t.Fatal(err)
+	}
+	expected = `ID,Cast,Day 1,Day 2,Day 3,Start,Finish,Work,Hold,Total
+1,ANNA,SW,WF,,1,2,2,0,2
+2,BEN,SW,H,WF,1,3,2,1,3
+3,CARL,,,SWF,3,3,1,0,1
+`
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+	buf.Reset()
+	if err := schedule.WriteStripboard(buf, HTMLFormat); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); !strings.Contains(s, `<tr class="ext-night"><td>3</td><td>2</td>`) ||
+		strings.Count(s, `<tr class="day-break">`) != 3 {
+		t.Errorf("expected colored strips and day breaks, got\n%s", s)
+	}
+
+	options, err := ParseScheduleOptions([]byte("pages_per_day: 5\norder: [5]\nlocked: true\nday_breaks: [3]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule, err = document.Schedule(options); err != nil {
+		t.Fatal(err)
+	}
+	days := []string{}
+	for _, day := range schedule.Days {
+		numbers := []string{}
+		for _, strip := range day.Strips {
+			numbers = append(numbers, strip.Scene.Number)
+		}
+		days = append(days, strings.Join(numbers, " "))
+	}
+	if s := strings.Join(days, ", "); s != "5 1, 2, 3 4" {
+		t.Errorf("expected days \"5 1, 2, 3 4\", got %q", s)
+	}
+	for _, options := range []*ScheduleOptions{
+		{PagesPerDay: "lots"},
+		{Order: []string{"9"}},
+		{DayBreaks: []string{"9"}},
+	} {
+		if _, err := document.Schedule(options); err == nil {
+			t.Errorf("expected an error for %+v", options)
+		}
+	}
+
+	// A time of day that isn't day or night follows the scene before
+	// only at the same location, as in LocationStats
+	document, err = Parse([]byte(`<FinalDraft>
+  <Content>
+    <Paragraph Type="Scene Heading" Number="1"><Text>EXT. PARK - NIGHT</Text></Paragraph>
+    <Paragraph Type="Scene Heading" Number="2"><Text>EXT. PARK - CONTINUOUS</Text></Paragraph>
+    <Paragraph Type="Scene Heading" Number="3"><Text>INT. DINER - CONTINUOUS</Text></Paragraph>
+  </Content>
+</FinalDraft>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dayNights := []string{}
+	for _, strip := range document.scheduleStrips() {
+		dayNights = append(dayNights, strip.DayNight)
+	}
+	if s := strings.Join(dayNights, ","); s != "Night,Night," {
+		t.Errorf("expected Night,Night, got %q", s)
+	}
+}
+
+func TestUnzipLimits(t *testing.T) {
+	entrySize, archiveSize := MaxArchiveEntrySize, MaxArchiveSize
+	defer func() {
+		MaxArchiveEntrySize, MaxArchiveSize = entrySize, archiveSize
+	}()
+	MaxArchiveEntrySize, MaxArchiveSize = 64<<10, 100<<10
+
+	// A megabyte of zeros compresses to a few kilobytes
+	bomb := makeZip(t, []string{"bomb.fountain"}, map[string]string{"bomb.fountain": strings.Repeat("\x00", 1<<20)})
+	if _, _, err := unzip(bomb); err == nil || !strings.Contains(err.Error(), "bomb.fountain") {
+		t.Errorf("expected an error for an entry over the limit, got %v", err)
+	}
+	if _, err := ParseHighland(bomb); err == nil {
+		t.Errorf("expected ParseHighland to return an error for a zip bomb")
+	}
+	files := map[string]string{"a.txt": strings.Repeat("a", 60<<10), "b.txt": strings.Repeat("b", 60<<10)}
+	if _, _, err := unzip(makeZip(t, []string{"a.txt", "b.txt"}, files)); err == nil {
+		t.Errorf("expected an error for an archive over the limit")
+	}
+	if _, names, err := unzip(makeZip(t, []string{"a.txt"}, files)); err != nil || len(names) != 1 {
+		t.Errorf("expected an archive under the limits to unzip, got %v, %v", names, err)
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Setup everything, process flags, etc.
 	os.Exit(m.Run())
@@ -31,86 +31,370 @@ package fdx
 
 import (
 	"strings"
+	"unicode"
 
 	// My Packages
 	"github.com/rsdoiel/fountain"
 )
 
+// emphasisToken is either literal text or a Fountain emphasis marker
+// (e.g. "*", "**", "***", "_", "~~")
+type emphasisToken struct {
+	text     string
+	marker   string
+	isMarker bool
+}
+
+// emphasisStyles maps Fountain emphasis markers to FDX styles
+var emphasisStyles = map[string][]string{
+	"*":   []string{ItalicStyle},
+	"**":  []string{BoldStyle},
+	"***": []string{BoldStyle, ItalicStyle},
+	"_":   []string{UnderlineStyle},
+	"~~":  []string{Strikethrough},
+}
+
+// tokenizeEmphasis splits s into text and emphasis marker tokens.
+// Backslash escaped markers (e.g. "\*") are treated as text.
+func tokenizeEmphasis(s string) []*emphasisToken {
+	tokens := []*emphasisToken{}
+	buf := []rune{}
+	flush := func() {
+		if len(buf) > 0 {
+			tokens = append(tokens, &emphasisToken{text: string(buf)})
+			buf = []rune{}
+		}
+	}
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes) && strings.ContainsRune(`*_~\`, runes[i+1]):
+			buf = append(buf, runes[i+1])
+			i++
+		case r == '*':
+			j := i
+			for j < len(runes) && runes[j] == '*' && j-i < 3 {
+				j++
+			}
+			flush()
+			tokens = append(tokens, &emphasisToken{marker: string(runes[i:j]), isMarker: true})
+			i = j - 1
+		case r == '_':
+			j := i
+			for j < len(runes) && runes[j] == '_' {
+				j++
+			}
+			if j-i > 1 {
+				// A run of underscores (e.g. "NAME: ______") is text
+				buf = append(buf, runes[i:j]...)
+			} else {
+				flush()
+				tokens = append(tokens, &emphasisToken{marker: "_", isMarker: true})
+			}
+			i = j - 1
+		case r == '~' && i+1 < len(runes) && runes[i+1] == '~':
+			flush()
+			tokens = append(tokens, &emphasisToken{marker: "~~", isMarker: true})
+			i++
+		default:
+			buf = append(buf, r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// isWordRune reports if r can be part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// pairEmphasis turns markers without a matching partner back into text.
+// An opening marker must be followed by non-space text and a closing
+// marker preceded by it (e.g. "2 * 3 * 4" has no emphasis) and
+// a pair must enclose some text. Underscores must also be at a word
+// boundary so "snake_case_name" has no emphasis. Closers are matched
+// to the nearest opener, innermost first, splitting asterisk runs of
+// different lengths (e.g. "***bold italic** italic*").
+func pairEmphasis(tokens []*emphasisToken) []*emphasisToken {
+	startsText := func(i int) bool {
+		return i+1 < len(tokens) && (tokens[i+1].isMarker || (tokens[i+1].text != "" && strings.TrimLeft(tokens[i+1].text, " \t") == tokens[i+1].text))
+	}
+	endsText := func(i int) bool {
+		return i > 0 && (tokens[i-1].isMarker || (tokens[i-1].text != "" && strings.TrimRight(tokens[i-1].text, " \t") == tokens[i-1].text))
+	}
+	boundaryBefore := func(i int) bool {
+		if i == 0 || tokens[i-1].isMarker {
+			return true
+		}
+		runes := []rune(tokens[i-1].text)
+		return len(runes) == 0 || !isWordRune(runes[len(runes)-1])
+	}
+	boundaryAfter := func(i int) bool {
+		if i+1 >= len(tokens) || tokens[i+1].isMarker {
+			return true
+		}
+		runes := []rune(tokens[i+1].text)
+		return len(runes) == 0 || !isWordRune(runes[0])
+	}
+	hasText := func(i, j int) bool {
+		for k := i + 1; k < j; k++ {
+			if !tokens[k].isMarker && tokens[k].text != "" {
+				return true
+			}
+		}
+		return false
+	}
+	// insert adds tok to tokens at index i
+	insert := func(i int, tok *emphasisToken) {
+		tokens = append(tokens[:i], append([]*emphasisToken{tok}, tokens[i:]...)...)
+	}
+	paired := map[*emphasisToken]bool{}
+	openers := []int{}
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if !tok.isMarker {
+			continue
+		}
+		if endsText(i) && (tok.marker != "_" || boundaryAfter(i)) {
+			for k := len(openers) - 1; k >= 0; k-- {
+				o := openers[k]
+				opener := tokens[o]
+				if opener.marker[0] != tok.marker[0] || !hasText(o, i) {
+					continue
+				}
+				n := min(len(opener.marker), len(tok.marker))
+				if len(opener.marker) > n {
+					// The inner part of the opener pairs here, the
+					// rest is left open (e.g. "***" as "*" and "**").
+					inner := &emphasisToken{marker: opener.marker[:n], isMarker: true}
+					opener.marker = opener.marker[n:]
+					insert(o+1, inner)
+					i++
+					paired[inner] = true
+					openers = openers[:k+1]
+				} else {
+					paired[opener] = true
+					openers = openers[:k]
+				}
+				if len(tok.marker) > n {
+					// The rest of the closer looks for another opener
+					insert(i+1, &emphasisToken{marker: tok.marker[n:], isMarker: true})
+					tok.marker = tok.marker[:n]
+				}
+				paired[tok] = true
+				break
+			}
+			if paired[tok] {
+				continue
+			}
+		}
+		if startsText(i) && (tok.marker != "_" || boundaryBefore(i)) {
+			openers = append(openers, i)
+		}
+	}
+	for _, tok := range tokens {
+		if tok.isMarker && !paired[tok] {
+			tok.text, tok.isMarker = tok.marker, false
+		}
+	}
+	return tokens
+}
+
+// StringToTextArray converts a string holding Fountain emphasis
+// (e.g. *italic*, **bold**, ***bold italic***, _underline_ and
+// ~~strikethrough~~, nested or escaped with a backslash) into an
+// array of Text with their Style set (e.g. "Bold+Underline").
 func StringToTextArray(s string) []*Text {
 	var a []*Text
 
-	//FIXME: if string has embedded formatting split into parts and set format attributes
-	// (e.g. text.Bold, text.Italic) as needed.
-	text := new(Text)
-	text.InnerText = s
-	a = append(a, text)
+	tokens := pairEmphasis(tokenizeEmphasis(s))
+	active := map[string]bool{}
+	for _, tok := range tokens {
+		if tok.isMarker {
+			for _, style := range emphasisStyles[tok.marker] {
+				active[style] = !active[style]
+			}
+			continue
+		}
+		styles := []string{}
+		for _, style := range []string{BoldStyle, ItalicStyle, UnderlineStyle, Strikethrough} {
+			if active[style] {
+				styles = append(styles, style)
+			}
+		}
+		style := strings.Join(styles, "+")
+		if i := len(a) - 1; i >= 0 && a[i].Style == style {
+			a[i].InnerText += tok.text
+			continue
+		}
+		text := new(Text)
+		text.InnerText = tok.text
+		text.Style = style
+		a = append(a, text)
+	}
+	if len(a) == 0 {
+		a = append(a, new(Text))
+	}
 	return a
 }
 
-// AddInfo parses a map[string]string and updates/adds Info struct to document as needed.
+const (
+	// Title page vertical spacing (SpaceBefore in points, 12 points
+	// is a line of Courier 12pt) following Final Draft's conventions
+	// for a title page.
+	titleSpaceBefore       = "192"
+	creditSpaceBefore      = "48"
+	authorSpaceBefore      = "24"
+	sourceSpaceBefore      = "48"
+	bottomBlockSpaceBefore = "300"
+	bottomLineSpaceBefore  = "24"
+
+	// DefaultCredit is used on a title page with an author but no credit
+	DefaultCredit = "Written by"
+)
+
+// titlePageValue normalizes a Fountain title page value, values can
+// span several indented lines.
+func titlePageValue(s string) string {
+	lines := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// titlePageParagraph builds a title page paragraph
+func titlePageParagraph(s string, alignment string, spaceBefore string) *Paragraph {
+	paragraph := new(Paragraph)
+	paragraph.Alignment = alignment
+	paragraph.SpaceBefore = spaceBefore
+	paragraph.Text = StringToTextArray(s)
+	return paragraph
+}
+
+// titlePageLayout turns Fountain title page elements into paragraphs
+// laid out the way Final Draft lays out a title page. Title, credit,
+// author(s) and source are centered a third of the way down the page,
+// the draft date is at the bottom right followed by copyright, notes,
+// any other keys (as "Key: value") and the contact at the bottom left.
+func titlePageLayout(elements []*fountain.Element) []*Paragraph {
+	var (
+		titles, credits, authors, sources, dates, contacts []string
+		bottom                                             []string
+	)
+	for _, elem := range elements {
+		value := titlePageValue(elem.Content)
+		if value == "" {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(elem.Name)) {
+		case "title":
+			titles = append(titles, value)
+		case "credit":
+			credits = append(credits, value)
+		case "author", "authors":
+			authors = append(authors, value)
+		case "source", "story by":
+			if strings.EqualFold(strings.TrimSpace(elem.Name), "story by") {
+				value = "Story by\n" + value
+			}
+			sources = append(sources, value)
+		case "draft date", "date":
+			dates = append(dates, value)
+		case "contact":
+			contacts = append(contacts, value)
+		case "copyright":
+			bottom = append(bottom, value)
+		default:
+			// Preserve custom keys so they can be recovered.
+			bottom = append(bottom, strings.TrimSpace(elem.Name)+": "+value)
+		}
+	}
+	if len(credits) == 0 && len(authors) > 0 {
+		credits = append(credits, DefaultCredit)
+	}
+	paragraphs := []*Paragraph{}
+	centered := []struct {
+		values      []string
+		spaceBefore string
+	}{
+		{titles, titleSpaceBefore},
+		{credits, creditSpaceBefore},
+		{authors, authorSpaceBefore},
+		{sources, sourceSpaceBefore},
+	}
+	for _, block := range centered {
+		for i, value := range block.values {
+			spaceBefore := block.spaceBefore
+			if i > 0 {
+				spaceBefore = authorSpaceBefore
+			}
+			if len(paragraphs) == 0 {
+				spaceBefore = titleSpaceBefore
+			}
+			paragraphs = append(paragraphs, titlePageParagraph(value, CenterAlignment, spaceBefore))
+		}
+	}
+	spaceBefore := bottomBlockSpaceBefore
+	for _, value := range dates {
+		paragraphs = append(paragraphs, titlePageParagraph(value, RightAlignment, spaceBefore))
+		spaceBefore = bottomLineSpaceBefore
+	}
+	for _, value := range append(bottom, contacts...) {
+		paragraphs = append(paragraphs, titlePageParagraph(value, LeftAlignment, spaceBefore))
+		spaceBefore = bottomLineSpaceBefore
+	}
+	return paragraphs
+}
+
+// stripForcedMarkers removes Fountain's forced element markers (e.g.
+// "." scene headings, "!" action, "@" characters, ">" transitions)
+// from content returning the paragraph type, content and alignment.
+func stripForcedMarkers(elemType int, typeName string, content string) (string, string, string) {
+	s := strings.TrimSpace(content)
+	switch elemType {
+	case fountain.SceneHeadingType:
+		if strings.HasPrefix(s, ".") && !strings.HasPrefix(s, "..") {
+			return typeName, strings.TrimPrefix(s, "."), ""
+		}
+	case fountain.ActionType:
+		// NOTE: the fountain package reads transitions ending in ":"
+		// as action, per the spec they are transitions.
+		if !strings.Contains(s, "\n") && !strings.HasSuffix(s, "<") &&
+			(strings.HasPrefix(s, ">") || (strings.HasSuffix(s, "TO:") && s == strings.ToUpper(s))) {
+			return TransitionType, strings.TrimSpace(strings.TrimPrefix(s, ">")), ""
+		}
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimPrefix(line, "!")
+		}
+		return typeName, strings.Join(lines, "\n"), ""
+	case fountain.CharacterType:
+		return typeName, strings.TrimPrefix(s, "@"), ""
+	case fountain.TransitionType:
+		if strings.HasPrefix(s, ">") && strings.HasSuffix(s, "<") {
+			return ActionType, strings.TrimSpace(s[1 : len(s)-1]), CenterAlignment
+		}
+		return typeName, strings.TrimSpace(strings.TrimPrefix(s, ">")), ""
+	}
+	return typeName, content, ""
+}
+
+// FromFountain populates the document from a parsed Fountain screenplay
 func (document *FinalDraft) FromFountain(screenplay *fountain.Fountain) {
 	if screenplay.TitlePage != nil {
-		// Build the Info section
+		// Build the title page
 		if document.TitlePage == nil {
 			document.TitlePage = new(TitlePage)
 		}
 		if document.TitlePage.Content == nil {
 			document.TitlePage.Content = new(Content)
 		}
-
-		// NOTE: build a map of elements that will belong to Info.
-		for _, elem := range screenplay.TitlePage {
-			switch strings.ToLower(elem.Name) {
-			case "title":
-				paragraph := new(Paragraph)
-				text := new(Text)
-				text.InnerText = elem.Content
-				paragraph.Text = append(paragraph.Text, text)
-				document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
-			case "credit":
-				paragraph := new(Paragraph)
-				text := new(Text)
-				text.InnerText = elem.Content
-				paragraph.Text = append(paragraph.Text, text)
-				document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
-			case "author":
-				paragraph := new(Paragraph)
-				text := new(Text)
-				text.InnerText = elem.Content
-				paragraph.Text = append(paragraph.Text, text)
-				document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
-			case "copyright":
-				paragraph := new(Paragraph)
-				text := new(Text)
-				text.InnerText = elem.Content
-				paragraph.Text = append(paragraph.Text, text)
-				document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
-			case "draft date":
-				paragraph := new(Paragraph)
-				text := new(Text)
-				text.InnerText = elem.Content
-				paragraph.Text = append(paragraph.Text, text)
-				document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
-			case "contact":
-				paragraph := new(Paragraph)
-				text := new(Text)
-				text.InnerText = elem.Content
-				paragraph.Text = append(paragraph.Text, text)
-				document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
-			case "source":
-				paragraph := new(Paragraph)
-				text := new(Text)
-				text.InnerText = elem.Content
-				paragraph.Text = append(paragraph.Text, text)
-				document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
-			case "story by":
-				paragraph := new(Paragraph)
-				text := new(Text)
-				text.InnerText = elem.Content
-				paragraph.Text = append(paragraph.Text, text)
-				document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, paragraph)
-			}
-		}
+		document.TitlePage.Content.Paragraph = append(document.TitlePage.Content.Paragraph, titlePageLayout(screenplay.TitlePage)...)
 	}
 
 	if screenplay.Elements != nil {
@@ -118,12 +402,38 @@ func (document *FinalDraft) FromFountain(screenplay *fountain.Fountain) {
 		if document.Content == nil {
 			document.Content = new(Content)
 		}
+		newPage := false
+		var dual *DualDialogue
 		for _, elem := range screenplay.Elements {
-			text := new(Text)
-			text.InnerText = elem.Content
+			if elem.Type == fountain.PageFeed {
+				// A page break at the top of the script is dropped
+				newPage = len(document.Content.Paragraph) > 0
+				continue
+			}
 			paragraph := new(Paragraph)
-			paragraph.Type = elem.TypeName()
-			paragraph.Text = append(paragraph.Text, text)
+			content := ""
+			paragraph.Type, content, paragraph.Alignment = stripForcedMarkers(elem.Type, elem.TypeName(), elem.Content)
+			if paragraph.Type == SceneHeadingType {
+				content, paragraph.Number = splitSceneNumber(content)
+			}
+			if paragraph.Type == CharacterType {
+				name, isDual := isDualDialogueCharacter(content)
+				if isDual {
+					paragraph.Text = StringToTextArray(name)
+					dual = document.Content.startDualDialogue(paragraph)
+					continue
+				}
+			}
+			paragraph.Text = StringToTextArray(content)
+			if dual != nil && (paragraph.Type == ParentheticalType || paragraph.Type == DialogueType) {
+				dual.Paragraph = append(dual.Paragraph, paragraph)
+				continue
+			}
+			dual = nil
+			if newPage {
+				paragraph.StartsNewPage = "Yes"
+				newPage = false
+			}
 			document.Content.Paragraph = append(document.Content.Paragraph, paragraph)
 		}
 	}
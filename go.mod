@@ -1,7 +1,11 @@
 module github.com/rsdoiel/fdx
 
-go 1.22.0
+go 1.23.0
 
-require github.com/rsdoiel/fountain v1.0.1
+require (
+	github.com/rsdoiel/fountain v1.0.1
+	golang.org/x/image v0.18.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require golang.org/x/text v0.16.0 // indirect
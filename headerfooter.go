@@ -0,0 +1,262 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// measure parses a Final Draft number (e.g. an indent in inches, a
+// margin in points or a line spacing) returning fallback when s is
+// empty or invalid.
+func measure(s string, fallback float64) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
+
+// columns converts a measurement in inches to columns of monospace text
+func columns(f float64) int {
+	return int(math.Round(f * float64(CharactersPerInch)))
+}
+
+// padTo pads s with spaces until it is col characters long, if s is
+// already that long a single space is added.
+func padTo(s string, col int) string {
+	l := len([]rune(s))
+	if col <= l {
+		return s + " "
+	}
+	return s + strings.Repeat(" ", col-l)
+}
+
+// ExpandTabs lays out s replacing each tab character with the spaces
+// needed to reach the paragraph's next tabstop. Tabstop positions are
+// measured in inches from the left edge of the page while the
+// returned text starts at the paragraph's left indent. Left, Center
+// and Right tabstops are supported, when no tabstop remains tabs
+// advance to the next half inch.
+func (paragraph *Paragraph) ExpandTabs(s string) string {
+	if strings.Contains(s, "\t") == false {
+		return s
+	}
+	leftIndent := measure(paragraph.LeftIndent, 0)
+	stops := []*Tabstop{}
+	if paragraph.Tabstops != nil {
+		stops = paragraph.Tabstops.Tabstop
+	}
+	parts := strings.Split(s, "\t")
+	line := parts[0]
+	for _, part := range parts[1:] {
+		l := len([]rune(line))
+		partLen := len([]rune(part))
+		col := -1
+		for _, stop := range stops {
+			pos := columns(measure(stop.Position, 0) - leftIndent)
+			start := pos
+			switch stop.Type {
+			case RightType:
+				start = pos - partLen
+			case CenterType:
+				start = pos - (partLen / 2)
+			}
+			if pos > l && start >= l {
+				col = start
+				break
+			}
+		}
+		if col < 0 {
+			halfInch := CharactersPerInch / 2
+			col = ((l / halfInch) + 1) * halfInch
+		}
+		line = padTo(line, col) + part
+	}
+	return line
+}
+
+// labeledText returns the paragraph's Text and DynamicLabel elements,
+// in document order, as a string. DynamicLabel values are provided by
+// the label function.
+func (paragraph *Paragraph) labeledText(label func(string) string) string {
+	src := []string{}
+	ti, di := 0, 0
+	for _, name := range paragraph.order {
+		switch name {
+		case "Text":
+			if ti < len(paragraph.Text) {
+				src = append(src, paragraph.Text[ti].String())
+				ti++
+			}
+		case "DynamicLabel":
+			if di < len(paragraph.DynamicLabel) {
+				src = append(src, label(paragraph.DynamicLabel[di].Type))
+				di++
+			}
+		}
+	}
+	// NOTE: paragraphs created (or added to) outside of Parse put
+	// their labels ahead of their text.
+	for ; di < len(paragraph.DynamicLabel); di++ {
+		src = append(src, label(paragraph.DynamicLabel[di].Type))
+	}
+	for ; ti < len(paragraph.Text); ti++ {
+		src = append(src, paragraph.Text[ti].String())
+	}
+	return strings.Join(src, "")
+}
+
+// headerTemplate returns the paragraph's text with placeholder (e.g.
+// "#") standing in for the page number.
+func (paragraph *Paragraph) headerTemplate(placeholder string) string {
+	return paragraph.labeledText(func(labelType string) string {
+		if labelType == PageNoType {
+			return placeholder
+		}
+		return ""
+	})
+}
+
+// headerParagraph builds a header or footer paragraph from a template
+// (e.g. "#.") where placeholder marks the page number. left and right
+// are the page margins in inches.
+func headerParagraph(s string, placeholder string, alignment string, left float64, right float64) Paragraph {
+	paragraph := Paragraph{}
+	paragraph.Alignment = alignment
+	paragraph.LeftIndent = fmt.Sprintf("%.2f", left)
+	paragraph.RightIndent = fmt.Sprintf("%.2f", -right)
+	for i, part := range strings.Split(s, placeholder) {
+		if i > 0 {
+			label := new(DynamicLabel)
+			label.Type = PageNoType
+			paragraph.DynamicLabel = append(paragraph.DynamicLabel, label)
+			paragraph.order = append(paragraph.order, "DynamicLabel")
+		}
+		if part != "" {
+			paragraph.Text = append(paragraph.Text, &Text{InnerText: part})
+			paragraph.order = append(paragraph.order, "Text")
+		}
+	}
+	return paragraph
+}
+
+// headerFooterLines lays out header or footer paragraphs as lines of
+// plain text honoring alignment, indents and tabstops. Each line's X
+// is the paragraph's left indent.
+func (document *FinalDraft) headerFooterLines(paragraphs []Paragraph, pageNo int, lastRevised string) []*Line {
+	pageWidth, _ := document.PageSize()
+	label := func(labelType string) string {
+		switch labelType {
+		case PageNoType:
+			return strconv.Itoa(pageNo)
+		case LastRevisedType:
+			return lastRevised
+		}
+		return ""
+	}
+	lines := []*Line{}
+	for i := range paragraphs {
+		paragraph := &paragraphs[i]
+		leftIndent := measure(paragraph.LeftIndent, 1.5)
+		rightIndent := measure(paragraph.RightIndent, pageWidth-1)
+		if rightIndent <= 0 {
+			// NOTE: header and footer right indents are relative
+			// to the right edge of the page.
+			rightIndent = pageWidth + rightIndent
+		}
+		width := columns(rightIndent - leftIndent)
+		for _, s := range strings.Split(paragraph.labeledText(label), "\n") {
+			hasTabs := strings.Contains(s, "\t")
+			s = paragraph.ExpandTabs(s)
+			l := len([]rune(s))
+			if hasTabs == false && l < width {
+				switch paragraph.Alignment {
+				case RightAlignment:
+					s = strings.Repeat(" ", width-l) + s
+				case CenterAlignment:
+					s = strings.Repeat(" ", (width-l)/2) + s
+				}
+			}
+			line := new(Line)
+			line.Paragraph = i
+			line.Y = float64(len(lines))
+			line.X = leftIndent
+			line.Text = strings.TrimRight(s, " ")
+			lines = append(lines, line)
+		}
+	}
+	// Trailing blank lines are dropped
+	for len(lines) > 0 && lines[len(lines)-1].Text == "" {
+		lines = lines[0 : len(lines)-1]
+	}
+	return lines
+}
+
+// renderHeaderFooter renders header or footer paragraphs as plain text
+// honoring alignment, indents and tabstops.
+func (document *FinalDraft) renderHeaderFooter(paragraphs []Paragraph, pageNo int, lastRevised string) string {
+	src := []string{}
+	for _, line := range document.headerFooterLines(paragraphs, pageNo, lastRevised) {
+		src = append(src, line.Text)
+	}
+	return strings.Join(src, "\n")
+}
+
+// HeaderText returns the document's header for page pageNo as plain
+// text. lastRevised is used for "Last Revised" labels. An empty
+// string is returned when the header isn't shown on the page.
+func (document *FinalDraft) HeaderText(pageNo int, lastRevised string) string {
+	if document == nil || document.HeaderAndFooter == nil {
+		return ""
+	}
+	hf := document.HeaderAndFooter
+	if hf.HeaderVisible == "No" || (pageNo == 1 && hf.HeaderFirstPage == "No") {
+		return ""
+	}
+	return document.renderHeaderFooter(hf.Header.Paragraph, pageNo, lastRevised)
+}
+
+// FooterText returns the document's footer for page pageNo as plain
+// text. lastRevised is used for "Last Revised" labels. An empty
+// string is returned when the footer isn't shown on the page.
+func (document *FinalDraft) FooterText(pageNo int, lastRevised string) string {
+	if document == nil || document.HeaderAndFooter == nil {
+		return ""
+	}
+	hf := document.HeaderAndFooter
+	if hf.FooterVisible == "No" || (pageNo == 1 && hf.FooterFirstPage == "No") {
+		return ""
+	}
+	return document.renderHeaderFooter(hf.Footer.Paragraph, pageNo, lastRevised)
+}
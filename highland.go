@@ -0,0 +1,79 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+var (
+	// highlandTextExts are the extensions of the screenplay text in a
+	// Highland 2 TextBundle, in order of preference.
+	highlandTextExts = []string{".fountain", ".highland", ".md", ".markdown", ".txt"}
+)
+
+// ParseHighland takes []byte holding a Highland 2 (.highland) file and
+// returns a FinalDraft struct and error. A .highland file is a zip
+// archive of a TextBundle, the screenplay is the Fountain text (e.g.
+// text.fountain) next to its info.json metadata and assets.
+func ParseHighland(src []byte) (*FinalDraft, error) {
+	files, names, err := unzip(src)
+	if err != nil {
+		return nil, err
+	}
+	for _, ext := range highlandTextExts {
+		for _, name := range names {
+			base := path.Base(name)
+			if strings.HasPrefix(base, "text.") && strings.EqualFold(path.Ext(base), ext) {
+				return ParseFountain(files[name])
+			}
+		}
+	}
+	// Fallback to the first Fountain file in the archive
+	for _, name := range names {
+		if strings.EqualFold(path.Ext(name), ".fountain") {
+			return ParseFountain(files[name])
+		}
+	}
+	return nil, fmt.Errorf("no Fountain text found in Highland file")
+}
+
+// ParseHighlandFile takes a filename of a Highland 2 file and returns
+// a FinalDraft struct and error
+func ParseHighlandFile(fname string) (*FinalDraft, error) {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseHighland(src)
+}
@@ -0,0 +1,298 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// ScrippetsCSS styles the HTML rendered by ToHTML following the
+	// Scrippets (https://fountain.io/scrippets) layout.
+	ScrippetsCSS = `.scrippet {
+  width: 6in;
+  margin: 1em auto;
+  padding: 1em 0.5in;
+  font-family: "Courier Prime", "Courier Final Draft", Courier, monospace;
+  font-size: 12pt;
+  line-height: 1;
+  background: #fff;
+  color: #000;
+}
+.scrippet p { margin: 1em 0 0 0; }
+.scrippet .title-page { text-align: center; margin-bottom: 3em; }
+.scrippet .title-page .draft-date, .scrippet .title-page .contact,
+.scrippet .title-page .copyright { text-align: left; }
+.scrippet .sceneheader { text-transform: uppercase; margin-top: 2em; }
+.scrippet .character { text-transform: uppercase; margin-left: 2in; }
+.scrippet .parenthetical { margin: 0 0 0 1.5in; width: 2.5in; }
+.scrippet .dialogue { margin: 0 0 0 1in; width: 3.5in; }
+.scrippet .singing { margin: 0 0 0 1in; width: 3.5in; font-style: italic; }
+.scrippet .transition { text-transform: uppercase; text-align: right; }
+.scrippet .shot { text-transform: uppercase; }
+.scrippet .center { text-align: center; }
+.scrippet .page-break { page-break-before: always; border-top: 1px dashed #ccc; padding-top: 1em; }
+.scrippet .dual-dialogue { display: flex; gap: 0.4in; }
+.scrippet .dual-dialogue > div { flex: 1; }
+.scrippet .dual-dialogue .character { margin-left: 0; text-align: center; }
+.scrippet .dual-dialogue .parenthetical { margin-left: 0.3in; width: auto; }
+.scrippet .dual-dialogue .dialogue { margin-left: 0; width: auto; }
+.scrippet .note { color: #666; background: #ffffcc; }
+.scrippet .scene-number { float: right; }
+`
+)
+
+// htmlClass returns the Scrippets class of a paragraph type, types
+// Scrippets doesn't define become lower case with dashes (e.g.
+// "Cast List" is "cast-list").
+func htmlClass(elementType string) string {
+	switch elementType {
+	case SceneHeadingType:
+		return "sceneheader"
+	case "":
+		return "general"
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(elementType)), " ", "-")
+}
+
+// htmlRun renders a Text element as HTML, styles become <em>,
+// <strong>, <u> and <del>.
+func htmlRun(text *Text, allCaps bool) string {
+	src := text.InnerText
+	if allCaps || strings.Contains(text.Style, AllCapsStyle) || strings.Contains(text.Font, "Capitals") {
+		src = strings.ToUpper(src)
+	}
+	src = strings.ReplaceAll(html.EscapeString(src), "\n", "<br>\n")
+	if strings.Contains(text.Style, ItalicStyle) {
+		src = "<em>" + src + "</em>"
+	}
+	if strings.Contains(text.Style, BoldStyle) {
+		src = "<strong>" + src + "</strong>"
+	}
+	if strings.Contains(text.Style, UnderlineStyle) {
+		src = "<u>" + src + "</u>"
+	}
+	if strings.Contains(text.Style, Strikethrough) {
+		src = "<del>" + src + "</del>"
+	}
+	return src
+}
+
+// htmlNotes renders script notes as HTML
+func htmlNotes(notes []*ScriptNote) string {
+	src := []string{}
+	for _, note := range notes {
+		if s := strings.TrimSpace(note.Text()); s != "" {
+			src = append(src, `<span class="note">`+html.EscapeString(s)+`</span>`)
+		}
+	}
+	return strings.Join(src, " ")
+}
+
+// sceneAnchor returns the id of the nth (starting at one) scene
+// heading, the scene number is used when the scene is numbered.
+// Characters other than letters, digits, "-", "_" and "." become "-".
+// Ids already in used get a suffix (e.g. "scene-12-2").
+func sceneAnchor(paragraph *Paragraph, n int, used map[string]int) string {
+	id := fmt.Sprintf("scene-%d", n)
+	if number := strings.TrimSpace(paragraph.Number); number != "" {
+		id = "scene-" + strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+				return r
+			}
+			return '-'
+		}, number)
+	}
+	anchor := id
+	for used[anchor] > 0 {
+		used[id]++
+		anchor = fmt.Sprintf("%s-%d", id, used[id])
+	}
+	used[anchor]++
+	return anchor
+}
+
+// paragraphHTML renders a paragraph as a Scrippets <p>, id is used for scene
+// headings.
+func (document *FinalDraft) paragraphHTML(paragraph *Paragraph, id string) string {
+	if paragraph.DualDialogue != nil {
+		src := []string{`<div class="dual-dialogue">`}
+		for _, speech := range paragraph.DualDialogue.Speeches() {
+			src = append(src, "<div>")
+			for _, p := range speech {
+				src = append(src, document.paragraphHTML(p, ""))
+			}
+			src = append(src, "</div>")
+		}
+		src = append(src, "</div>")
+		return strings.Join(src, "\n")
+	}
+	settings := document.ElementSetting(paragraph.Type)
+	allCaps := settings.FontSpec != nil && strings.Contains(settings.FontSpec.Style, AllCapsStyle)
+	classes := []string{htmlClass(paragraph.Type)}
+	if paragraph.Alignment == CenterAlignment && paragraph.Type != CharacterType {
+		classes = append(classes, "center")
+	}
+	if paragraph.StartsNewPage == "Yes" {
+		classes = append(classes, "page-break")
+	}
+	attrs := fmt.Sprintf(` class="%s"`, strings.Join(classes, " "))
+	if id != "" {
+		attrs = fmt.Sprintf(` id="%s"`, html.EscapeString(id)) + attrs
+	}
+	runs := []string{}
+	for _, text := range paragraph.Text {
+		runs = append(runs, htmlRun(text, allCaps))
+	}
+	if paragraph.Type == SceneHeadingType && paragraph.Number != "" {
+		runs = append(runs, `<span class="scene-number">`+html.EscapeString(paragraph.Number)+`</span>`)
+	}
+	if ShowNotes && len(paragraph.ScriptNote) > 0 {
+		runs = append(runs, " "+htmlNotes(paragraph.ScriptNote))
+	}
+	return "<p" + attrs + ">" + strings.Join(runs, "") + "</p>"
+}
+
+// titlePageHTML renders the title page fields, an empty string is
+// returned when there is no title page.
+func (tp *TitlePage) titlePageHTML() string {
+	fields := tp.Fields()
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := []string{}
+	for _, key := range TitlePageKeys {
+		if _, ok := fields[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	others := []string{}
+	for key := range fields {
+		if !isTitlePageKey(key) {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	src := []string{`<div class="title-page">`}
+	for _, key := range append(keys, others...) {
+		// NOTE: field values hold Fountain emphasis
+		runs := []string{}
+		for _, text := range StringToTextArray(fields[key]) {
+			runs = append(runs, htmlRun(text, false))
+		}
+		value := strings.Join(runs, "")
+		class := htmlClass(key)
+		if key == "Title" {
+			src = append(src, fmt.Sprintf(`<h1 class="%s">%s</h1>`, class, value))
+		} else {
+			src = append(src, fmt.Sprintf(`<p class="%s">%s</p>`, class, value))
+		}
+	}
+	src = append(src, "</div>")
+	return strings.Join(src, "\n")
+}
+
+// ToHTML writes the screenplay as HTML following the Scrippets
+// convention, a <div class="scrippet"> holding a <p> per paragraph
+// with a class for its type (e.g. "sceneheader", "action",
+// "character", "dialogue"). Text styles become <em>, <strong> and <u>
+// and each scene heading gets an id (e.g. "scene-12A") to link to.
+// When fragment is true only the <div> is written so it can be
+// embedded in another page, otherwise a complete HTML document with
+// a list of links to the scenes is written. When embedCSS is true
+// the ScrippetsCSS is included in a <style> element.
+func (document *FinalDraft) ToHTML(w io.Writer, fragment bool, embedCSS bool) error {
+	out := bufio.NewWriter(w)
+	paragraphs := []*Paragraph{}
+	if document.Content != nil {
+		paragraphs = document.Content.Paragraph
+	}
+	ids := map[int]string{}
+	used := map[string]int{}
+	nav := []string{}
+	n := 0
+	for i, paragraph := range paragraphs {
+		if paragraph.Type == SceneHeadingType {
+			n++
+			ids[i] = sceneAnchor(paragraph, n, used)
+			heading := []string{}
+			for _, text := range paragraph.Text {
+				heading = append(heading, text.InnerText)
+			}
+			label := strings.ToUpper(strings.TrimSpace(strings.Join(heading, "")))
+			nav = append(nav, fmt.Sprintf(`<li><a href="#%s">%s</a></li>`, html.EscapeString(ids[i]), html.EscapeString(label)))
+		}
+	}
+	if !fragment {
+		title := []string{}
+		for _, text := range StringToTextArray(document.TitlePage.Fields()["Title"]) {
+			title = append(title, strings.ReplaceAll(text.InnerText, "\n", " "))
+		}
+		out.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+		if len(title) > 0 {
+			fmt.Fprintf(out, "<title>%s</title>\n", html.EscapeString(strings.Join(title, "")))
+		}
+	}
+	if embedCSS {
+		fmt.Fprintf(out, "<style>\n%s</style>\n", ScrippetsCSS)
+	}
+	if !fragment {
+		out.WriteString("</head>\n<body>\n")
+		if len(nav) > 0 {
+			fmt.Fprintf(out, "<nav class=\"scenes\">\n<ul>\n%s\n</ul>\n</nav>\n", strings.Join(nav, "\n"))
+		}
+	}
+	out.WriteString("<div class=\"scrippet\">\n")
+	if s := document.TitlePage.titlePageHTML(); s != "" {
+		out.WriteString(s + "\n")
+	}
+	for i, paragraph := range paragraphs {
+		if len(paragraph.Text) == 0 && paragraph.DualDialogue == nil {
+			continue
+		}
+		out.WriteString(document.paragraphHTML(paragraph, ids[i]) + "\n")
+	}
+	if ShowNotes && document.UnanchoredScriptNotes != nil {
+		if s := htmlNotes(document.UnanchoredScriptNotes.ScriptNote); s != "" {
+			out.WriteString("<p class=\"notes\">" + s + "</p>\n")
+		}
+	}
+	out.WriteString("</div>\n")
+	if !fragment {
+		out.WriteString("</body>\n</html>\n")
+	}
+	return out.Flush()
+}
@@ -0,0 +1,132 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	// My Packages
+	"github.com/rsdoiel/fountain"
+)
+
+var (
+	// MaxArchiveEntrySize and MaxArchiveSize limit the bytes read
+	// from a file in a zipped project (e.g. .celtx, .highland, .fadein)
+	// and from all of its files so a crafted archive (a zip bomb)
+	// can't exhaust memory.
+	MaxArchiveEntrySize = int64(64 << 20)
+	MaxArchiveSize      = int64(256 << 20)
+)
+
+// unzip returns the files in a zip archive by name along with the
+// names in archive order. Directories are skipped. An error is
+// returned if a file is larger than MaxArchiveEntrySize or the files
+// together are larger than MaxArchiveSize.
+func unzip(src []byte) (map[string][]byte, []string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(src), int64(len(src)))
+	if err != nil {
+		return nil, nil, err
+	}
+	files, names := map[string][]byte{}, []string{}
+	total := int64(0)
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		limit := MaxArchiveEntrySize
+		if remaining := MaxArchiveSize - total; remaining < limit {
+			limit = remaining
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+		r.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		if int64(len(data)) > limit {
+			if limit < MaxArchiveEntrySize {
+				return nil, nil, fmt.Errorf("archive is larger than %d bytes", MaxArchiveSize)
+			}
+			return nil, nil, fmt.Errorf("%s is larger than %d bytes", f.Name, MaxArchiveEntrySize)
+		}
+		total += int64(len(data))
+		files[f.Name] = data
+		names = append(names, f.Name)
+	}
+	return files, names, nil
+}
+
+// ParseFountain takes []byte holding a Fountain screenplay and returns
+// a FinalDraft struct and error
+func ParseFountain(src []byte) (*FinalDraft, error) {
+	screenplay, err := fountain.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	document := NewFinalDraft()
+	document.FromFountain(screenplay)
+	return document, nil
+}
+
+// Import reads a screenplay in any of the supported formats picking
+// the parser by the file's extension, .fdx (Final Draft), .fadein
+// (Fade In), .celtx (Celtx), .highland (Highland 2), .trelby (Trelby),
+// .fountain, .spmd or .txt (Fountain).
+func Import(fname string) (*FinalDraft, error) {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	switch ext := strings.ToLower(filepath.Ext(fname)); ext {
+	case ".fdx":
+		return Parse(src)
+	case ".fadein":
+		return ParseFadeIn(src)
+	case ".celtx":
+		return ParseCeltx(src)
+	case ".highland":
+		return ParseHighland(src)
+	case ".trelby":
+		return ParseTrelby(src)
+	case ".fountain", ".spmd", ".txt":
+		return ParseFountain(src)
+	default:
+		return nil, fmt.Errorf("%s, unsupported file type %q", fname, ext)
+	}
+}
@@ -0,0 +1,230 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageWidth and DefaultPageHeight are US Letter in inches
+	DefaultPageWidth  = 8.5
+	DefaultPageHeight = 11.0
+
+	// DefaultTargetScriptLength is the page count Final Draft targets
+	// for a new script
+	DefaultTargetScriptLength = 120
+
+	// DefaultWatermarkOpacity is the opacity (percent) Final Draft uses
+	// for a new watermark
+	DefaultWatermarkOpacity = 70
+)
+
+// NewWatermarking returns a Watermarking struct populated with Final Draft's
+// defaults (no text, 70% opacity, diagonal descending)
+func NewWatermarking() *Watermarking {
+	watermark := new(Watermarking)
+	watermark.Opacity = strconv.Itoa(DefaultWatermarkOpacity)
+	watermark.Position = DiagonalDescendingPosition
+	watermark.DynamicContent = new(DynamicContent)
+	watermark.Distribution = new(Distribution)
+	watermark.WatermarkImage = new(WatermarkImage)
+	watermark.WatermarkImage.Height = "144"
+	return watermark
+}
+
+// Text returns the watermark text
+func (watermark *Watermarking) Text() string {
+	if watermark == nil || watermark.DynamicContent == nil {
+		return ""
+	}
+	src := []string{}
+	for _, paragraph := range watermark.DynamicContent.Paragraph {
+		s := []string{}
+		for _, text := range paragraph.Text {
+			s = append(s, text.InnerText)
+		}
+		src = append(src, strings.Join(s, ""))
+	}
+	return strings.Join(src, "\n")
+}
+
+// SetText replaces the watermark text. Each line of s becomes a paragraph.
+func (watermark *Watermarking) SetText(s string) {
+	if watermark.DynamicContent == nil {
+		watermark.DynamicContent = new(DynamicContent)
+	}
+	watermark.DynamicContent.Paragraph = []*Paragraph{}
+	for _, line := range strings.Split(s, "\n") {
+		paragraph := new(Paragraph)
+		paragraph.Alignment = LeftAlignment
+		text := new(Text)
+		text.InnerText = line
+		paragraph.Text = append(paragraph.Text, text)
+		watermark.DynamicContent.Paragraph = append(watermark.DynamicContent.Paragraph, paragraph)
+	}
+}
+
+// OpacityPercent returns the watermark opacity as a percentage (0 to 100)
+func (watermark *Watermarking) OpacityPercent() int {
+	if watermark == nil || watermark.Opacity == "" {
+		return DefaultWatermarkOpacity
+	}
+	i, err := strconv.Atoi(watermark.Opacity)
+	if err != nil {
+		return DefaultWatermarkOpacity
+	}
+	return i
+}
+
+// SetOpacity sets the watermark opacity, percent is limited to 0 to 100
+func (watermark *Watermarking) SetOpacity(percent int) {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	watermark.Opacity = strconv.Itoa(percent)
+}
+
+// SetPosition sets the watermark position (e.g. DiagonalDescendingPosition)
+func (watermark *Watermarking) SetPosition(position string) {
+	watermark.Position = position
+}
+
+// NewPageSize returns a PageSize struct for US Letter
+func NewPageSize() *PageSize {
+	pageSize := new(PageSize)
+	pageSize.SetInches(DefaultPageWidth, DefaultPageHeight)
+	return pageSize
+}
+
+// Inches returns the page width and height in inches. Missing or
+// invalid values are reported as US Letter.
+func (pageSize *PageSize) Inches() (float64, float64) {
+	width, height := DefaultPageWidth, DefaultPageHeight
+	if pageSize != nil {
+		if f, err := strconv.ParseFloat(pageSize.Width, 64); err == nil && f > 0 {
+			width = f
+		}
+		if f, err := strconv.ParseFloat(pageSize.Height, 64); err == nil && f > 0 {
+			height = f
+		}
+	}
+	return width, height
+}
+
+// SetInches sets the page width and height in inches
+func (pageSize *PageSize) SetInches(width float64, height float64) {
+	pageSize.Width = fmt.Sprintf("%.2f", width)
+	pageSize.Height = fmt.Sprintf("%.2f", height)
+}
+
+// NewTargetScriptLength returns a TargetScriptLength struct set to
+// Final Draft's default of 120 pages
+func NewTargetScriptLength() *TargetScriptLength {
+	target := new(TargetScriptLength)
+	target.SetPages(DefaultTargetScriptLength)
+	return target
+}
+
+// Pages returns the target length in pages, zero if it isn't set
+func (target *TargetScriptLength) Pages() int {
+	if target == nil {
+		return 0
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(target.InnerText))
+	if err != nil {
+		return 0
+	}
+	return i
+}
+
+// SetPages sets the target length in pages
+func (target *TargetScriptLength) SetPages(pages int) {
+	target.InnerText = strconv.Itoa(pages)
+}
+
+// PageSize returns the width and height of the document's pages in inches
+func (document *FinalDraft) PageSize() (float64, float64) {
+	if document == nil || document.PageLayout == nil {
+		return DefaultPageWidth, DefaultPageHeight
+	}
+	return document.PageLayout.PageSize.Inches()
+}
+
+// SetPageSize sets the width and height of the document's pages in inches
+func (document *FinalDraft) SetPageSize(width float64, height float64) {
+	if document.PageLayout == nil {
+		document.PageLayout = new(PageLayout)
+	}
+	if document.PageLayout.PageSize == nil {
+		document.PageLayout.PageSize = new(PageSize)
+	}
+	document.PageLayout.PageSize.SetInches(width, height)
+}
+
+// WatermarkText returns the document's watermark text
+func (document *FinalDraft) WatermarkText() string {
+	if document == nil {
+		return ""
+	}
+	return document.Watermarking.Text()
+}
+
+// SetWatermark sets the document's watermark text, opacity (percent)
+// and position
+func (document *FinalDraft) SetWatermark(text string, opacity int, position string) {
+	if document.Watermarking == nil {
+		document.Watermarking = NewWatermarking()
+	}
+	document.Watermarking.SetText(text)
+	document.Watermarking.SetOpacity(opacity)
+	document.Watermarking.SetPosition(position)
+}
+
+// TargetPages returns the document's target script length in pages
+func (document *FinalDraft) TargetPages() int {
+	if document == nil {
+		return 0
+	}
+	return document.TargetScriptLength.Pages()
+}
+
+// SetTargetPages sets the document's target script length in pages
+func (document *FinalDraft) SetTargetPages(pages int) {
+	if document.TargetScriptLength == nil {
+		document.TargetScriptLength = new(TargetScriptLength)
+	}
+	document.TargetScriptLength.SetPages(pages)
+}
@@ -0,0 +1,242 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DayTime and NightTime classify a scene's time of day
+	DayTime   = "Day"
+	NightTime = "Night"
+)
+
+var (
+	// NightTimes and DayTimes are the words classifying a time of
+	// day as night or day, night is checked first.
+	NightTimes = []string{"NIGHT", "EVENING", "DUSK", "SUNSET"}
+	DayTimes   = []string{"DAY", "MORNING", "AFTERNOON", "NOON", "DAWN", "SUNRISE"}
+)
+
+// LocationStats groups the scenes set at a location
+type LocationStats struct {
+	// Name of the location (e.g. "HOUSE") or sub-location (e.g. "KITCHEN")
+	Name string `json:"name" yaml:"name"`
+	// IntExt holds the scene intros used at the location (e.g. "INT.")
+	IntExt []string `json:"int_ext,omitempty" yaml:"int_ext,omitempty"`
+	// Scenes holds the scene numbers, the position of the scene in
+	// the script for scenes without a number
+	Scenes []string `json:"scenes" yaml:"scenes"`
+	// Day and Night count the day and night scenes
+	Day   int `json:"day" yaml:"day"`
+	Night int `json:"night" yaml:"night"`
+	// Length is the total length of the scenes (e.g. "1 3/8")
+	Length string `json:"length,omitempty" yaml:"length,omitempty"`
+	// Eighths is the total length of the scenes in eighths of a page
+	Eighths int `json:"eighths" yaml:"eighths"`
+	// SubLocations groups the scenes set at parts of the location
+	// (e.g. "KITCHEN" in "HOUSE - KITCHEN")
+	SubLocations []*LocationStats `json:"sub_locations,omitempty" yaml:"sub_locations,omitempty"`
+}
+
+// dayOrNight classifies a time of day as DayTime or NightTime,
+// returning an empty string if it doesn't say (e.g. "CONTINUOUS").
+func dayOrNight(timeOfDay string) string {
+	timeOfDay = strings.ToUpper(timeOfDay)
+	for _, s := range NightTimes {
+		if strings.Contains(timeOfDay, s) {
+			return NightTime
+		}
+	}
+	for _, s := range DayTimes {
+		if strings.Contains(timeOfDay, s) {
+			return DayTime
+		}
+	}
+	return ""
+}
+
+// splitLocation returns a scene's location and sub-location. The
+// location is the longest SmartType location the scene's location
+// starts with, otherwise the text before the first separator.
+// locations holds the SmartType locations (see smartTypeLocations).
+func (document *FinalDraft) splitLocation(location string, locations map[string]bool) (string, string) {
+	sep := document.timeOfDaySeparator()
+	n := 0
+	for known := range locations {
+		if len(known) > n && len(known)+len(sep) <= len(location) &&
+			strings.EqualFold(location[0:len(known)+len(sep)], known+sep) {
+			n = len(known)
+		}
+	}
+	if n == 0 {
+		n = strings.Index(location, sep)
+	}
+	if n <= 0 {
+		return location, ""
+	}
+	return strings.TrimSpace(location[0:n]), strings.TrimSpace(location[n+len(sep):])
+}
+
+// sceneDayNights returns DayTime, NightTime or an empty string for
+// each scene. A time of day that isn't day or night (e.g. "CONTINUOUS")
+// follows the scene before when it is set at the same location.
+// locations holds the SmartType locations (see smartTypeLocations).
+func (document *FinalDraft) sceneDayNights(scenes []*Scene, locations map[string]bool) []string {
+	dayNights := make([]string, len(scenes))
+	previous, previousName := "", ""
+	for i, scene := range scenes {
+		name, _ := document.splitLocation(scene.Location, locations)
+		if !strings.EqualFold(name, previousName) {
+			previous = ""
+		}
+		previousName = name
+		dayNights[i] = dayOrNight(scene.TimeOfDay)
+		if dayNights[i] == "" {
+			dayNights[i] = previous
+		}
+		previous = dayNights[i]
+	}
+	return dayNights
+}
+
+// add adds a scene to the location
+func (stats *LocationStats) add(scene *Scene, number string, dayNight string) {
+	stats.Scenes = append(stats.Scenes, number)
+	if scene.IntExt != "" {
+		found := false
+		for _, s := range stats.IntExt {
+			found = found || strings.EqualFold(s, scene.IntExt)
+		}
+		if !found {
+			stats.IntExt = append(stats.IntExt, scene.IntExt)
+		}
+	}
+	switch dayNight {
+	case DayTime:
+		stats.Day++
+	case NightTime:
+		stats.Night++
+	}
+	stats.Eighths += scene.Eighths
+	stats.Length = FormatEighths(stats.Eighths)
+}
+
+// sortLocations sorts locations by name
+func sortLocations(list []*LocationStats) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return strings.ToUpper(list[i].Name) < strings.ToUpper(list[j].Name)
+	})
+}
+
+// LocationStats returns the script's locations, sorted by name, with
+// the scenes set at each, the day and night split and total length.
+// Scene headings are split using SmartType's scene intros, locations
+// and time of day separator (e.g. "INT. HOUSE - KITCHEN - NIGHT" is
+// the KITCHEN sub-location of HOUSE). A time of day that isn't day or
+// night (e.g. "CONTINUOUS", "LATER") follows the scene before when
+// it is set at the same location.
+// The totals of a location include its sub-locations.
+func (document *FinalDraft) LocationStats() []*LocationStats {
+	list := []*LocationStats{}
+	byName := map[string]*LocationStats{}
+	known := document.smartTypeLocations()
+	scenes := document.Scenes()
+	dayNights := document.sceneDayNights(scenes, known)
+	for i, scene := range scenes {
+		number := scene.Number
+		if number == "" {
+			number = strconv.Itoa(i + 1)
+		}
+		name, sub := document.splitLocation(scene.Location, known)
+		dayNight := dayNights[i]
+		if scene.Location == "" {
+			continue
+		}
+		stats, ok := byName[strings.ToUpper(name)]
+		if !ok {
+			stats = &LocationStats{Name: name}
+			byName[strings.ToUpper(name)] = stats
+			list = append(list, stats)
+		}
+		stats.add(scene, number, dayNight)
+		if sub == "" {
+			continue
+		}
+		var subStats *LocationStats
+		for _, s := range stats.SubLocations {
+			if strings.EqualFold(s.Name, sub) {
+				subStats = s
+			}
+		}
+		if subStats == nil {
+			subStats = &LocationStats{Name: sub}
+			stats.SubLocations = append(stats.SubLocations, subStats)
+		}
+		subStats.add(scene, number, dayNight)
+	}
+	sortLocations(list)
+	for _, stats := range list {
+		sortLocations(stats.SubLocations)
+	}
+	return list
+}
+
+// WriteLocationReport writes the locations as a table, CSV or JSON
+// (see TableFormat, CSVFormat and JSONFormat). Each location is
+// followed by its sub-locations.
+func (document *FinalDraft) WriteLocationReport(w io.Writer, format string) error {
+	list := document.LocationStats()
+	header := []string{"Location", "Sub-location", "Int/Ext", "Scenes", "Day", "Night", "Length", "Scene Numbers"}
+	rows := [][]string{}
+	row := func(name string, sub string, stats *LocationStats) []string {
+		return []string{
+			name,
+			sub,
+			strings.Join(stats.IntExt, ", "),
+			strconv.Itoa(len(stats.Scenes)),
+			strconv.Itoa(stats.Day),
+			strconv.Itoa(stats.Night),
+			stats.Length,
+			strings.Join(stats.Scenes, ", "),
+		}
+	}
+	for _, stats := range list {
+		rows = append(rows, row(stats.Name, "", stats))
+		for _, sub := range stats.SubLocations {
+			rows = append(rows, row(stats.Name, sub.Name, sub))
+		}
+	}
+	return writeReport(w, format, header, rows, list)
+}
@@ -0,0 +1,167 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Line types of the markers pagination adds at page breaks
+	DialogueBottomType = "Dialogue Bottom"
+	DialogueTopType    = "Dialogue Top"
+	SceneBottomType    = "Scene Bottom"
+	SceneTopType       = "Scene Top"
+)
+
+var (
+	// DefaultDialogueBreaks are Final Draft's default (MORE) and
+	// (CONT'D) settings used when a document doesn't provide them
+	DefaultDialogueBreaks = &DialogueBreaks{
+		AutomaticCharacterContinueds: "Yes",
+		BottomOfPage:                 "Yes",
+		DialogueBottom:               "(MORE)",
+		DialogueTop:                  "(CONT'D)",
+		TopOfNext:                    "Yes",
+	}
+
+	// DefaultSceneBreaks are Final Draft's default CONTINUED settings
+	// used when a document doesn't provide them
+	DefaultSceneBreaks = &SceneBreaks{
+		ContinuedNumber:   "No",
+		SceneBottom:       "(CONTINUED)",
+		SceneBottomOfPage: "No",
+		SceneTop:          "CONTINUED:",
+		SceneTopOfNext:    "No",
+	}
+
+	// reExtension matches a character extension (e.g. "(V.O.)")
+	reExtension = regexp.MustCompile(`\s*\([^)]*\)`)
+)
+
+// breakSettings returns the document's dialogue and scene break
+// settings falling back to the defaults.
+func (document *FinalDraft) breakSettings() (*DialogueBreaks, *SceneBreaks) {
+	dialogue, scene := DefaultDialogueBreaks, DefaultSceneBreaks
+	if mc := document.MoresAndContinueds; mc != nil {
+		if mc.DialogueBreaks != nil {
+			dialogue = mc.DialogueBreaks
+		}
+		if mc.SceneBreaks != nil {
+			scene = mc.SceneBreaks
+		}
+	}
+	return dialogue, scene
+}
+
+// speakerName returns a character's name without extensions (e.g.
+// "(V.O.)", "(CONT'D)") for comparing speakers.
+func speakerName(s string) string {
+	return strings.TrimSpace(reExtension.ReplaceAllString(strings.ToUpper(s), ""))
+}
+
+// addContinued appends the dialogue top marker (e.g. "(CONT'D)") to a
+// character's laid out name unless it is already there.
+func addContinued(lines []*Line, marker string) {
+	if len(lines) == 0 || marker == "" {
+		return
+	}
+	line := lines[len(lines)-1]
+	if strings.HasSuffix(strings.ToUpper(line.Text), strings.ToUpper(marker)) {
+		return
+	}
+	line.Text += " " + marker
+	run := new(Text)
+	run.InnerText = " " + marker
+	line.Runs = append(line.Runs, run)
+}
+
+// marker adds a line of marker text at the current position
+func (p *paginator) marker(index int, markerType string, text string, x float64) {
+	line := new(Line)
+	line.Paragraph = index
+	line.Type = markerType
+	line.Y = p.y
+	line.X = x
+	line.Text = text
+	line.Runs = []*Text{&Text{InnerText: text}}
+	p.page.Lines = append(p.page.Lines, line)
+	p.y++
+}
+
+// reserve returns the lines kept free at the bottom of a page for the
+// markers added when a page breaks in a scene or speech.
+func (p *paginator) reserve(inSpeech bool, inScene bool) float64 {
+	r := 0.0
+	if inSpeech && p.dialogue.BottomOfPage == "Yes" {
+		r++
+	}
+	if inScene && p.scene.SceneBottomOfPage == "Yes" {
+		r++
+	}
+	return r
+}
+
+// breakPage ends the page and starts the next adding (MORE),
+// (CONTINUED), CONTINUED: and NAME (CONT'D) markers as the settings
+// request. continuing is true when the page breaks in a speech,
+// startsScene when the next page starts with a scene heading.
+func (p *paginator) breakPage(index int, continuing bool, startsScene bool) {
+	inScene := p.inScene && !startsScene
+	if continuing && p.dialogue.BottomOfPage == "Yes" {
+		p.marker(index, DialogueBottomType, p.dialogue.DialogueBottom, p.characterX)
+	}
+	if inScene && p.scene.SceneBottomOfPage == "Yes" {
+		text := p.scene.SceneBottom
+		p.marker(index, SceneBottomType, text, p.rightX-float64(len([]rune(text)))/float64(CharactersPerInch))
+	}
+	p.newPage()
+	if inScene {
+		p.scenePages++
+		if p.scene.SceneTopOfNext == "Yes" {
+			text := p.scene.SceneTop
+			if p.scene.ContinuedNumber == "Yes" {
+				text = fmt.Sprintf("%s (%d)", text, p.scenePages)
+			}
+			p.marker(index, SceneTopType, text, p.actionX)
+			p.y++
+		}
+	}
+	if continuing && p.dialogue.TopOfNext == "Yes" && p.speaker != "" {
+		text := p.speaker
+		if marker := p.dialogue.DialogueTop; marker != "" && !strings.HasSuffix(strings.ToUpper(text), strings.ToUpper(marker)) {
+			text += " " + marker
+		}
+		p.marker(index, DialogueTopType, text, p.characterX)
+	}
+	p.top = p.y
+}
@@ -0,0 +1,262 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Note describes a script note along with where it is anchored
+// and the definition (e.g. "Plot", "Character") it belongs to.
+type Note struct {
+	// ScriptNote is the note element as found in the document
+	ScriptNote *ScriptNote `json:"-" yaml:"-"`
+	// Paragraph is the paragraph the note is anchored to, nil for
+	// unanchored notes
+	Paragraph *Paragraph `json:"-" yaml:"-"`
+	// Index of the anchor paragraph in Content, for dual dialogue the
+	// index of the paragraph holding it, -1 for unanchored notes
+	Index int `json:"index" yaml:"index"`
+	// ID of the note
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+	// Name of the note's definition
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Color of the note, taken from the definition if the note has none
+	Color string `json:"color,omitempty" yaml:"color,omitempty"`
+	// Start and Length describe the range of anchor paragraph text the
+	// note applies to
+	Start  int `json:"start" yaml:"start"`
+	Length int `json:"length" yaml:"length"`
+	// Text of the note
+	Text string `json:"text,omitempty" yaml:"text,omitempty"`
+}
+
+// Text returns the note's text, each paragraph on its own line
+func (note *ScriptNote) Text() string {
+	if note == nil {
+		return ""
+	}
+	src := []string{}
+	for _, paragraph := range note.Paragraph {
+		src = append(src, paragraph.PlainText())
+	}
+	return strings.Join(src, "\n")
+}
+
+// SetText replaces the note's text, each line becomes a paragraph
+func (note *ScriptNote) SetText(s string) {
+	note.Paragraph = []*Paragraph{}
+	for _, line := range strings.Split(s, "\n") {
+		paragraph := new(Paragraph)
+		paragraph.Text = StringToTextArray(line)
+		note.Paragraph = append(note.Paragraph, paragraph)
+	}
+}
+
+// ParseRange returns the start and length of the note's Range
+// attribute (e.g. "12,5"). Zeros are returned if the range is
+// missing or malformed.
+func (note *ScriptNote) ParseRange() (int, int) {
+	parts := strings.SplitN(note.Range, ",", 2)
+	if len(parts) != 2 {
+		return 0, 0
+	}
+	start, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return 0, 0
+	}
+	return start, length
+}
+
+// SetRange sets the note's Range attribute
+func (note *ScriptNote) SetRange(start int, length int) {
+	note.Range = fmt.Sprintf("%d,%d", start, length)
+}
+
+// NoteDefinition returns the script note definition with the given
+// id, nil if it isn't defined.
+func (document *FinalDraft) NoteDefinition(id string) *ScriptNoteDefinition {
+	if document == nil || document.ScriptNoteDefinitions == nil {
+		return nil
+	}
+	for _, def := range document.ScriptNoteDefinitions.ScriptNoteDefinition {
+		if def.ID == id {
+			return def
+		}
+	}
+	return nil
+}
+
+// newNote populates a Note from a ScriptNote
+func (document *FinalDraft) newNote(scriptNote *ScriptNote, paragraph *Paragraph, index int) *Note {
+	note := new(Note)
+	note.ScriptNote = scriptNote
+	note.Paragraph = paragraph
+	note.Index = index
+	note.ID = scriptNote.ID
+	note.Color = scriptNote.Color
+	note.Start, note.Length = scriptNote.ParseRange()
+	note.Text = scriptNote.Text()
+	if def := document.NoteDefinition(scriptNote.DefinitionID); def != nil {
+		note.Name = def.Name
+		if note.Color == "" {
+			note.Color = def.Color
+		}
+	}
+	return note
+}
+
+// Notes returns the script notes in the document. Notes anchored to
+// paragraphs in Content (including dual dialogue) are listed first
+// (in document order) followed by unanchored notes.
+func (document *FinalDraft) Notes() []*Note {
+	notes := []*Note{}
+	if document == nil {
+		return notes
+	}
+	// add adds the notes of paragraph and those of the paragraphs in
+	// its dual dialogue, index is its index in Content.
+	var add func(paragraph *Paragraph, index int)
+	add = func(paragraph *Paragraph, index int) {
+		for _, scriptNote := range paragraph.ScriptNote {
+			notes = append(notes, document.newNote(scriptNote, paragraph, index))
+		}
+		if paragraph.DualDialogue != nil {
+			for _, p := range paragraph.DualDialogue.Paragraph {
+				add(p, index)
+			}
+		}
+	}
+	if document.Content != nil {
+		for i, paragraph := range document.Content.Paragraph {
+			add(paragraph, i)
+		}
+	}
+	if document.UnanchoredScriptNotes != nil {
+		for _, scriptNote := range document.UnanchoredScriptNotes.ScriptNote {
+			notes = append(notes, document.newNote(scriptNote, nil, -1))
+		}
+	}
+	return notes
+}
+
+// nextNoteID returns an unused script note ID
+func (document *FinalDraft) nextNoteID() string {
+	maxID := 0
+	for _, note := range document.Notes() {
+		if i, err := strconv.Atoi(note.ID); err == nil && i > maxID {
+			maxID = i
+		}
+	}
+	return strconv.Itoa(maxID + 1)
+}
+
+// AddNote anchors a new script note to a paragraph. start and length
+// describe the range of the paragraph's text the note applies to and
+// definitionID names a ScriptNoteDefinition (it may be empty).
+func (document *FinalDraft) AddNote(paragraph *Paragraph, start int, length int, definitionID string, text string) *ScriptNote {
+	scriptNote := new(ScriptNote)
+	scriptNote.ID = document.nextNoteID()
+	scriptNote.DefinitionID = definitionID
+	scriptNote.SetRange(start, length)
+	scriptNote.SetText(text)
+	paragraph.ScriptNote = append(paragraph.ScriptNote, scriptNote)
+	return scriptNote
+}
+
+// AddUnanchoredNote adds a script note that isn't attached to
+// any paragraph.
+func (document *FinalDraft) AddUnanchoredNote(definitionID string, text string) *ScriptNote {
+	scriptNote := new(ScriptNote)
+	scriptNote.ID = document.nextNoteID()
+	scriptNote.DefinitionID = definitionID
+	scriptNote.SetText(text)
+	if document.UnanchoredScriptNotes == nil {
+		document.UnanchoredScriptNotes = new(UnanchoredScriptNotes)
+	}
+	document.UnanchoredScriptNotes.ScriptNote = append(document.UnanchoredScriptNotes.ScriptNote, scriptNote)
+	return scriptNote
+}
+
+// removeScriptNote removes scriptNote from notes returning the new list
+// and true if it was found.
+func removeScriptNote(notes []*ScriptNote, scriptNote *ScriptNote) ([]*ScriptNote, bool) {
+	for i, note := range notes {
+		if note == scriptNote {
+			return append(notes[:i:i], notes[i+1:]...), true
+		}
+	}
+	return notes, false
+}
+
+// RemoveNote removes a script note (anchored or not) from the
+// document. It returns true if the note was found.
+func (document *FinalDraft) RemoveNote(scriptNote *ScriptNote) bool {
+	found := false
+	var walk func([]*Paragraph)
+	walk = func(paragraphs []*Paragraph) {
+		for _, paragraph := range paragraphs {
+			if found {
+				return
+			}
+			paragraph.ScriptNote, found = removeScriptNote(paragraph.ScriptNote, scriptNote)
+			if !found && paragraph.DualDialogue != nil {
+				walk(paragraph.DualDialogue.Paragraph)
+			}
+		}
+	}
+	if document.Content != nil {
+		walk(document.Content.Paragraph)
+	}
+	if found {
+		return true
+	}
+	if document.UnanchoredScriptNotes != nil {
+		document.UnanchoredScriptNotes.ScriptNote, found = removeScriptNote(document.UnanchoredScriptNotes.ScriptNote, scriptNote)
+	}
+	return found
+}
+
+// fountainNotes renders script notes as Fountain notes (e.g. "[[a note]]")
+func fountainNotes(notes []*ScriptNote) string {
+	src := []string{}
+	for _, note := range notes {
+		if s := strings.TrimSpace(note.Text()); s != "" {
+			src = append(src, "[["+s+"]]")
+		}
+	}
+	return strings.Join(src, " ")
+}
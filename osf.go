@@ -0,0 +1,185 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"encoding/xml"
+)
+
+//
+// Open Screenplay Format (OSF) is the XML format Fade In keeps in the
+// document.xml of a .fadein archive. Like the Final Draft structs,
+// attributes and elements not modeled here are kept in UnknownAttrs
+// and UnknownElements.
+//
+
+type OSFDocument struct {
+	XMLName         xml.Name          `xml:"document" json:"-" yaml:"-"`
+	Type            string            `xml:"type,attr,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	Version         string            `xml:"version,attr,omitempty" json:"version,omitempty" yaml:"version,omitempty"`
+	Info            *OSFInfo          `xml:"info" json:"info,omitempty" yaml:"info,omitempty"`
+	Settings        *OSFSettings      `xml:"settings" json:"settings,omitempty" yaml:"settings,omitempty"`
+	Styles          *OSFStyles        `xml:"styles" json:"styles,omitempty" yaml:"styles,omitempty"`
+	Paragraphs      *OSFParagraphs    `xml:"paragraphs" json:"paragraphs,omitempty" yaml:"paragraphs,omitempty"`
+	TitlePage       *OSFParagraphs    `xml:"titlepage" json:"title_page,omitempty" yaml:"title_page,omitempty"`
+	Lists           *OSFLists         `xml:"lists" json:"lists,omitempty" yaml:"lists,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type OSFInfo struct {
+	XMLName         xml.Name          `xml:"info" json:"-" yaml:"-"`
+	UUID            string            `xml:"uuid,attr,omitempty" json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	PageCount       string            `xml:"pagecount,attr,omitempty" json:"page_count,omitempty" yaml:"page_count,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+// OSFSettings holds the page layout, measurements are in tenths of a
+// millimeter.
+type OSFSettings struct {
+	XMLName           xml.Name          `xml:"settings" json:"-" yaml:"-"`
+	PageWidth         string            `xml:"page_width,attr,omitempty" json:"page_width,omitempty" yaml:"page_width,omitempty"`
+	PageHeight        string            `xml:"page_height,attr,omitempty" json:"page_height,omitempty" yaml:"page_height,omitempty"`
+	MarginTop         string            `xml:"margin_top,attr,omitempty" json:"margin_top,omitempty" yaml:"margin_top,omitempty"`
+	MarginBottom      string            `xml:"margin_bottom,attr,omitempty" json:"margin_bottom,omitempty" yaml:"margin_bottom,omitempty"`
+	MarginLeft        string            `xml:"margin_left,attr,omitempty" json:"margin_left,omitempty" yaml:"margin_left,omitempty"`
+	MarginRight       string            `xml:"margin_right,attr,omitempty" json:"margin_right,omitempty" yaml:"margin_right,omitempty"`
+	BreakOnSentences  string            `xml:"break_on_sentences,attr,omitempty" json:"break_on_sentences,omitempty" yaml:"break_on_sentences,omitempty"`
+	DialogueContinues string            `xml:"dialogue_continues,attr,omitempty" json:"dialogue_continues,omitempty" yaml:"dialogue_continues,omitempty"`
+	DialoguePageBreak string            `xml:"dialogue_pagebreaks,attr,omitempty" json:"dialogue_pagebreaks,omitempty" yaml:"dialogue_pagebreaks,omitempty"`
+	ContText          string            `xml:"cont_text,attr,omitempty" json:"cont_text,omitempty" yaml:"cont_text,omitempty"`
+	MoreText          string            `xml:"more_text,attr,omitempty" json:"more_text,omitempty" yaml:"more_text,omitempty"`
+	ScenesContinue    string            `xml:"scenes_continue,attr,omitempty" json:"scenes_continue,omitempty" yaml:"scenes_continue,omitempty"`
+	ContinuedText     string            `xml:"continued_text,attr,omitempty" json:"continued_text,omitempty" yaml:"continued_text,omitempty"`
+	NumberContinued   string            `xml:"number_continued,attr,omitempty" json:"number_continued,omitempty" yaml:"number_continued,omitempty"`
+	SceneTimeSep      string            `xml:"scene_time_separator,attr,omitempty" json:"scene_time_separator,omitempty" yaml:"scene_time_separator,omitempty"`
+	PageHeader        string            `xml:"page_header,attr" json:"page_header,omitempty" yaml:"page_header,omitempty"`
+	PageFooter        string            `xml:"page_footer,attr" json:"page_footer,omitempty" yaml:"page_footer,omitempty"`
+	HeaderAlignment   string            `xml:"header_alignment,attr,omitempty" json:"header_alignment,omitempty" yaml:"header_alignment,omitempty"`
+	FooterAlignment   string            `xml:"footer_alignment,attr,omitempty" json:"footer_alignment,omitempty" yaml:"footer_alignment,omitempty"`
+	HeaderFirstPage   string            `xml:"header_first_page,attr,omitempty" json:"header_first_page,omitempty" yaml:"header_first_page,omitempty"`
+	FooterFirstPage   string            `xml:"footer_first_page,attr,omitempty" json:"footer_first_page,omitempty" yaml:"footer_first_page,omitempty"`
+	PageNumberStart   string            `xml:"pagenumber_start,attr,omitempty" json:"pagenumber_start,omitempty" yaml:"pagenumber_start,omitempty"`
+	SceneNumbering    string            `xml:"scene_numbering,attr,omitempty" json:"scene_numbering,omitempty" yaml:"scene_numbering,omitempty"`
+	ScenesLocked      string            `xml:"scenes_locked,attr,omitempty" json:"scenes_locked,omitempty" yaml:"scenes_locked,omitempty"`
+	UnknownAttrs      []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements   []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type OSFStyles struct {
+	XMLName         xml.Name          `xml:"styles" json:"-" yaml:"-"`
+	Style           []*OSFStyle       `xml:"style" json:"styles,omitempty" yaml:"styles,omitempty"`
+	HeaderStyle     *OSFStyle         `xml:"header_style" json:"header_style,omitempty" yaml:"header_style,omitempty"`
+	FooterStyle     *OSFStyle         `xml:"footer_style" json:"footer_style,omitempty" yaml:"footer_style,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+// OSFStyle is a paragraph style, indents are in tenths of a millimeter
+// from the page margins and spacebefore is in lines. A paragraph's
+// style refers to a named style by basestylename.
+type OSFStyle struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Name            string            `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	BaseStyleName   string            `xml:"basestylename,attr,omitempty" json:"base_style_name,omitempty" yaml:"base_style_name,omitempty"`
+	Label           string            `xml:"label,attr,omitempty" json:"label,omitempty" yaml:"label,omitempty"`
+	Font            string            `xml:"font,attr,omitempty" json:"font,omitempty" yaml:"font,omitempty"`
+	Size            string            `xml:"size,attr,omitempty" json:"size,omitempty" yaml:"size,omitempty"`
+	SpaceBefore     string            `xml:"spacebefore,attr,omitempty" json:"space_before,omitempty" yaml:"space_before,omitempty"`
+	KeepWithNext    string            `xml:"keepwithnext,attr,omitempty" json:"keep_with_next,omitempty" yaml:"keep_with_next,omitempty"`
+	PageBreakBefore string            `xml:"pagebreakbefore,attr,omitempty" json:"page_break_before,omitempty" yaml:"page_break_before,omitempty"`
+	Align           string            `xml:"align,attr,omitempty" json:"align,omitempty" yaml:"align,omitempty"`
+	LeftIndent      string            `xml:"leftindent,attr,omitempty" json:"left_indent,omitempty" yaml:"left_indent,omitempty"`
+	RightIndent     string            `xml:"rightindent,attr,omitempty" json:"right_indent,omitempty" yaml:"right_indent,omitempty"`
+	AllCaps         string            `xml:"allcaps,attr,omitempty" json:"all_caps,omitempty" yaml:"all_caps,omitempty"`
+	Bold            string            `xml:"bold,attr,omitempty" json:"bold,omitempty" yaml:"bold,omitempty"`
+	Italic          string            `xml:"italic,attr,omitempty" json:"italic,omitempty" yaml:"italic,omitempty"`
+	Underline       string            `xml:"underline,attr,omitempty" json:"underline,omitempty" yaml:"underline,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type OSFParagraphs struct {
+	XMLName         xml.Name          `json:"-" yaml:"-"`
+	Para            []*OSFPara        `xml:"para" json:"paras,omitempty" yaml:"paras,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type OSFPara struct {
+	XMLName         xml.Name          `xml:"para" json:"-" yaml:"-"`
+	Bookmark        string            `xml:"bookmark,attr,omitempty" json:"bookmark,omitempty" yaml:"bookmark,omitempty"`
+	Number          string            `xml:"number,attr,omitempty" json:"number,omitempty" yaml:"number,omitempty"`
+	Style           *OSFStyle         `xml:"style" json:"style,omitempty" yaml:"style,omitempty"`
+	Text            []*OSFText        `xml:"text" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type OSFText struct {
+	XMLName         xml.Name          `xml:"text" json:"-" yaml:"-"`
+	Bold            string            `xml:"bold,attr,omitempty" json:"bold,omitempty" yaml:"bold,omitempty"`
+	Italic          string            `xml:"italic,attr,omitempty" json:"italic,omitempty" yaml:"italic,omitempty"`
+	Underline       string            `xml:"underline,attr,omitempty" json:"underline,omitempty" yaml:"underline,omitempty"`
+	Strikeout       string            `xml:"strikeout,attr,omitempty" json:"strikeout,omitempty" yaml:"strikeout,omitempty"`
+	AllCaps         string            `xml:"allcaps,attr,omitempty" json:"all_caps,omitempty" yaml:"all_caps,omitempty"`
+	Font            string            `xml:"font,attr,omitempty" json:"font,omitempty" yaml:"font,omitempty"`
+	Size            string            `xml:"size,attr,omitempty" json:"size,omitempty" yaml:"size,omitempty"`
+	InnerText       string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+// OSFLists holds the SmartType style lists (characters, locations,
+// etc.), each entry is an element with a name attribute.
+type OSFLists struct {
+	XMLName         xml.Name          `xml:"lists" json:"-" yaml:"-"`
+	Characters      *OSFList          `xml:"characters" json:"characters,omitempty" yaml:"characters,omitempty"`
+	Locations       *OSFList          `xml:"locations" json:"locations,omitempty" yaml:"locations,omitempty"`
+	SceneIntros     *OSFList          `xml:"scene_intros" json:"scene_intros,omitempty" yaml:"scene_intros,omitempty"`
+	SceneTimes      *OSFList          `xml:"scene_times" json:"scene_times,omitempty" yaml:"scene_times,omitempty"`
+	Extensions      *OSFList          `xml:"extensions" json:"extensions,omitempty" yaml:"extensions,omitempty"`
+	Transitions     *OSFList          `xml:"transitions" json:"transitions,omitempty" yaml:"transitions,omitempty"`
+	UnknownAttrs    []xml.Attr        `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+	UnknownElements []*UnknownElement `xml:",any" json:"unknown_elements,omitempty" yaml:"unknown_elements,omitempty"`
+}
+
+type OSFList struct {
+	XMLName      xml.Name       `json:"-" yaml:"-"`
+	Item         []*OSFListItem `xml:",any" json:"items,omitempty" yaml:"items,omitempty"`
+	UnknownAttrs []xml.Attr     `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+}
+
+type OSFListItem struct {
+	XMLName      xml.Name   `json:"-" yaml:"-"`
+	Name         string     `xml:"name,attr,omitempty" json:"name,omitempty" yaml:"name,omitempty"`
+	UnknownAttrs []xml.Attr `xml:",any,attr" json:"unknown_attrs,omitempty" yaml:"unknown_attrs,omitempty"`
+}
@@ -0,0 +1,647 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PointsPerLine is the height of a line of Courier 12pt in points
+	PointsPerLine = 12
+
+	// PointsPerInch converts Final Draft's margins (points) to inches
+	PointsPerInch = 72
+
+	// Default page margins in points, used when PageLayout doesn't
+	// provide them
+	DefaultTopMargin    = 72
+	DefaultBottomMargin = 72
+	DefaultHeaderMargin = 36
+	DefaultFooterMargin = 36
+
+	// MinSplitLines is the fewest lines of a paragraph left at the
+	// bottom of a page or carried to the top of the next when the
+	// paragraph is split across pages.
+	MinSplitLines = 2
+)
+
+var (
+	// DefaultElementSettings holds Final Draft's default ParagraphSpec,
+	// Behavior and FontSpec style by paragraph type, they are used
+	// for types missing from a document's ElementSettings.
+	DefaultElementSettings = map[string]*ElementSettings{
+		GeneralType:       defaultElementSettings(GeneralType, "Left", "1.50", "7.50", "0.00", "0", "", GeneralType),
+		SceneHeadingType:  defaultElementSettings(SceneHeadingType, "Left", "1.50", "7.50", "0.00", "24", AllCapsStyle, SceneHeadingType),
+		ActionType:        defaultElementSettings(ActionType, "Left", "1.50", "7.50", "0.00", "12", "", ActionType),
+		CharacterType:     defaultElementSettings(CharacterType, "Left", "3.50", "7.25", "0.00", "12", AllCapsStyle, CharacterType),
+		ParentheticalType: defaultElementSettings(ParentheticalType, "Left", "3.00", "5.50", "-0.10", "0", "", ParentheticalType),
+		DialogueType:      defaultElementSettings(DialogueType, "Left", "2.50", "6.00", "0.00", "0", "", DialogueType),
+		TransitionType:    defaultElementSettings(TransitionType, "Right", "5.50", "7.10", "0.00", "12", AllCapsStyle, TransitionType),
+		ShotType:          defaultElementSettings(ShotType, "Left", "1.50", "7.50", "0.00", "12", AllCapsStyle, SceneHeadingType),
+		CastListType:      defaultElementSettings(CastListType, "Left", "1.50", "7.50", "0.00", "0", "", ActionType),
+		SingingType:       defaultElementSettings(SingingType, "Left", "2.50", "6.00", "0.00", "0", ItalicStyle, DialogueType),
+	}
+)
+
+// defaultElementSettings builds an ElementSettings for DefaultElementSettings
+func defaultElementSettings(elementType, alignment, leftIndent, rightIndent, firstIndent, spaceBefore, style, paginateAs string) *ElementSettings {
+	settings := new(ElementSettings)
+	settings.Type = elementType
+	settings.FontSpec = new(FontSpec)
+	settings.FontSpec.Style = style
+	settings.ParagraphSpec = new(ParagraphSpec)
+	settings.ParagraphSpec.Alignment = alignment
+	settings.ParagraphSpec.FirstIndent = firstIndent
+	settings.ParagraphSpec.LeftIndent = leftIndent
+	settings.ParagraphSpec.RightIndent = rightIndent
+	settings.ParagraphSpec.SpaceBefore = spaceBefore
+	settings.ParagraphSpec.Spacing = "1"
+	settings.ParagraphSpec.StartsNewPage = "No"
+	settings.Behavior = new(Behavior)
+	settings.Behavior.PaginateAs = paginateAs
+	return settings
+}
+
+// Line is a line of text laid out on a page
+type Line struct {
+	// Paragraph is the index of the paragraph in Content
+	Paragraph int `json:"paragraph" yaml:"paragraph"`
+	// Type is the paragraph type
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Y is the position in lines below the page's top margin
+	Y float64 `json:"y" yaml:"y"`
+	// X is the position in inches from the page's left edge
+	X float64 `json:"x" yaml:"x"`
+	// Text is the plain text of the line
+	Text string `json:"text" yaml:"text"`
+	// Runs is the styled text of the line
+	Runs []*Text `json:"runs,omitempty" yaml:"runs,omitempty"`
+}
+
+// Page is a page of laid out script
+type Page struct {
+	// Number is the page number
+	Number int `json:"number" yaml:"number"`
+	// Lines holds the lines on the page top to bottom
+	Lines []*Line `json:"lines" yaml:"lines"`
+}
+
+// Paragraphs returns the indexes of the paragraphs (fully or
+// partially) on the page.
+func (page *Page) Paragraphs() []int {
+	indexes := []int{}
+	for _, line := range page.Lines {
+		if len(indexes) == 0 || indexes[len(indexes)-1] != line.Paragraph {
+			indexes = append(indexes, line.Paragraph)
+		}
+	}
+	return indexes
+}
+
+// ElementSetting returns the element settings of a paragraph type,
+// falling back to Final Draft's defaults (then General) when the
+// document doesn't define the type.
+func (document *FinalDraft) ElementSetting(elementType string) *ElementSettings {
+	if document != nil {
+		for _, settings := range document.ElementSettings {
+			if settings.Type == elementType && settings.ParagraphSpec != nil {
+				return settings
+			}
+		}
+	}
+	if settings, ok := DefaultElementSettings[elementType]; ok {
+		return settings
+	}
+	return DefaultElementSettings[GeneralType]
+}
+
+// margins returns the top, bottom, header and footer margins in points
+func (document *FinalDraft) margins() (float64, float64, float64, float64) {
+	top, bottom, header, footer := float64(DefaultTopMargin), float64(DefaultBottomMargin), float64(DefaultHeaderMargin), float64(DefaultFooterMargin)
+	if layout := document.PageLayout; layout != nil {
+		top = measure(layout.TopMargin, top)
+		bottom = measure(layout.BottomMargin, bottom)
+		header = measure(layout.HeaderMargin, header)
+		footer = measure(layout.FooterMargin, footer)
+	}
+	return top, bottom, header, footer
+}
+
+// LinesPerPage returns the number of lines fitting between the top
+// and bottom margins of a page.
+func (document *FinalDraft) LinesPerPage() int {
+	_, height := document.PageSize()
+	top, bottom, _, _ := document.margins()
+	return int(math.Floor((height*PointsPerInch - top - bottom) / PointsPerLine))
+}
+
+// startingPage returns the number of the first script page
+func (document *FinalDraft) startingPage() int {
+	if document.HeaderAndFooter != nil {
+		if i, err := strconv.Atoi(document.HeaderAndFooter.StartingPage); err == nil {
+			return i
+		}
+	}
+	return 1
+}
+
+// paragraphLayout holds a paragraph's measurements and wrapped lines
+type paragraphLayout struct {
+	index         int
+	paginateAs    string
+	spaceBefore   float64
+	spacing       float64
+	startsNewPage bool
+	lines         []*Line
+	// rows is the number of rows the lines occupy, dual dialogue
+	// columns share rows
+	rows int
+}
+
+// height returns the height in lines of n rows
+func (lay *paragraphLayout) height(n int) float64 {
+	return float64(n) * lay.spacing
+}
+
+// keepWithNext reports if the paragraph must be on the same page as
+// the start of the next paragraph.
+func (lay *paragraphLayout) keepWithNext() bool {
+	switch lay.paginateAs {
+	case SceneHeadingType, CharacterType, ParentheticalType:
+		return true
+	}
+	return false
+}
+
+// splittable reports if the paragraph can be split across pages
+func (lay *paragraphLayout) splittable() bool {
+	switch lay.paginateAs {
+	case ActionType, GeneralType, DialogueType:
+		return lay.rows >= MinSplitLines*2
+	}
+	return false
+}
+
+// wrapRanges word wraps runes into lines returning the start and end
+// offset of each line. The first line is firstWidth wide, the rest width.
+func wrapRanges(runes []rune, firstWidth int, width int) [][2]int {
+	ranges := [][2]int{}
+	if firstWidth < 1 {
+		firstWidth = 1
+	}
+	if width < 1 {
+		width = 1
+	}
+	start := 0
+	for start <= len(runes) {
+		w := width
+		if len(ranges) == 0 {
+			w = firstWidth
+		}
+		end := start
+		for end < len(runes) && runes[end] != '\n' {
+			end++
+		}
+		if end-start > w {
+			// Break at the last space that fits
+			end = start + w
+			for i := end; i > start; i-- {
+				if runes[i] == ' ' {
+					end = i
+					break
+				}
+			}
+		}
+		lineEnd := end
+		for lineEnd > start && runes[lineEnd-1] == ' ' {
+			lineEnd--
+		}
+		ranges = append(ranges, [2]int{start, lineEnd})
+		if end >= len(runes) {
+			break
+		}
+		if runes[end] == '\n' {
+			end++
+		} else {
+			for end < len(runes) && runes[end] == ' ' {
+				end++
+			}
+		}
+		start = end
+		if start == len(runes) && runes[start-1] != '\n' {
+			break
+		}
+	}
+	return ranges
+}
+
+// sliceRuns returns the Text runs covering the rune offsets start to end
+func sliceRuns(runs []*Text, start int, end int) []*Text {
+	out := []*Text{}
+	base := 0
+	for _, text := range runs {
+		runes := []rune(text.InnerText)
+		from, to := base, base+len(runes)
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+		if from < to {
+			run := new(Text)
+			*run = *text
+			run.InnerText = string(runes[from-base : to-base])
+			out = append(out, run)
+		}
+		base += len(runes)
+	}
+	return out
+}
+
+// layoutText wraps a paragraph's text between left and right (inches)
+// returning lines positioned horizontally by alignment.
+func layoutText(paragraph *Paragraph, index int, elementType string, allCaps bool, alignment string, left, right, firstIndent float64) []*Line {
+	runs := []*Text{}
+	for _, text := range paragraph.Text {
+		run := new(Text)
+		*run = *text
+		if allCaps {
+			run.InnerText = strings.ToUpper(run.InnerText)
+		}
+		runs = append(runs, run)
+	}
+	plain := []rune{}
+	for _, run := range runs {
+		plain = append(plain, []rune(run.InnerText)...)
+	}
+	width := columns(right - left)
+	firstWidth := columns(right - left - firstIndent)
+	lines := []*Line{}
+	for i, r := range wrapRanges(plain, firstWidth, width) {
+		line := new(Line)
+		line.Paragraph = index
+		line.Type = elementType
+		line.Text = string(plain[r[0]:r[1]])
+		line.Runs = sliceRuns(runs, r[0], r[1])
+		l := left
+		if i == 0 {
+			l += firstIndent
+		}
+		textWidth := float64(len([]rune(line.Text))) / float64(CharactersPerInch)
+		switch alignment {
+		case CenterAlignment:
+			line.X = l + ((right-l)-textWidth)/2
+		case RightAlignment:
+			line.X = right - textWidth
+		default:
+			line.X = l
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// layoutParagraph measures and wraps a paragraph using its element
+// settings, paragraph attributes override the settings.
+func (document *FinalDraft) layoutParagraph(paragraph *Paragraph, index int) *paragraphLayout {
+	settings := document.ElementSetting(paragraph.Type)
+	spec := settings.ParagraphSpec
+	lay := new(paragraphLayout)
+	lay.index = index
+	lay.paginateAs = paragraph.Type
+	if settings.Behavior != nil && settings.Behavior.PaginateAs != "" {
+		lay.paginateAs = settings.Behavior.PaginateAs
+	}
+	pick := func(a, b string) string {
+		if a != "" {
+			return a
+		}
+		return b
+	}
+	left := measure(pick(paragraph.LeftIndent, spec.LeftIndent), 1.5)
+	right := measure(pick(paragraph.RightIndent, spec.RightIndent), 7.5)
+	firstIndent := measure(pick(paragraph.FirstIndent, spec.FirstIndent), 0)
+	alignment := pick(paragraph.Alignment, spec.Alignment)
+	lay.spaceBefore = measure(pick(paragraph.SpaceBefore, spec.SpaceBefore), 0) / PointsPerLine
+	lay.spacing = measure(pick(paragraph.Spacing, spec.Spacing), 1)
+	if lay.spacing <= 0 {
+		lay.spacing = 1
+	}
+	lay.startsNewPage = pick(paragraph.StartsNewPage, spec.StartsNewPage) == "Yes"
+	allCaps := settings.FontSpec != nil && strings.Contains(settings.FontSpec.Style, AllCapsStyle)
+
+	if paragraph.DualDialogue != nil {
+		// Dual dialogue is neither split nor kept with the next paragraph
+		lay.paginateAs = "Dual Dialogue"
+		lay.lines, lay.rows = document.layoutDualDialogue(paragraph.DualDialogue, index)
+		lay.spaceBefore = measure(document.ElementSetting(CharacterType).ParagraphSpec.SpaceBefore, 12) / PointsPerLine
+		lay.spacing = 1
+		return lay
+	}
+	lay.lines = layoutText(paragraph, index, paragraph.Type, allCaps, alignment, left, right, firstIndent)
+	for i, line := range lay.lines {
+		line.Y = float64(i)
+	}
+	lay.rows = len(lay.lines)
+	return lay
+}
+
+// dualDialogueColumns lays out the first two speeches of dual
+// dialogue as columns side by side, returning each speech's lines (Y
+// relative to the first row).
+func (document *FinalDraft) dualDialogueColumns(dd *DualDialogue, index int) [][]*Line {
+	action := document.ElementSetting(ActionType).ParagraphSpec
+	left := measure(action.LeftIndent, 1.5)
+	right := measure(action.RightIndent, 7.5)
+	gutter := float64(DualDialogueGutter) / float64(CharactersPerInch)
+	columnWidth := (right - left - gutter) / 2
+	columns := [][]*Line{}
+	for i, speech := range dd.Speeches() {
+		if i > 1 {
+			break
+		}
+		colLeft := left + float64(i)*(columnWidth+gutter)
+		colRight := colLeft + columnWidth
+		lines := []*Line{}
+		for _, paragraph := range speech {
+			settings := document.ElementSetting(paragraph.Type)
+			allCaps := settings.FontSpec != nil && strings.Contains(settings.FontSpec.Style, AllCapsStyle)
+			l, alignment := colLeft, LeftAlignment
+			switch paragraph.Type {
+			case CharacterType:
+				alignment = CenterAlignment
+			case ParentheticalType:
+				l += 0.3
+			}
+			for _, line := range layoutText(paragraph, index, paragraph.Type, allCaps, alignment, l, colRight, 0) {
+				line.Y = float64(len(lines))
+				lines = append(lines, line)
+			}
+		}
+		columns = append(columns, lines)
+	}
+	return columns
+}
+
+// layoutDualDialogue lays out the speeches of dual dialogue side by
+// side, returning the lines (Y relative to the first row) and the
+// number of rows.
+func (document *FinalDraft) layoutDualDialogue(dd *DualDialogue, index int) ([]*Line, int) {
+	lines, rows := []*Line{}, 0
+	for _, column := range document.dualDialogueColumns(dd, index) {
+		lines = append(lines, column...)
+		if len(column) > rows {
+			rows = len(column)
+		}
+	}
+	// Lines are ordered top to bottom, left to right
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Y < lines[j].Y
+	})
+	return lines, rows
+}
+
+// endsSentence reports if a line of text ends a sentence
+func endsSentence(s string) bool {
+	s = strings.TrimRight(strings.TrimSpace(s), `"')`)
+	return strings.HasSuffix(s, ".") || strings.HasSuffix(s, "?") ||
+		strings.HasSuffix(s, "!") || strings.HasSuffix(s, "--")
+}
+
+// paginator tracks the pages as paragraphs are placed
+type paginator struct {
+	pages        []*Page
+	page         *Page
+	y            float64
+	linesPerPage float64
+	number       int
+	// top is the position below any markers at the top of the page
+	top float64
+
+	// Dialogue and scene break settings
+	dialogue *DialogueBreaks
+	scene    *SceneBreaks
+
+	// Marker positions in inches
+	characterX float64
+	actionX    float64
+	rightX     float64
+
+	// Speech and scene being paginated
+	inSpeech    bool
+	speaker     string
+	lastSpeaker string
+	inScene     bool
+	scenePages  int
+}
+
+// newPage starts a new page
+func (p *paginator) newPage() {
+	p.page = new(Page)
+	p.page.Number = p.number
+	p.number++
+	p.pages = append(p.pages, p.page)
+	p.y = 0
+	p.top = 0
+}
+
+// place puts rows start to end of a paragraph layout on the current page
+func (p *paginator) place(lay *paragraphLayout, start int, end int) {
+	for _, line := range lay.lines {
+		if line.Y >= float64(start) && line.Y < float64(end) {
+			placed := new(Line)
+			*placed = *line
+			placed.Y = p.y + (line.Y-float64(start))*lay.spacing
+			p.page.Lines = append(p.page.Lines, placed)
+		}
+	}
+	p.y += lay.height(end - start)
+}
+
+// splitAt returns the number of rows of a splittable paragraph that
+// fit in avail lines, or zero if it can't be split there.
+func (lay *paragraphLayout) splitAt(avail float64, atSentences bool) int {
+	if !lay.splittable() {
+		return 0
+	}
+	k := int(math.Floor(avail / lay.spacing))
+	if k > lay.rows-MinSplitLines {
+		k = lay.rows - MinSplitLines
+	}
+	for ; k >= MinSplitLines; k-- {
+		if !atSentences || endsSentence(lay.lines[k-1].Text) {
+			return k
+		}
+	}
+	return 0
+}
+
+// Pages lays out the script's Content into pages using the PageLayout
+// (page size and margins) and the ElementSettings (indents, spacing,
+// alignment and PaginateAs behavior) of each paragraph type. Scene
+// headings, characters and parentheticals are kept with the paragraph
+// that follows, action and dialogue are split across pages leaving at
+// least MinSplitLines on each page (at the end of a sentence when the
+// PageLayout's BreakDialogueAndActionAtSentences is "Yes"). Page breaks
+// in a speech or scene get the (MORE), (CONT'D) and CONTINUED markers
+// requested by MoresAndContinueds.
+func (document *FinalDraft) Pages() []*Page {
+	p := new(paginator)
+	p.linesPerPage = float64(document.LinesPerPage())
+	p.number = document.startingPage()
+	if document.Content == nil || len(document.Content.Paragraph) == 0 {
+		return p.pages
+	}
+	p.dialogue, p.scene = document.breakSettings()
+	p.characterX = measure(document.ElementSetting(CharacterType).ParagraphSpec.LeftIndent, 3.5)
+	p.actionX = measure(document.ElementSetting(ActionType).ParagraphSpec.LeftIndent, 1.5)
+	p.rightX = measure(document.ElementSetting(TransitionType).ParagraphSpec.RightIndent, 7.1)
+	atSentences := document.PageLayout != nil && document.PageLayout.BreakDialogueAndActionAtSentences == "Yes"
+	layouts := []*paragraphLayout{}
+	for i, paragraph := range document.Content.Paragraph {
+		layouts = append(layouts, document.layoutParagraph(paragraph, i))
+	}
+	// keepHeight returns the lines needed to place layouts[i] with the
+	// start of the paragraphs it is kept with.
+	var keepHeight func(i int, first bool) float64
+	keepHeight = func(i int, first bool) float64 {
+		lay := layouts[i]
+		h := lay.spaceBefore
+		if first {
+			h += lay.height(lay.rows)
+		} else if lay.splittable() {
+			return h + lay.height(MinSplitLines)
+		} else {
+			h += lay.height(lay.rows)
+		}
+		if lay.keepWithNext() && i+1 < len(layouts) {
+			h += keepHeight(i+1, false)
+		}
+		return h
+	}
+	// inSpeech reports if layouts[i] continues the current speech
+	inSpeech := func(i int) bool {
+		if i >= len(layouts) || document.Content.Paragraph[i].DualDialogue != nil {
+			return false
+		}
+		return p.inSpeech && (layouts[i].paginateAs == DialogueType || layouts[i].paginateAs == ParentheticalType)
+	}
+	// endsScene reports if the scene ends with layouts[i]
+	endsScene := func(i int) bool {
+		return i+1 >= len(layouts) || document.Content.Paragraph[i+1].Type == SceneHeadingType
+	}
+
+	p.newPage()
+	for i, lay := range layouts {
+		if lay.rows == 0 {
+			continue
+		}
+		paragraph := document.Content.Paragraph[i]
+		startsScene := paragraph.Type == SceneHeadingType
+		if startsScene {
+			p.inScene, p.scenePages, p.lastSpeaker = true, 1, ""
+		}
+		continuing := inSpeech(i)
+		if !continuing {
+			p.inSpeech = false
+		}
+		switch {
+		case paragraph.DualDialogue != nil:
+			p.lastSpeaker = ""
+		case lay.paginateAs == CharacterType:
+			p.inSpeech = true
+			p.speaker = strings.TrimSpace(lay.lines[0].Text)
+			name := speakerName(p.speaker)
+			if p.dialogue.AutomaticCharacterContinueds == "Yes" && name == p.lastSpeaker {
+				addContinued(lay.lines, p.dialogue.DialogueTop)
+			}
+			p.lastSpeaker = name
+		}
+
+		if lay.startsNewPage && p.y > p.top {
+			p.newPage()
+		}
+		if p.y > p.top {
+			p.y += lay.spaceBefore
+		}
+		// A paragraph filling the page ending a speech or scene
+		// needs no room for markers.
+		limit := p.linesPerPage - p.reserve(continuing && inSpeech(i+1), p.inScene && !endsScene(i))
+		need := keepHeight(i, true) - lay.spaceBefore
+		if lay.keepWithNext() && p.y > p.top && p.y+need > limit {
+			p.breakPage(i, continuing, startsScene)
+		}
+		start := 0
+		for start < lay.rows {
+			rows := lay.rows - start
+			if p.y+lay.height(rows) <= limit {
+				p.place(lay, start, lay.rows)
+				break
+			}
+			avail := p.linesPerPage - p.reserve(continuing || lay.paginateAs == DialogueType, p.inScene) - p.y
+			rest := *lay
+			rest.rows = rows
+			rest.lines = []*Line{}
+			for _, line := range lay.lines {
+				if line.Y >= float64(start) {
+					shifted := new(Line)
+					*shifted = *line
+					shifted.Y -= float64(start)
+					rest.lines = append(rest.lines, shifted)
+				}
+			}
+			k := rest.splitAt(avail, atSentences)
+			switch {
+			case k > 0:
+				p.place(lay, start, start+k)
+				start += k
+			case p.y <= p.top:
+				// Too long for an empty page, break where it must
+				k = int(math.Max(1, math.Floor(avail/lay.spacing)))
+				k = min(k, rows)
+				p.place(lay, start, start+k)
+				start += k
+			}
+			if start == lay.rows {
+				// The forced break placed the last rows
+				break
+			}
+			// Dialogue split across the break continues the speech
+			split := start > 0 && lay.paginateAs == DialogueType
+			p.breakPage(i, continuing || split, startsScene && start == 0)
+		}
+	}
+	return p.pages
+}
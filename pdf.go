@@ -0,0 +1,496 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	// Golang x packages
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/gomonobolditalic"
+	"golang.org/x/image/font/gofont/gomonoitalic"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// DefaultFontSize is the point size used when a Text element
+	// doesn't provide one
+	DefaultFontSize = 12
+
+	// WatermarkFontSize is the largest point size used for watermarks
+	WatermarkFontSize = 144
+)
+
+var (
+	// PDFFonts holds the TrueType fonts embedded in PDF output for
+	// regular, bold, italic and bold italic text. A font left nil
+	// uses Go Mono, the Go Mono fonts share Courier's metrics (each
+	// character is 0.6 em wide) so text lines up the way it does in
+	// Final Draft.
+	PDFFonts [4][]byte
+
+	// winAnsi maps the Windows-1252 characters outside of Latin-1
+	// to their codes, WinAnsiEncoding is used for PDF text.
+	winAnsi = map[rune]byte{
+		'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86,
+		'‡': 0x87, 'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c,
+		'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95,
+		'–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+		'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+	}
+)
+
+// pdfNumber formats f for a PDF content stream
+func pdfNumber(f float64) string {
+	s := strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// pdfString returns s as a PDF literal string in WinAnsiEncoding,
+// characters that can't be encoded are replaced by "?".
+func pdfString(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for _, r := range s {
+		var c byte
+		switch {
+		case r == '\t':
+			c = ' '
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			c = byte(r)
+		default:
+			if b, ok := winAnsi[r]; ok {
+				c = b
+			} else {
+				c = '?'
+			}
+		}
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 0x20 || c > 0x7e:
+			fmt.Fprintf(&buf, "\\%03o", c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
+
+// pdfColor converts a Final Draft color (e.g. "#FFFF00000000", 16 bits
+// per channel, or "#FF0000") to RGB values from 0 to 1. Black is
+// returned for missing or invalid colors.
+func pdfColor(s string) (float64, float64, float64) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 && len(s) != 12 {
+		return 0, 0, 0
+	}
+	n := len(s) / 3
+	max := math.Pow(16, float64(n)) - 1
+	rgb := [3]float64{}
+	for i := range rgb {
+		v, err := strconv.ParseUint(s[i*n:(i+1)*n], 16, 64)
+		if err != nil {
+			return 0, 0, 0
+		}
+		rgb[i] = float64(v) / max
+	}
+	return rgb[0], rgb[1], rgb[2]
+}
+
+// fontSize returns a Text element's size in points
+func (text *Text) fontSize() float64 {
+	if f, err := strconv.ParseFloat(strings.TrimSpace(text.Size), 64); err == nil && f > 0 {
+		return f
+	}
+	return DefaultFontSize
+}
+
+// fontIndex returns the index in PDFFonts of a Text element's style
+func (text *Text) fontIndex() int {
+	i := 0
+	if strings.Contains(text.Style, BoldStyle) {
+		i++
+	}
+	if strings.Contains(text.Style, ItalicStyle) {
+		i += 2
+	}
+	return i
+}
+
+// pdfObjects holds the numbered objects of a PDF file
+type pdfObjects struct {
+	objects [][]byte
+}
+
+// add appends an object returning its number
+func (po *pdfObjects) add(data string) int {
+	po.objects = append(po.objects, []byte(data))
+	return len(po.objects)
+}
+
+// set replaces the object numbered n
+func (po *pdfObjects) set(n int, data string) {
+	po.objects[n-1] = []byte(data)
+}
+
+// stream adds a compressed stream object, extra holds additional
+// dictionary entries.
+func (po *pdfObjects) stream(extra string, data []byte) int {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	obj := fmt.Sprintf("<< /Length %d /Filter /FlateDecode%s >>\nstream\n", buf.Len(), extra)
+	return po.add(obj + buf.String() + "\nendstream")
+}
+
+// write writes the objects as a PDF file with the root (catalog) and
+// info objects given.
+func (po *pdfObjects) write(w io.Writer, root int, info int) error {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(po.objects))
+	for i, obj := range po.objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		buf.Write(obj)
+		buf.WriteString("\nendobj\n")
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(po.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(po.objects)+1, root, info, xref)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfFont returns the font at index i in PDFFonts, Go Mono when it is
+// nil. The Go Mono fonts are only referenced here so programs that
+// don't write PDF leave them out.
+func pdfFont(i int) []byte {
+	if PDFFonts[i] != nil {
+		return PDFFonts[i]
+	}
+	return [4][]byte{gomono.TTF, gomonobold.TTF, gomonoitalic.TTF, gomonobolditalic.TTF}[i]
+}
+
+// embedFont adds a TrueType font from PDFFonts and its descriptor
+// returning the font's object number.
+func (po *pdfObjects) embedFont(data []byte) (int, error) {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return 0, err
+	}
+	b := new(sfnt.Buffer)
+	ppem := fixed.I(1000)
+	name, err := f.Name(b, sfnt.NameIDPostScript)
+	if err != nil {
+		return 0, err
+	}
+	bounds, err := f.Bounds(b, ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	metrics, err := f.Metrics(b, ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	gi, err := f.GlyphIndex(b, 'M')
+	if err != nil {
+		return 0, err
+	}
+	advance, err := f.GlyphAdvance(b, gi, ppem, font.HintingNone)
+	if err != nil {
+		return 0, err
+	}
+	italicAngle := 0
+	if strings.Contains(name, "Italic") {
+		italicAngle = -10
+	}
+	file := po.stream(fmt.Sprintf(" /Length1 %d", len(data)), data)
+	descriptor := po.add(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 33 /FontBBox [%d %d %d %d] /ItalicAngle %d /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, bounds.Min.X.Round(), -bounds.Max.Y.Round(), bounds.Max.X.Round(), -bounds.Min.Y.Round(),
+		italicAngle, metrics.Ascent.Round(), -metrics.Descent.Round(), metrics.CapHeight.Round(), file))
+	widths := strings.TrimSpace(strings.Repeat(fmt.Sprintf("%d ", advance.Round()), 224))
+	return po.add(fmt.Sprintf("<< /Type /Font /Subtype /TrueType /BaseFont /%s /FirstChar 32 /LastChar 255 /Widths [%s] /Encoding /WinAnsiEncoding /FontDescriptor %d 0 R >>",
+		name, widths, descriptor)), nil
+}
+
+// pdfRenderer draws the pages of a FinalDraft document
+type pdfRenderer struct {
+	document *FinalDraft
+	width    float64
+	height   float64
+	top      float64
+	header   float64
+	footer   float64
+	used     [4]bool
+}
+
+// text draws s at x, y (points from the bottom left of the page)
+// returning the width drawn.
+func (r *pdfRenderer) text(buf *bytes.Buffer, text *Text, s string, x float64, y float64) float64 {
+	if s == "" {
+		return 0
+	}
+	size := text.fontSize()
+	i := text.fontIndex()
+	r.used[i] = true
+	width := float64(len([]rune(s))) * size * 0.6
+	red, green, blue := pdfColor(text.Color)
+	fmt.Fprintf(buf, "%s %s %s rg\n", pdfNumber(red), pdfNumber(green), pdfNumber(blue))
+	fmt.Fprintf(buf, "BT /F%d %s Tf %s %s Td %s Tj ET\n", i+1, pdfNumber(size), pdfNumber(x), pdfNumber(y), pdfString(s))
+	lines := []float64{}
+	if strings.Contains(text.Style, UnderlineStyle) {
+		lines = append(lines, y-size*0.15)
+	}
+	if strings.Contains(text.Style, Strikethrough) {
+		lines = append(lines, y+size*0.25)
+	}
+	for _, ly := range lines {
+		fmt.Fprintf(buf, "%s %s %s RG 0.6 w %s %s m %s %s l S\n", pdfNumber(red), pdfNumber(green), pdfNumber(blue),
+			pdfNumber(x), pdfNumber(ly), pdfNumber(x+width), pdfNumber(ly))
+	}
+	return width
+}
+
+// line draws a laid out line whose top is y points below the top of
+// the page.
+func (r *pdfRenderer) line(buf *bytes.Buffer, line *Line, y float64) {
+	baseline := r.height - y - PointsPerLine*0.8
+	x := line.X * PointsPerInch
+	if len(line.Runs) == 0 {
+		r.text(buf, new(Text), line.Text, x, baseline)
+		return
+	}
+	for _, run := range line.Runs {
+		s := run.InnerText
+		if strings.Contains(run.Style, AllCapsStyle) {
+			s = strings.ToUpper(s)
+		}
+		x += r.text(buf, run, s, x, baseline)
+	}
+}
+
+// watermark draws the watermark text centered on the page
+func (r *pdfRenderer) watermark(buf *bytes.Buffer) {
+	watermark := r.document.Watermarking
+	if watermark == nil {
+		return
+	}
+	s := strings.TrimSpace(watermark.Text())
+	if s == "" {
+		return
+	}
+	rows := strings.Split(s, "\n")
+	longest := 1
+	for _, row := range rows {
+		if l := len([]rune(row)); l > longest {
+			longest = l
+		}
+	}
+	angle, avail := 0.0, r.width*0.8
+	switch watermark.Position {
+	case DiagonalAscendingPosition:
+		angle, avail = math.Atan2(r.height, r.width), math.Hypot(r.width, r.height)*0.7
+	case DiagonalDescendingPosition:
+		angle, avail = -math.Atan2(r.height, r.width), math.Hypot(r.width, r.height)*0.7
+	}
+	size := math.Min(WatermarkFontSize, avail/(float64(longest)*0.6))
+	text := new(Text)
+	text.Size = pdfNumber(size)
+	text.Style = BoldStyle
+	text.Color = "#BFBFBF"
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	fmt.Fprintf(buf, "q /GS1 gs %s %s %s %s %s %s cm\n", pdfNumber(cos), pdfNumber(sin), pdfNumber(-sin), pdfNumber(cos),
+		pdfNumber(r.width/2), pdfNumber(r.height/2))
+	for i, row := range rows {
+		x := -float64(len([]rune(row))) * size * 0.6 / 2
+		y := (float64(len(rows))/2-float64(i)-1)*size + size*0.2
+		r.text(buf, text, row, x, y)
+	}
+	buf.WriteString("Q\n")
+}
+
+// headerFooter draws the page's header and footer
+func (r *pdfRenderer) headerFooter(buf *bytes.Buffer, number int, lastRevised string) {
+	hf := r.document.HeaderAndFooter
+	if hf == nil {
+		return
+	}
+	// draw skips the spaces used to align the text
+	draw := func(line *Line, y float64) {
+		s := strings.TrimLeft(line.Text, " ")
+		x := line.X + float64(len(line.Text)-len(s))/float64(CharactersPerInch)
+		r.text(buf, new(Text), s, x*PointsPerInch, y)
+	}
+	if r.document.HeaderText(number, lastRevised) != "" {
+		for i, line := range r.document.headerFooterLines(hf.Header.Paragraph, number, lastRevised) {
+			draw(line, r.height-r.header-(float64(i)+0.8)*PointsPerLine)
+		}
+	}
+	if r.document.FooterText(number, lastRevised) != "" {
+		lines := r.document.headerFooterLines(hf.Footer.Paragraph, number, lastRevised)
+		for i, line := range lines {
+			draw(line, r.footer+float64(len(lines)-i-1)*PointsPerLine)
+		}
+	}
+}
+
+// sceneNumbers draws a scene heading's number in the margins
+func (r *pdfRenderer) sceneNumbers(buf *bytes.Buffer, number string, y float64) {
+	options := r.document.SceneNumberOptions
+	if options == nil || number == "" {
+		return
+	}
+	baseline := r.height - y - PointsPerLine*0.8
+	text := new(Text)
+	if options.ShowNumbersOnLeft == "Yes" {
+		r.text(buf, text, number, measure(options.LeftLocation, 0.75)*PointsPerInch, baseline)
+	}
+	if options.ShowNumbersOnRight == "Yes" {
+		r.text(buf, text, number, measure(options.RightLocation, 7.38)*PointsPerInch, baseline)
+	}
+}
+
+// titlePages lays out the title page's Content
+func (document *FinalDraft) titlePages() []*Page {
+	pages := []*Page{}
+	if document.TitlePage == nil || document.TitlePage.Content == nil || len(document.TitlePage.Content.Paragraph) == 0 {
+		return pages
+	}
+	linesPerPage := float64(document.LinesPerPage())
+	page, y := new(Page), 0.0
+	pages = append(pages, page)
+	for i, paragraph := range document.TitlePage.Content.Paragraph {
+		lay := document.layoutParagraph(paragraph, i)
+		if y > 0 && (lay.startsNewPage || y+lay.spaceBefore+lay.height(lay.rows) > linesPerPage) {
+			page, y = new(Page), 0.0
+			pages = append(pages, page)
+		} else {
+			y += lay.spaceBefore
+		}
+		for _, line := range lay.lines {
+			placed := new(Line)
+			*placed = *line
+			placed.Y = y + line.Y*lay.spacing
+			page.Lines = append(page.Lines, placed)
+		}
+		y += lay.height(lay.rows)
+	}
+	return pages
+}
+
+// ToPDF writes the screenplay as a PDF file. The title page is
+// followed by the script laid out by Pages() using the document's
+// page size, margins, element settings and text styles. Headers and
+// footers are drawn from HeaderAndFooter, lastRevised provides the
+// value of "Last Revised" labels. The watermark, if any, is drawn on
+// each page and the PDFFonts are embedded.
+func (document *FinalDraft) ToPDF(w io.Writer, lastRevised string) error {
+	r := new(pdfRenderer)
+	r.document = document
+	width, height := document.PageSize()
+	r.width, r.height = width*PointsPerInch, height*PointsPerInch
+	r.top, _, r.header, r.footer = document.margins()
+
+	po := new(pdfObjects)
+	catalog := po.add("")
+	pagesObj := po.add("")
+	resources := po.add("")
+	kids := []string{}
+	addPage := func(page *Page, script bool) {
+		var buf bytes.Buffer
+		r.watermark(&buf)
+		if script {
+			r.headerFooter(&buf, page.Number, lastRevised)
+		}
+		var prev *Line
+		for _, line := range page.Lines {
+			y := r.top + line.Y*PointsPerLine
+			r.line(&buf, line, y)
+			if script && line.Type == SceneHeadingType && (prev == nil || prev.Paragraph != line.Paragraph || prev.Type != line.Type) {
+				r.sceneNumbers(&buf, document.Content.Paragraph[line.Paragraph].Number, y)
+			}
+			prev = line
+		}
+		contents := po.stream("", buf.Bytes())
+		kids = append(kids, fmt.Sprintf("%d 0 R", po.add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Resources %d 0 R /Contents %d 0 R >>", pagesObj, resources, contents))))
+	}
+	for _, page := range document.titlePages() {
+		addPage(page, false)
+	}
+	for _, page := range document.Pages() {
+		addPage(page, true)
+	}
+	if len(kids) == 0 {
+		// A PDF needs at least one page
+		addPage(new(Page), false)
+	}
+
+	fonts := []string{}
+	for i, used := range r.used {
+		if used {
+			obj, err := po.embedFont(pdfFont(i))
+			if err != nil {
+				return err
+			}
+			fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i+1, obj))
+		}
+	}
+	opacity := float64(document.Watermarking.OpacityPercent()) / 100
+	po.set(resources, fmt.Sprintf("<< /Font << %s >> /ExtGState << /GS1 << /Type /ExtGState /ca %s >> >> >>", strings.Join(fonts, " "), pdfNumber(opacity)))
+	po.set(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>", strings.Join(kids, " "), len(kids), pdfNumber(r.width), pdfNumber(r.height)))
+	po.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	info := fmt.Sprintf("/Producer %s", pdfString("fdx "+Version))
+	if document.TitlePage != nil {
+		if title := document.TitlePage.Fields()["Title"]; title != "" {
+			info += fmt.Sprintf(" /Title %s", pdfString(strings.ReplaceAll(title, "\n", " ")))
+		}
+	}
+	return po.write(w, catalog, po.add("<< "+info+" >>"))
+}
@@ -0,0 +1,337 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// UnknownElement holds an element this package does not model. The
+// element's attributes, text and children are kept so that Parse followed
+// by ToXML doesn't discard content written by Final Draft (or other
+// tools) that we don't yet understand.
+type UnknownElement struct {
+	XMLName  xml.Name          `json:"name" yaml:"name"`
+	Attrs    []xml.Attr        `xml:",any,attr" json:"attrs,omitempty" yaml:"attrs,omitempty"`
+	CharData string            `xml:",chardata" json:"text,omitempty" yaml:"text,omitempty"`
+	Elements []*UnknownElement `xml:",any" json:"elements,omitempty" yaml:"elements,omitempty"`
+	// text holds the character data before each child and after the
+	// last so mixed content is written back in its original order
+	text []string
+}
+
+// UnmarshalXML decodes an unknown element dropping the whitespace used
+// to indent its children.
+func (elem *UnknownElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	elem.XMLName = start.Name
+	elem.Attrs = append(elem.Attrs, start.Attr...)
+	text := []string{""}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child := new(UnknownElement)
+			if err := child.UnmarshalXML(d, t); err != nil {
+				return err
+			}
+			elem.Elements = append(elem.Elements, child)
+			text = append(text, "")
+		case xml.CharData:
+			text[len(text)-1] += string(t)
+		case xml.EndElement:
+			elem.CharData = strings.Join(text, "")
+			if len(elem.Elements) > 0 && strings.TrimSpace(elem.CharData) == "" {
+				elem.CharData = ""
+			} else if len(elem.Elements) > 0 {
+				elem.text = text
+			}
+			return nil
+		}
+	}
+}
+
+// MarshalXML encodes an unknown element, mixed content is written in
+// the order it was read unless the text or children have changed.
+func (elem *UnknownElement) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = elem.XMLName
+	start.Attr = elem.Attrs
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	text := elem.text
+	if len(text) != len(elem.Elements)+1 || strings.Join(text, "") != elem.CharData {
+		text = make([]string, len(elem.Elements)+1)
+		text[0] = elem.CharData
+	}
+	for i, s := range text {
+		if s != "" {
+			if err := e.EncodeToken(xml.CharData(s)); err != nil {
+				return err
+			}
+		}
+		if i < len(elem.Elements) {
+			if err := e.Encode(elem.Elements[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// keepEmptyAttrs returns unknown with the attributes of start that
+// have an empty value (e.g. Alignment="") added, attributes decoded
+// into omitempty fields would otherwise be dropped by ToXML.
+func keepEmptyAttrs(start xml.StartElement, unknown []xml.Attr) []xml.Attr {
+	for _, attr := range start.Attr {
+		if attr.Value != "" {
+			continue
+		}
+		found := false
+		for _, a := range unknown {
+			found = found || a.Name == attr.Name
+		}
+		if !found {
+			unknown = append(unknown, attr)
+		}
+	}
+	return unknown
+}
+
+// tokenList replays a list of tokens as an xml.TokenReader
+type tokenList struct {
+	tokens []xml.Token
+}
+
+func (tl *tokenList) Token() (xml.Token, error) {
+	if len(tl.tokens) == 0 {
+		return nil, io.EOF
+	}
+	tok := tl.tokens[0]
+	tl.tokens = tl.tokens[1:]
+	return tok, nil
+}
+
+// decodeOrdered decodes the element described by start into v and
+// returns the names of the element's children in the order they were
+// read. v must not implement xml.Unmarshaler itself (use a type
+// alias without methods).
+func decodeOrdered(d *xml.Decoder, start xml.StartElement, v interface{}) ([]string, error) {
+	tokens := []xml.Token{start.Copy()}
+	order := []string{}
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 1 {
+				order = append(order, t.Name.Local)
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+		tokens = append(tokens, xml.CopyToken(tok))
+	}
+	return order, xml.NewTokenDecoder(&tokenList{tokens: tokens}).Decode(v)
+}
+
+// encodeOrdered encodes v as the element named by start writing its
+// children in the order given.
+// Children not named in order (e.g. added after the document was
+// parsed) follow in their usual order. v must not implement
+// xml.Marshaler itself (use a type alias without methods).
+func encodeOrdered(e *xml.Encoder, start xml.StartElement, v interface{}, order []string) error {
+	src, err := xml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var (
+		root     xml.StartElement
+		end      xml.EndElement
+		text     []xml.Token
+		names    []string
+		children [][]xml.Token
+	)
+	d := xml.NewDecoder(bytes.NewReader(src))
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		tok = xml.CopyToken(tok)
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				// An attribute set in a field and kept empty in
+				// UnknownAttrs (see keepEmptyAttrs) is written once.
+				root = xml.StartElement{Name: start.Name}
+				seen := map[xml.Name]bool{}
+				for _, attr := range t.Attr {
+					if !seen[attr.Name] {
+						seen[attr.Name] = true
+						root.Attr = append(root.Attr, attr)
+					}
+				}
+				continue
+			}
+			if depth == 2 {
+				names = append(names, t.Name.Local)
+				children = append(children, []xml.Token{})
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				end = xml.EndElement{Name: start.Name}
+				continue
+			}
+		case xml.CharData:
+			if depth == 1 {
+				// Character data belonging to the element itself
+				// (e.g. Text) is kept, in order, ahead of the
+				// children.
+				text = append(text, t)
+				continue
+			}
+		}
+		if len(children) > 0 {
+			i := len(children) - 1
+			children[i] = append(children[i], tok)
+		}
+	}
+	if err := e.EncodeToken(root); err != nil {
+		return err
+	}
+	for _, tok := range text {
+		if err := e.EncodeToken(tok); err != nil {
+			return err
+		}
+	}
+	used := make([]bool, len(children))
+	emit := func(i int) error {
+		used[i] = true
+		for _, tok := range children[i] {
+			if err := e.EncodeToken(tok); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range order {
+		for i := range children {
+			if !used[i] && names[i] == name {
+				if err := emit(i); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	for i := range children {
+		if !used[i] {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+	}
+	return e.EncodeToken(end)
+}
+
+// UnmarshalXML decodes a FinalDraft remembering the order of its children
+func (document *FinalDraft) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type rawFinalDraft FinalDraft
+	order, err := decodeOrdered(d, start, (*rawFinalDraft)(document))
+	document.order = order
+	document.UnknownAttrs = keepEmptyAttrs(start, document.UnknownAttrs)
+	return err
+}
+
+// MarshalXML encodes a FinalDraft keeping the order its children were read in
+func (document *FinalDraft) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type rawFinalDraft FinalDraft
+	return encodeOrdered(e, start, (*rawFinalDraft)(document), document.order)
+}
+
+// UnmarshalXML decodes a TitlePage remembering the order of its children
+func (tp *TitlePage) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type rawTitlePage TitlePage
+	order, err := decodeOrdered(d, start, (*rawTitlePage)(tp))
+	tp.order = order
+	tp.UnknownAttrs = keepEmptyAttrs(start, tp.UnknownAttrs)
+	return err
+}
+
+// MarshalXML encodes a TitlePage keeping the order its children were read in
+func (tp *TitlePage) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type rawTitlePage TitlePage
+	return encodeOrdered(e, start, (*rawTitlePage)(tp), tp.order)
+}
+
+// UnmarshalXML decodes a Paragraph remembering the order of its children
+func (paragraph *Paragraph) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type rawParagraph Paragraph
+	order, err := decodeOrdered(d, start, (*rawParagraph)(paragraph))
+	paragraph.order = order
+	paragraph.UnknownAttrs = keepEmptyAttrs(start, paragraph.UnknownAttrs)
+	return err
+}
+
+// MarshalXML encodes a Paragraph keeping the order its children were read in
+func (paragraph *Paragraph) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type rawParagraph Paragraph
+	return encodeOrdered(e, start, (*rawParagraph)(paragraph), paragraph.order)
+}
+
+// UnmarshalXML decodes a PageLayout remembering the order of its children
+func (layout *PageLayout) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type rawPageLayout PageLayout
+	order, err := decodeOrdered(d, start, (*rawPageLayout)(layout))
+	layout.order = order
+	layout.UnknownAttrs = keepEmptyAttrs(start, layout.UnknownAttrs)
+	return err
+}
+
+// MarshalXML encodes a PageLayout keeping the order its children were read in
+func (layout *PageLayout) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type rawPageLayout PageLayout
+	return encodeOrdered(e, start, (*rawPageLayout)(layout), layout.order)
+}
@@ -0,0 +1,209 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// SceneNumberWidth is the number of columns used for a scene
+	// number shown on the left in text output
+	SceneNumberWidth = 6
+)
+
+var (
+	// reFountainSceneNumber matches a Fountain scene number (e.g. "#12A#")
+	// at the end of a scene heading
+	reFountainSceneNumber = regexp.MustCompile(`\s*#([A-Za-z0-9.\-]+)#\s*$`)
+)
+
+// splitSceneNumber returns a scene heading without its Fountain
+// scene number and the number, if any.
+func splitSceneNumber(s string) (string, string) {
+	if m := reFountainSceneNumber.FindStringSubmatchIndex(s); m != nil {
+		return s[0:m[0]], s[m[2]:m[3]]
+	}
+	return s, ""
+}
+
+// SceneHeadings returns the Scene Heading paragraphs of the script
+func (document *FinalDraft) SceneHeadings() []*Paragraph {
+	headings := []*Paragraph{}
+	if document == nil || document.Content == nil {
+		return headings
+	}
+	for _, paragraph := range document.Content.Paragraph {
+		if paragraph.Type == SceneHeadingType {
+			headings = append(headings, paragraph)
+		}
+	}
+	return headings
+}
+
+// SceneNumbersLocked reports if the scene numbers are locked
+func (document *FinalDraft) SceneNumbersLocked() bool {
+	return document.SceneNumberOptions != nil && document.SceneNumberOptions.Locked == "Yes"
+}
+
+// LockSceneNumbers numbers any unnumbered scenes then locks the scene
+// numbers so scenes added later get A/B insertions (e.g. 12A, 12B)
+// instead of renumbering the script.
+func (document *FinalDraft) LockSceneNumbers() {
+	document.NumberScenes()
+	if document.SceneNumberOptions == nil {
+		document.SceneNumberOptions = new(SceneNumberOptions)
+	}
+	document.SceneNumberOptions.Locked = "Yes"
+}
+
+// UnlockSceneNumbers unlocks the scene numbers, the next call to
+// NumberScenes() renumbers the script.
+func (document *FinalDraft) UnlockSceneNumbers() {
+	if document.SceneNumberOptions != nil {
+		document.SceneNumberOptions.Locked = "No"
+	}
+}
+
+// nextLetter increments the trailing letters of s the way Final Draft
+// does (e.g. "12" to "12A", "12A" to "12B", "12Z" to "12AA", "12AZ" to
+// "12BA").
+func nextLetter(s string) string {
+	runes := []rune(s)
+	i := len(runes) - 1
+	for i >= 0 && runes[i] == 'Z' {
+		runes[i] = 'A'
+		i--
+	}
+	if i >= 0 && runes[i] >= 'A' && runes[i] < 'Z' {
+		runes[i]++
+		return string(runes)
+	}
+	return string(runes[:i+1]) + "A" + string(runes[i+1:])
+}
+
+// sceneNumberKey returns the key used to compare scene numbers, numbers
+// typed by hand (e.g. "3a" or " 3A") are the same scene number as "3A".
+func sceneNumberKey(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+// insertNumber returns an unused scene number for a scene added after
+// the scene numbered prev. The numbers before it may be out of order
+// (e.g. 5, 3) so the number is checked against every number used.
+func insertNumber(prev string, used map[string]bool) string {
+	number := nextLetter(sceneNumberKey(prev))
+	for used[sceneNumberKey(number)] {
+		number = nextLetter(number)
+	}
+	return number
+}
+
+// NumberScenes numbers the scene headings. An unlocked script is
+// numbered from 1. In a locked script existing numbers are kept and
+// added scenes are numbered after the scene before them (e.g. 12A,
+// 12B), scenes added before the first numbered scene are numbered A1,
+// B1, etc.
+func (document *FinalDraft) NumberScenes() {
+	headings := document.SceneHeadings()
+	if !document.SceneNumbersLocked() {
+		for i, paragraph := range headings {
+			paragraph.Number = fmt.Sprintf("%d", i+1)
+		}
+		return
+	}
+	used := map[string]bool{}
+	first := ""
+	for _, paragraph := range headings {
+		if paragraph.Number != "" {
+			used[sceneNumberKey(paragraph.Number)] = true
+			if first == "" {
+				first = paragraph.Number
+			}
+		}
+	}
+	if first == "" {
+		// Nothing is numbered yet
+		for i, paragraph := range headings {
+			paragraph.Number = fmt.Sprintf("%d", i+1)
+		}
+		return
+	}
+	prev, prefix := "", "A"
+	for _, paragraph := range headings {
+		switch {
+		case paragraph.Number != "":
+			prev = paragraph.Number
+		case prev == "":
+			number := prefix + first
+			for used[sceneNumberKey(number)] {
+				prefix = nextLetter(prefix)
+				number = prefix + first
+			}
+			paragraph.Number = number
+			prefix = nextLetter(prefix)
+		default:
+			paragraph.Number = insertNumber(prev, used)
+			prev = paragraph.Number
+		}
+		used[sceneNumberKey(paragraph.Number)] = true
+	}
+}
+
+// numberedHeading places a scene number on the left and/or right of
+// a rendered scene heading line.
+func numberedHeading(line string, number string, left bool, right bool) string {
+	if left {
+		line = padTo(number, SceneNumberWidth) + line
+	}
+	if right {
+		line = padTo(line, MaxLineWidth-len([]rune(number))) + number
+	}
+	return line
+}
+
+// numberedString (of Paragraph) returns plain text in Fountain format
+// with a scene heading's number shown on the left and/or right.
+func (p *Paragraph) numberedString(left bool, right bool) string {
+	s := p.String()
+	if (left || right) && p.Type == SceneHeadingType && p.Number != "" {
+		lines := strings.Split(s, "\n")
+		for i, line := range lines {
+			if line != "" && line != "===" {
+				lines[i] = numberedHeading(line, p.Number, left, right)
+				break
+			}
+		}
+		s = strings.Join(lines, "\n")
+	}
+	return s
+}
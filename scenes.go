@@ -0,0 +1,359 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultTimeOfDaySeparator separates a scene heading's location
+	// from the time of day when SmartType doesn't set one.
+	DefaultTimeOfDaySeparator = " - "
+)
+
+var (
+	// DefaultSceneIntros are the scene heading prefixes recognized
+	// along with those listed in SmartType.
+	DefaultSceneIntros = []string{"INT./EXT.", "EXT./INT.", "INT/EXT.", "I/E.", "INT.", "EXT.", "EST."}
+)
+
+// Scene describes a scene of the script, a scene heading and the
+// paragraphs up to the next scene heading.
+type Scene struct {
+	// Index of the scene heading in Content
+	Index int `json:"index" yaml:"index"`
+	// Paragraphs holds the scene heading and the paragraphs that follow it
+	Paragraphs []*Paragraph `json:"-" yaml:"-"`
+	// Heading is the text of the scene heading
+	Heading string `json:"heading" yaml:"heading"`
+	// Number is the scene number
+	Number string `json:"number,omitempty" yaml:"number,omitempty"`
+	// IntExt is the heading's scene intro (e.g. "INT.", "EXT.")
+	IntExt string `json:"int_ext,omitempty" yaml:"int_ext,omitempty"`
+	// Location is the heading's location (e.g. "STUDIO APARTMENT")
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+	// TimeOfDay is the heading's time of day (e.g. "NIGHT")
+	TimeOfDay string `json:"time_of_day,omitempty" yaml:"time_of_day,omitempty"`
+	// Page the scene starts on
+	Page string `json:"page,omitempty" yaml:"page,omitempty"`
+	// Length of the scene the way Final Draft writes it (e.g. "1 3/8")
+	Length string `json:"length,omitempty" yaml:"length,omitempty"`
+	// Eighths is the length of the scene in eighths of a page
+	Eighths int `json:"eighths" yaml:"eighths"`
+	// Characters holds the names of the characters speaking in the scene
+	Characters []string `json:"characters,omitempty" yaml:"characters,omitempty"`
+	// Words is the number of words in the scene
+	Words int `json:"words" yaml:"words"`
+}
+
+// parseEighths returns the eighths of a page in a scene length (e.g.
+// "1 3/8", "3/8" or "2"), zero if the length is missing or malformed.
+func parseEighths(s string) int {
+	eighths := 0
+	for _, field := range strings.Fields(s) {
+		if num, denom, ok := strings.Cut(field, "/"); ok {
+			n, err := strconv.Atoi(num)
+			if err != nil || denom != "8" {
+				return 0
+			}
+			eighths += n
+		} else {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return 0
+			}
+			eighths += n * 8
+		}
+	}
+	return eighths
+}
+
+// FormatEighths returns a length in eighths of a page the way Final
+// Draft writes it (e.g. "1 3/8").
+func FormatEighths(eighths int) string {
+	pages, rest := eighths/8, eighths%8
+	switch {
+	case rest == 0:
+		return strconv.Itoa(pages)
+	case pages == 0:
+		return fmt.Sprintf("%d/8", rest)
+	}
+	return fmt.Sprintf("%d %d/8", pages, rest)
+}
+
+// sceneIntros returns the scene heading prefixes, longest first
+func (document *FinalDraft) sceneIntros() []string {
+	intros := append([]string{}, DefaultSceneIntros...)
+	if document.SmartType != nil && document.SmartType.SceneIntros != nil {
+		for _, intro := range document.SmartType.SceneIntros.SceneIntro {
+			if s := strings.ToUpper(strings.TrimSpace(intro.InnerText)); s != "" {
+				intros = append(intros, s)
+			}
+		}
+	}
+	sort.SliceStable(intros, func(i, j int) bool {
+		return len(intros[i]) > len(intros[j])
+	})
+	return intros
+}
+
+// timeOfDaySeparator returns the separator between a scene heading's
+// location and time of day.
+func (document *FinalDraft) timeOfDaySeparator() string {
+	if document.SmartType != nil && document.SmartType.TimesOfDay != nil && document.SmartType.TimesOfDay.Separator != "" {
+		return document.SmartType.TimesOfDay.Separator
+	}
+	return DefaultTimeOfDaySeparator
+}
+
+// splitHeading splits a scene heading into its scene intro, location
+// and time of day.
+func (document *FinalDraft) splitHeading(heading string) (string, string, string) {
+	intExt, rest := "", heading
+	upper := strings.ToUpper(heading)
+	for _, intro := range document.sceneIntros() {
+		// The intro's trailing period is often left off
+		bare := strings.TrimSuffix(intro, ".") + " "
+		if strings.HasPrefix(upper, intro) {
+			intExt, rest = heading[0:len(intro)], heading[len(intro):]
+			break
+		}
+		if strings.HasPrefix(upper, bare) {
+			intExt, rest = heading[0:len(bare)-1], heading[len(bare):]
+			break
+		}
+	}
+	location, timeOfDay := rest, ""
+	sep := document.timeOfDaySeparator()
+	if i := strings.LastIndex(rest, sep); i >= 0 {
+		location, timeOfDay = rest[0:i], rest[i+len(sep):]
+		// A heading without a time of day (e.g. "INT. HOUSE - KITCHEN")
+		// is a location listed in SmartType.
+		name := strings.ToUpper(strings.TrimSpace(rest))
+		if !document.isTimeOfDay(timeOfDay) && document.smartTypeLocations()[name] {
+			location, timeOfDay = rest, ""
+		}
+	}
+	return strings.TrimSpace(intExt), strings.TrimSpace(location), strings.TrimSpace(timeOfDay)
+}
+
+// smartTypeLocations returns the locations listed in SmartType
+func (document *FinalDraft) smartTypeLocations() map[string]bool {
+	locations := map[string]bool{}
+	if document.SmartType != nil && document.SmartType.Locations != nil {
+		for _, location := range document.SmartType.Locations.Location {
+			if s := strings.ToUpper(strings.TrimSpace(location.InnerText)); s != "" {
+				locations[s] = true
+			}
+		}
+	}
+	return locations
+}
+
+// isTimeOfDay reports if s is listed in SmartType's times of day
+func (document *FinalDraft) isTimeOfDay(s string) bool {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if document.SmartType != nil && document.SmartType.TimesOfDay != nil {
+		for _, timeOfDay := range document.SmartType.TimesOfDay.TimeOfDay {
+			if strings.ToUpper(strings.TrimSpace(timeOfDay.InnerText)) == s {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// countWords returns the number of words, runs of text holding a
+// letter or digit, in s.
+func countWords(s string) int {
+	words := 0
+	for _, field := range strings.Fields(s) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			words++
+		}
+	}
+	return words
+}
+
+// paragraphWords returns the number of words in a paragraph,
+// including the speeches of dual dialogue.
+func paragraphWords(paragraph *Paragraph) int {
+	words := countWords(paragraph.PlainText())
+	if paragraph.DualDialogue != nil {
+		for _, p := range paragraph.DualDialogue.Paragraph {
+			words += paragraphWords(p)
+		}
+	}
+	return words
+}
+
+// speakers returns the names of the characters speaking in
+// paragraphs, including the speeches of dual dialogue, in the order
+// they first speak.
+func speakers(paragraphs []*Paragraph) []string {
+	names, seen := []string{}, map[string]bool{}
+	var add func([]*Paragraph)
+	add = func(paragraphs []*Paragraph) {
+		for _, paragraph := range paragraphs {
+			if paragraph.DualDialogue != nil {
+				add(paragraph.DualDialogue.Paragraph)
+			}
+			if paragraph.Type != CharacterType {
+				continue
+			}
+			if name := speakerName(paragraph.PlainText()); name != "" && !seen[name] {
+				names, seen[name] = append(names, name), true
+			}
+		}
+	}
+	add(paragraphs)
+	return names
+}
+
+// Scenes returns the scenes of the script. Page and length are taken
+// from the scene heading's SceneProperties, when missing they are
+// worked out by laying out the script with Pages.
+func (document *FinalDraft) Scenes() []*Scene {
+	return document.scenes(nil)
+}
+
+// scenes returns the scenes of the script using pages, the script laid
+// out with Pages, for page and length missing from SceneProperties.
+// When pages is nil the script is laid out if needed.
+func (document *FinalDraft) scenes(pages []*Page) []*Scene {
+	scenes := []*Scene{}
+	if document == nil || document.Content == nil {
+		return scenes
+	}
+	paragraphs := document.Content.Paragraph
+	for i, paragraph := range paragraphs {
+		if paragraph.Type == SceneHeadingType {
+			scene := new(Scene)
+			scene.Index = i
+			scenes = append(scenes, scene)
+		}
+		if n := len(scenes); n > 0 {
+			scenes[n-1].Paragraphs = append(scenes[n-1].Paragraphs, paragraph)
+		}
+	}
+	for _, scene := range scenes {
+		heading := scene.Paragraphs[0]
+		text, number := splitSceneNumber(strings.TrimSpace(heading.PlainText()))
+		scene.Heading = strings.TrimSpace(text)
+		scene.Number = heading.Number
+		if scene.Number == "" {
+			scene.Number = number
+		}
+		scene.IntExt, scene.Location, scene.TimeOfDay = document.splitHeading(scene.Heading)
+		scene.Characters = speakers(scene.Paragraphs)
+		scene.Words = countWords(scene.Heading)
+		for _, paragraph := range scene.Paragraphs[1:] {
+			scene.Words += paragraphWords(paragraph)
+		}
+		for _, props := range heading.SceneProperties {
+			if scene.Page == "" {
+				scene.Page = props.Page
+			}
+			if scene.Eighths == 0 {
+				scene.Eighths = parseEighths(props.Length)
+			}
+		}
+		if scene.Page != "" && scene.Eighths > 0 {
+			scene.Length = FormatEighths(scene.Eighths)
+			continue
+		}
+		if pages == nil {
+			pages = document.Pages()
+		}
+		// The scene's height is measured on each page from the top
+		// of its first line to the next scene's heading or the
+		// bottom of its last line.
+		page, height := "", 0.0
+		end := scene.Index + len(scene.Paragraphs)
+		for _, p := range pages {
+			top, bottom := -1.0, -1.0
+			for _, line := range p.Lines {
+				if line.Paragraph >= scene.Index && line.Paragraph < end {
+					if top < 0 {
+						top = line.Y
+					}
+					bottom = line.Y + 1
+				} else if line.Paragraph >= end && top >= 0 && bottom >= 0 {
+					// The scene runs to the next scene's heading
+					bottom = line.Y
+					break
+				}
+			}
+			if top >= 0 {
+				if page == "" {
+					page = strconv.Itoa(p.Number)
+				}
+				height += bottom - top
+			}
+		}
+		if scene.Page == "" {
+			scene.Page = page
+		}
+		if scene.Eighths == 0 && height > 0 {
+			// Round up to the next eighth of a page
+			scene.Eighths = int(math.Ceil(height * 8 / float64(document.LinesPerPage())))
+		}
+		scene.Length = FormatEighths(scene.Eighths)
+	}
+	return scenes
+}
+
+// WriteSceneReport writes the scenes as a table, CSV or JSON (see
+// TableFormat, CSVFormat and JSONFormat).
+func (document *FinalDraft) WriteSceneReport(w io.Writer, format string) error {
+	scenes := document.Scenes()
+	header := []string{"Scene", "Page", "Length", "Int/Ext", "Location", "Time", "Words", "Characters"}
+	rows := [][]string{}
+	for _, scene := range scenes {
+		rows = append(rows, []string{
+			scene.Number,
+			scene.Page,
+			scene.Length,
+			scene.IntExt,
+			scene.Location,
+			scene.TimeOfDay,
+			strconv.Itoa(scene.Words),
+			strings.Join(scene.Characters, ", "),
+		})
+	}
+	return writeReport(w, format, header, rows, scenes)
+}
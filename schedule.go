@@ -0,0 +1,489 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	// 3rd Party packages
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultPagesPerDay is the length of script shot in a day when
+	// ScheduleOptions doesn't set one.
+	DefaultPagesPerDay = "5"
+
+	// Day out of days codes
+	StartWorkCode       = "SW"
+	WorkCode            = "W"
+	WorkFinishCode      = "WF"
+	StartWorkFinishCode = "SWF"
+	HoldCode            = "H"
+
+	// StripboardCSS styles the stripboard and day out of days written
+	// as HTML, strips are colored the traditional way (INT/DAY white,
+	// EXT/DAY yellow, INT/NIGHT blue, EXT/NIGHT green) and each day
+	// ends with a black banner.
+	StripboardCSS = `body { font-family: Helvetica, Arial, sans-serif; font-size: 10pt; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #999; padding: 0.2em 0.5em; text-align: left; }
+th { background: #eee; }
+.int-day { background: #fff; }
+.ext-day { background: #ffff99; }
+.int-night { background: #99ccff; }
+.ext-night { background: #99ff99; }
+.day-break { background: #000; color: #fff; font-weight: bold; }
+`
+)
+
+// ScheduleOptions configure how Schedule groups the scenes into shoot
+// days, they are usually read from a YAML file like
+//
+//	pages_per_day: 4 4/8
+//	order: [12, 3, 4]
+//	locked: false
+//	day_breaks: [7]
+type ScheduleOptions struct {
+	// PagesPerDay is the length of script to shoot each day (e.g.
+	// "4 4/8"), DefaultPagesPerDay when empty
+	PagesPerDay string `json:"pages_per_day,omitempty" yaml:"pages_per_day,omitempty"`
+	// Order lists the scene numbers to shoot first in the order given
+	Order []string `json:"order,omitempty" yaml:"order,omitempty"`
+	// Locked keeps the scenes not listed in Order in script order
+	// instead of grouping them by location, day/night and cast
+	Locked bool `json:"locked,omitempty" yaml:"locked,omitempty"`
+	// DayBreaks lists the scene numbers that start a new shoot day
+	DayBreaks []string `json:"day_breaks,omitempty" yaml:"day_breaks,omitempty"`
+}
+
+// Strip describes a scene on the stripboard
+type Strip struct {
+	// Day is the shoot day (starting at one) the scene is shot on
+	Day int `json:"day" yaml:"day"`
+	// Scene scheduled, scenes without a number are numbered by
+	// their position in the script
+	Scene *Scene `json:"scene" yaml:"scene"`
+	// Location is the scene's location without the sub-location
+	// (e.g. "HOUSE" for "HOUSE - KITCHEN")
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+	// DayNight is DayTime or NightTime
+	DayNight string `json:"day_night,omitempty" yaml:"day_night,omitempty"`
+	// Cast holds the characters speaking or tagged as cast members
+	Cast []string `json:"cast,omitempty" yaml:"cast,omitempty"`
+}
+
+// ShootDay lists the scenes shot on a day
+type ShootDay struct {
+	// Day number starting at one
+	Day int `json:"day" yaml:"day"`
+	// Strips holds the scenes in shooting order
+	Strips []*Strip `json:"strips" yaml:"strips"`
+	// Length of the scenes (e.g. "4 3/8")
+	Length string `json:"length,omitempty" yaml:"length,omitempty"`
+	// Eighths is the length of the scenes in eighths of a page
+	Eighths int `json:"eighths" yaml:"eighths"`
+}
+
+// Schedule is a shooting schedule
+type Schedule struct {
+	// Cast lists the cast members, their cast ID is their position
+	// in the list starting at one. Cast members in the most scenes
+	// come first.
+	Cast []string `json:"cast,omitempty" yaml:"cast,omitempty"`
+	// Days holds the shoot days
+	Days []*ShootDay `json:"days" yaml:"days"`
+}
+
+// CastDays is a cast member's row of the day out of days
+type CastDays struct {
+	// ID is the cast ID
+	ID int `json:"id" yaml:"id"`
+	// Name of the cast member
+	Name string `json:"name" yaml:"name"`
+	// Days holds the code (e.g. "SW", "W", "H") for each shoot day, an
+	// empty string if the cast member isn't needed that day
+	Days []string `json:"days" yaml:"days"`
+	// Start and Finish are the first and last days worked
+	Start  int `json:"start" yaml:"start"`
+	Finish int `json:"finish" yaml:"finish"`
+	// Work and Hold count the days worked and held
+	Work int `json:"work" yaml:"work"`
+	Hold int `json:"hold" yaml:"hold"`
+	// Total is the days worked and held
+	Total int `json:"total" yaml:"total"`
+}
+
+// ParseScheduleOptions reads ScheduleOptions from YAML
+func ParseScheduleOptions(src []byte) (*ScheduleOptions, error) {
+	options := new(ScheduleOptions)
+	if err := yaml.Unmarshal(src, options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// ParseScheduleOptionsFile reads ScheduleOptions from a YAML file
+func ParseScheduleOptionsFile(fname string) (*ScheduleOptions, error) {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScheduleOptions(src)
+}
+
+// scheduleStrips returns a strip for each scene in script order
+func (document *FinalDraft) scheduleStrips() []*Strip {
+	strips := []*Strip{}
+	known := document.smartTypeLocations()
+	sheets := document.Breakdown()
+	scenes := make([]*Scene, len(sheets))
+	for i, sheet := range sheets {
+		scenes[i] = sheet.Scene
+	}
+	dayNights := document.sceneDayNights(scenes, known)
+	for i, sheet := range sheets {
+		strip := &Strip{Scene: sheet.Scene, Cast: sheet.Elements[CastCategory], DayNight: dayNights[i]}
+		if sheet.Scene.Location != "" {
+			strip.Location, _ = document.splitLocation(sheet.Scene.Location, known)
+		}
+		strips = append(strips, strip)
+	}
+	return strips
+}
+
+// groupStrips sorts strips by location, day before night and then
+// cast, keeping the order of the script within each group.
+func groupStrips(strips []*Strip) {
+	locations, casts := map[string]int{}, map[string]int{}
+	castKey := func(strip *Strip) string {
+		return strings.ToUpper(strings.Join(strip.Cast, ", "))
+	}
+	for _, strip := range strips {
+		location := strings.ToUpper(strip.Location)
+		if _, ok := locations[location]; !ok {
+			locations[location] = len(locations)
+		}
+		if _, ok := casts[castKey(strip)]; !ok {
+			casts[castKey(strip)] = len(casts)
+		}
+	}
+	dayNight := map[string]int{DayTime: 0, NightTime: 1, "": 2}
+	sort.SliceStable(strips, func(i, j int) bool {
+		a, b := strips[i], strips[j]
+		if la, lb := locations[strings.ToUpper(a.Location)], locations[strings.ToUpper(b.Location)]; la != lb {
+			return la < lb
+		}
+		if da, db := dayNight[a.DayNight], dayNight[b.DayNight]; da != db {
+			return da < db
+		}
+		return casts[castKey(a)] < casts[castKey(b)]
+	})
+}
+
+// Schedule groups the scenes into shoot days. The scenes listed in
+// options.Order come first, the others follow grouped by location,
+// day/night and cast (or in script order when options.Locked is
+// true). A new day starts when adding the next scene would go over
+// options.PagesPerDay or the scene is listed in options.DayBreaks. A
+// nil options uses the defaults.
+func (document *FinalDraft) Schedule(options *ScheduleOptions) (*Schedule, error) {
+	if options == nil {
+		options = new(ScheduleOptions)
+	}
+	pagesPerDay := options.PagesPerDay
+	if pagesPerDay == "" {
+		pagesPerDay = DefaultPagesPerDay
+	}
+	target := parseEighths(pagesPerDay)
+	if target <= 0 {
+		return nil, fmt.Errorf("pages per day %q is not a length like \"4 4/8\"", pagesPerDay)
+	}
+	strips := document.scheduleStrips()
+	byNumber := map[string]*Strip{}
+	for _, strip := range strips {
+		if _, ok := byNumber[strings.ToUpper(strip.Scene.Number)]; !ok {
+			byNumber[strings.ToUpper(strip.Scene.Number)] = strip
+		}
+	}
+	ordered, used := []*Strip{}, map[*Strip]bool{}
+	for _, number := range options.Order {
+		strip, ok := byNumber[strings.ToUpper(number)]
+		if !ok {
+			return nil, fmt.Errorf("scene %q in the shooting order is not in the script", number)
+		}
+		if !used[strip] {
+			ordered, used[strip] = append(ordered, strip), true
+		}
+	}
+	rest := []*Strip{}
+	for _, strip := range strips {
+		if !used[strip] {
+			rest = append(rest, strip)
+		}
+	}
+	if !options.Locked {
+		groupStrips(rest)
+	}
+	breaks := map[*Strip]bool{}
+	for _, number := range options.DayBreaks {
+		strip, ok := byNumber[strings.ToUpper(number)]
+		if !ok {
+			return nil, fmt.Errorf("day break scene %q is not in the script", number)
+		}
+		breaks[strip] = true
+	}
+
+	schedule := &Schedule{Days: []*ShootDay{}}
+	var day *ShootDay
+	for _, strip := range append(ordered, rest...) {
+		if day == nil || breaks[strip] || (len(day.Strips) > 0 && day.Eighths+strip.Scene.Eighths > target) {
+			day = &ShootDay{Day: len(schedule.Days) + 1}
+			schedule.Days = append(schedule.Days, day)
+		}
+		strip.Day = day.Day
+		day.Strips = append(day.Strips, strip)
+		day.Eighths += strip.Scene.Eighths
+		day.Length = FormatEighths(day.Eighths)
+	}
+
+	// Cast IDs go to the cast members in the most scenes first
+	count, first := map[string]int{}, map[string]int{}
+	for i, strip := range strips {
+		for _, name := range strip.Cast {
+			if _, ok := first[name]; !ok {
+				first[name] = i
+				schedule.Cast = append(schedule.Cast, name)
+			}
+			count[name]++
+		}
+	}
+	sort.SliceStable(schedule.Cast, func(i, j int) bool {
+		return count[schedule.Cast[i]] > count[schedule.Cast[j]]
+	})
+	return schedule, nil
+}
+
+// CastID returns the cast ID of a cast member, zero if they aren't
+// in the cast.
+func (schedule *Schedule) CastID(name string) int {
+	for i, s := range schedule.Cast {
+		if strings.EqualFold(s, name) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// castIDs returns the cast IDs of the cast members of a strip
+func (schedule *Schedule) castIDs(strip *Strip) string {
+	ids := []int{}
+	for _, name := range strip.Cast {
+		if id := schedule.CastID(name); id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	src := []string{}
+	for _, id := range ids {
+		src = append(src, strconv.Itoa(id))
+	}
+	return strings.Join(src, ", ")
+}
+
+// stripClass returns the stripboard color class of a strip (e.g. "ext-night")
+func stripClass(strip *Strip) string {
+	class := "int"
+	if intExt := strings.ToUpper(strip.Scene.IntExt); strings.Contains(intExt, "EXT") && !strings.Contains(intExt, "INT") {
+		class = "ext"
+	}
+	if strip.DayNight == NightTime {
+		return class + "-night"
+	}
+	return class + "-day"
+}
+
+// writeHTMLTable writes a table with a header row, rowClasses holds
+// the class of each row (it may be shorter than rows).
+func writeHTMLTable(out *bufio.Writer, header []string, rows [][]string, rowClasses []string) {
+	out.WriteString("<table>\n<tr>")
+	for _, s := range header {
+		fmt.Fprintf(out, "<th>%s</th>", html.EscapeString(s))
+	}
+	out.WriteString("</tr>\n")
+	for i, row := range rows {
+		if i < len(rowClasses) && rowClasses[i] != "" {
+			fmt.Fprintf(out, "<tr class=\"%s\">", rowClasses[i])
+		} else {
+			out.WriteString("<tr>")
+		}
+		for _, s := range row {
+			fmt.Fprintf(out, "<td>%s</td>", html.EscapeString(s))
+		}
+		out.WriteString("</tr>\n")
+	}
+	out.WriteString("</table>\n")
+}
+
+// writeHTMLPage writes an HTML document with the StripboardCSS
+// calling body to write its content.
+func writeHTMLPage(w io.Writer, title string, body func(*bufio.Writer)) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(out, "<style>\n%s</style>\n</head>\n<body>\n", StripboardCSS)
+	fmt.Fprintf(out, "<h1>%s</h1>\n", html.EscapeString(title))
+	body(out)
+	out.WriteString("</body>\n</html>\n")
+	return out.Flush()
+}
+
+// WriteStripboard writes the schedule as a stripboard, a table, CSV,
+// JSON or HTML (see TableFormat, CSVFormat, JSONFormat and
+// HTMLFormat). Each scene is a row (strip) listing its cast by cast
+// ID and each day ends with an "End of Day" row. HTML includes the
+// cast list.
+func (schedule *Schedule) WriteStripboard(w io.Writer, format string) error {
+	header := []string{"Day", "Scene", "Int/Ext", "Location", "Day/Night", "Page", "Length", "Cast"}
+	rows, classes := [][]string{}, []string{}
+	for _, day := range schedule.Days {
+		for _, strip := range day.Strips {
+			scene := strip.Scene
+			rows = append(rows, []string{
+				strconv.Itoa(day.Day),
+				scene.Number,
+				scene.IntExt,
+				scene.Location,
+				strip.DayNight,
+				scene.Page,
+				scene.Length,
+				schedule.castIDs(strip),
+			})
+			classes = append(classes, stripClass(strip))
+		}
+		rows = append(rows, []string{fmt.Sprintf("End of Day %d", day.Day), "", "", "", "", "", day.Length, ""})
+		classes = append(classes, "day-break")
+	}
+	if strings.ToLower(format) != HTMLFormat {
+		return writeReport(w, format, header, rows, schedule)
+	}
+	return writeHTMLPage(w, "Stripboard", func(out *bufio.Writer) {
+		writeHTMLTable(out, header, rows, classes)
+		cast := [][]string{}
+		for i, name := range schedule.Cast {
+			cast = append(cast, []string{strconv.Itoa(i + 1), name})
+		}
+		writeHTMLTable(out, []string{"ID", "Cast"}, cast, nil)
+	})
+}
+
+// DayOutOfDays returns the day out of days for each cast member in
+// cast ID order. Cast members start work (SW) on their first day,
+// finish (WF) on their last, are held (H) on the days between that
+// they aren't working and SWF marks a single day of work.
+func (schedule *Schedule) DayOutOfDays() []*CastDays {
+	list := []*CastDays{}
+	for i, name := range schedule.Cast {
+		castDays := &CastDays{ID: i + 1, Name: name, Days: make([]string, len(schedule.Days))}
+		worked := map[int]bool{}
+		for _, day := range schedule.Days {
+			for _, strip := range day.Strips {
+				for _, s := range strip.Cast {
+					if strings.EqualFold(s, name) {
+						worked[day.Day] = true
+					}
+				}
+			}
+			if worked[day.Day] {
+				if castDays.Start == 0 {
+					castDays.Start = day.Day
+				}
+				castDays.Finish = day.Day
+			}
+		}
+		for d := castDays.Start; d > 0 && d <= castDays.Finish; d++ {
+			code := HoldCode
+			switch {
+			case castDays.Start == castDays.Finish:
+				code = StartWorkFinishCode
+			case d == castDays.Start:
+				code = StartWorkCode
+			case d == castDays.Finish:
+				code = WorkFinishCode
+			case worked[d]:
+				code = WorkCode
+			}
+			if code == HoldCode {
+				castDays.Hold++
+			} else {
+				castDays.Work++
+			}
+			castDays.Days[d-1] = code
+		}
+		castDays.Total = castDays.Work + castDays.Hold
+		list = append(list, castDays)
+	}
+	return list
+}
+
+// WriteDayOutOfDays writes the day out of days as a table, CSV, JSON
+// or HTML (see TableFormat, CSVFormat, JSONFormat and HTMLFormat).
+func (schedule *Schedule) WriteDayOutOfDays(w io.Writer, format string) error {
+	list := schedule.DayOutOfDays()
+	header := []string{"ID", "Cast"}
+	for _, day := range schedule.Days {
+		header = append(header, fmt.Sprintf("Day %d", day.Day))
+	}
+	header = append(header, "Start", "Finish", "Work", "Hold", "Total")
+	rows := [][]string{}
+	for _, castDays := range list {
+		row := append([]string{strconv.Itoa(castDays.ID), castDays.Name}, castDays.Days...)
+		row = append(row,
+			strconv.Itoa(castDays.Start),
+			strconv.Itoa(castDays.Finish),
+			strconv.Itoa(castDays.Work),
+			strconv.Itoa(castDays.Hold),
+			strconv.Itoa(castDays.Total),
+		)
+		rows = append(rows, row)
+	}
+	if strings.ToLower(format) != HTMLFormat {
+		return writeReport(w, format, header, rows, list)
+	}
+	return writeHTMLPage(w, "Day Out of Days", func(out *bufio.Writer) {
+		writeHTMLTable(out, header, rows, nil)
+	})
+}
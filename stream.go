@@ -0,0 +1,470 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+const (
+	// decoder states
+	beforeDocument = iota
+	inDocument
+	inContent
+	afterDocument
+)
+
+// Decoder reads a Final Draft document from an io.Reader returning
+// the paragraphs of its Content one at a time. The elements outside of
+// Content (e.g. TitlePage, ElementSettings, PageLayout) are collected
+// into Document as they are read.
+type Decoder struct {
+	d        *xml.Decoder
+	recent   *recentReader
+	document *FinalDraft
+	root     xml.Name
+	path     []string
+	state    int
+	skip     bool
+	err      error
+}
+
+// NewDecoder returns a Decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	recent := &recentReader{r: r}
+	return &Decoder{
+		d:        xml.NewDecoder(recent),
+		recent:   recent,
+		document: new(FinalDraft),
+	}
+}
+
+// Document returns the document read so far. Its Content holds the
+// attributes and unknown elements of Content but not the paragraphs
+// returned by Next. The elements that follow Content in the file
+// (in Final Draft's files that is everything but Content) are only
+// available once Next has returned io.EOF.
+func (dec *Decoder) Document() *FinalDraft {
+	return dec.document
+}
+
+// elementTokens reads the tokens of the element described by start
+// through its end element.
+func elementTokens(d *xml.Decoder, start xml.StartElement) ([]xml.Token, error) {
+	tokens := []xml.Token{start.Copy()}
+	for depth := 1; depth > 0; {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+		tokens = append(tokens, xml.CopyToken(tok))
+	}
+	return tokens, nil
+}
+
+// decodeTokens decodes a list of tokens into v
+func decodeTokens(tokens []xml.Token, v interface{}) error {
+	return xml.NewTokenDecoder(&tokenList{tokens: tokens}).Decode(v)
+}
+
+// Next returns the next paragraph in the document's Content. io.EOF
+// is returned after the document's end element has been read, other
+// errors are returned as a *ParseError.
+func (dec *Decoder) Next() (*Paragraph, error) {
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	paragraph, err := dec.next()
+	if err != nil && err != io.EOF {
+		line, column := dec.d.InputPos()
+		err = &ParseError{
+			Line:    line,
+			Column:  column,
+			Path:    strings.Join(dec.path, "/"),
+			Snippet: dec.recent.snippet(dec.d.InputOffset()),
+			Err:     err,
+		}
+	}
+	if err != nil {
+		dec.err = err
+	}
+	return paragraph, err
+}
+
+func (dec *Decoder) next() (*Paragraph, error) {
+	type rawFinalDraft FinalDraft
+	for {
+		if dec.state == afterDocument {
+			return nil, io.EOF
+		}
+		tok, err := dec.d.Token()
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch dec.state {
+			case beforeDocument:
+				// Decode the document's attributes
+				dec.root = t.Name
+				dec.path = append(dec.path, t.Name.Local)
+				if err := decodeTokens([]xml.Token{t.Copy(), xml.EndElement{Name: t.Name}}, (*rawFinalDraft)(dec.document)); err != nil {
+					return nil, err
+				}
+				dec.document.UnknownAttrs = keepEmptyAttrs(t, dec.document.UnknownAttrs)
+				dec.state = inDocument
+			case inDocument:
+				dec.document.order = append(dec.document.order, t.Name.Local)
+				if t.Name.Local == "Content" {
+					content := new(Content)
+					if err := decodeTokens([]xml.Token{t.Copy(), xml.EndElement{Name: t.Name}}, content); err != nil {
+						return nil, err
+					}
+					dec.document.Content = content
+					dec.path = append(dec.path, t.Name.Local)
+					dec.state = inContent
+					continue
+				}
+				dec.path = append(dec.path, t.Name.Local)
+				// Decode the element as the only child of the document
+				// so it lands in the same field it would with Parse.
+				tokens, err := elementTokens(dec.d, t)
+				if err != nil {
+					return nil, err
+				}
+				tokens = append([]xml.Token{xml.StartElement{Name: dec.root}}, tokens...)
+				tokens = append(tokens, xml.EndElement{Name: dec.root})
+				if err := decodeTokens(tokens, (*rawFinalDraft)(dec.document)); err != nil {
+					return nil, err
+				}
+				dec.path = dec.path[0 : len(dec.path)-1]
+			case inContent:
+				dec.path = append(dec.path, t.Name.Local)
+				if t.Name.Local == "Paragraph" && dec.skip {
+					if err := dec.d.Skip(); err != nil {
+						return nil, err
+					}
+					dec.path = dec.path[0 : len(dec.path)-1]
+					continue
+				}
+				if t.Name.Local == "Paragraph" {
+					paragraph := new(Paragraph)
+					if err := dec.d.DecodeElement(paragraph, &t); err != nil {
+						return nil, err
+					}
+					dec.path = dec.path[0 : len(dec.path)-1]
+					return paragraph, nil
+				}
+				elem := new(UnknownElement)
+				if err := dec.d.DecodeElement(elem, &t); err != nil {
+					return nil, err
+				}
+				dec.path = dec.path[0 : len(dec.path)-1]
+				dec.document.Content.UnknownElements = append(dec.document.Content.UnknownElements, elem)
+			}
+		case xml.EndElement:
+			dec.path = dec.path[0 : len(dec.path)-1]
+			switch dec.state {
+			case inContent:
+				dec.state = inDocument
+			case inDocument:
+				dec.state = afterDocument
+			}
+		}
+	}
+}
+
+// Paragraphs returns an iterator over the remaining paragraphs in
+// the document's Content. Iteration stops after the first error.
+func (dec *Decoder) Paragraphs() iter.Seq2[*Paragraph, error] {
+	return func(yield func(*Paragraph, error) bool) {
+		for {
+			paragraph, err := dec.Next()
+			if err == io.EOF {
+				return
+			}
+			if !yield(paragraph, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Skip reads the rest of the document without decoding the remaining
+// paragraphs, Document then holds the document's settings (e.g. the
+// TitlePage and SceneNumberOptions which follow Content).
+func (dec *Decoder) Skip() error {
+	dec.skip = true
+	for {
+		if _, err := dec.Next(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Decode reads the rest of the document returning it with all of its
+// paragraphs, the same document Parse returns.
+func (dec *Decoder) Decode() (*FinalDraft, error) {
+	for paragraph, err := range dec.Paragraphs() {
+		if err != nil {
+			return dec.document, err
+		}
+		dec.document.Content.Paragraph = append(dec.document.Content.Paragraph, paragraph)
+	}
+	return dec.document, nil
+}
+
+// Encoder writes a Final Draft document to an io.Writer a paragraph
+// at a time. The output is the same as ToXML.
+type Encoder struct {
+	w        *bufio.Writer
+	document *FinalDraft
+	count    int
+}
+
+// NewEncoder returns an Encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// skeleton renders the document with an empty Content split after
+// Content's start tag.
+func (document *FinalDraft) skeleton() ([]byte, []byte, error) {
+	doc := *document
+	doc.Content = new(Content)
+	if document.Content != nil {
+		*doc.Content = *document.Content
+		doc.Content.Paragraph = nil
+	}
+	src, err := doc.ToXML()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Content is a child of the document so it starts a line
+	// indented once.
+	tag := []byte("\n    <Content")
+	for i := 0; i < len(src); {
+		j := bytes.Index(src[i:], tag)
+		if j < 0 {
+			break
+		}
+		j += i + len(tag)
+		if j < len(src) && (src[j] == '>' || src[j] == ' ') {
+			k := bytes.IndexByte(src[j:], '>') + j + 1
+			return src[:k], src[k:], nil
+		}
+		i = j
+	}
+	return nil, nil, fmt.Errorf("can't find Content in document")
+}
+
+// Start writes the document up to and including the start of
+// Content. The document's elements that follow Content are written
+// by Close. A document without Content is written with an empty one.
+func (enc *Encoder) Start(document *FinalDraft) error {
+	if enc.document != nil {
+		return fmt.Errorf("document already started")
+	}
+	head, _, err := document.skeleton()
+	if err != nil {
+		return err
+	}
+	enc.document, enc.count = document, 0
+	_, err = enc.w.Write(head)
+	return err
+}
+
+// EncodeParagraph writes a paragraph of Content
+func (enc *Encoder) EncodeParagraph(paragraph *Paragraph) error {
+	if enc.document == nil {
+		return fmt.Errorf("document not started")
+	}
+	if paragraph == nil {
+		return nil
+	}
+	src, err := xml.MarshalIndent(paragraph, "        ", "    ")
+	if err != nil {
+		return err
+	}
+	enc.w.WriteString("\n")
+	if _, err := enc.w.Write(CleanupSelfClosingElements(src)); err != nil {
+		return err
+	}
+	enc.count++
+	return nil
+}
+
+// Close writes the end of Content and the rest of the document
+func (enc *Encoder) Close() error {
+	if enc.document == nil {
+		return fmt.Errorf("document not started")
+	}
+	_, tail, err := enc.document.skeleton()
+	if err != nil {
+		return err
+	}
+	enc.document = nil
+	if enc.count > 0 && bytes.HasPrefix(tail, []byte("</Content>")) {
+		// Content is no longer empty so its end tag goes on its
+		// own line.
+		enc.w.WriteString("\n    ")
+	}
+	if _, err := enc.w.Write(tail); err != nil {
+		return err
+	}
+	return enc.w.Flush()
+}
+
+// Encode writes the document a paragraph at a time
+func (enc *Encoder) Encode(document *FinalDraft) error {
+	if err := enc.Start(document); err != nil {
+		return err
+	}
+	if document.Content != nil {
+		for _, paragraph := range document.Content.Paragraph {
+			if err := enc.EncodeParagraph(paragraph); err != nil {
+				return err
+			}
+		}
+	}
+	return enc.Close()
+}
+
+// TextWriter writes a document as plain text (the same as String) or
+// as Fountain (the same as ToFountain) a paragraph at a time. The
+// title page, scene number options and unanchored script notes are
+// taken from the document given to NewTextWriter, its paragraphs
+// are not written.
+type TextWriter struct {
+	w        *bufio.Writer
+	document *FinalDraft
+	fountain bool
+	begun    bool
+	started  bool
+	prev     string
+	err      error
+}
+
+// NewTextWriter returns a TextWriter writing to w, when fountain is
+// true the paragraphs are written as ToFountain writes them.
+func NewTextWriter(w io.Writer, document *FinalDraft, fountain bool) *TextWriter {
+	return &TextWriter{w: bufio.NewWriter(w), document: document, fountain: fountain}
+}
+
+// write writes s remembering the first error
+func (tw *TextWriter) write(s string) {
+	if tw.err == nil {
+		_, tw.err = tw.w.WriteString(s)
+	}
+}
+
+// begin writes the title page ahead of the first paragraph
+func (tw *TextWriter) begin() {
+	if tw.begun {
+		return
+	}
+	tw.begun = true
+	if s := tw.document.TitlePage.Fountain(); s != "" {
+		tw.write(s + "\n")
+	}
+}
+
+// WriteParagraph writes a paragraph of Content
+func (tw *TextWriter) WriteParagraph(paragraph *Paragraph) error {
+	tw.begin()
+	if !tw.fountain {
+		options := tw.document.SceneNumberOptions
+		left := options != nil && options.ShowNumbersOnLeft == "Yes"
+		right := options != nil && options.ShowNumbersOnRight == "Yes"
+		tw.write(paragraph.numberedString(left, right))
+		return tw.err
+	}
+	s := paragraph.ToFountain()
+	if s == "" {
+		return tw.err
+	}
+	if tw.started && inDialogue(tw.prev, paragraph.Type) {
+		tw.write("\n")
+	} else if tw.started {
+		tw.write("\n\n")
+	}
+	if paragraph.StartsNewPage == "Yes" && tw.started {
+		tw.write("===\n\n")
+	}
+	if !tw.started && tw.document.TitlePage.Fountain() == "" {
+		// Until a scene heading or transition Fountain reads
+		// lines as the title page, a page break ends it.
+		line := strings.SplitN(s, "\n", 2)[0]
+		if !looksLikeSceneHeading(line) && !looksLikeTransition(line) {
+			tw.write("===\n\n")
+		}
+	}
+	tw.write(s)
+	tw.prev, tw.started = paragraph.Type, true
+	return tw.err
+}
+
+// Close writes the unanchored script notes (when ShowNotes is true)
+// and flushes the output.
+func (tw *TextWriter) Close() error {
+	tw.begin()
+	if tw.started {
+		tw.write("\n")
+	}
+	if ShowNotes && tw.document.UnanchoredScriptNotes != nil {
+		for _, note := range tw.document.UnanchoredScriptNotes.ScriptNote {
+			if s := fountainNotes([]*ScriptNote{note}); s != "" {
+				tw.write("\n" + s + "\n")
+			}
+		}
+	}
+	if tw.err != nil {
+		return tw.err
+	}
+	return tw.w.Flush()
+}
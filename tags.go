@@ -0,0 +1,625 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"html"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Breakdown tag categories
+	CastCategory           = "Cast Members"
+	ExtrasCategory         = "Extras"
+	PropsCategory          = "Props"
+	WardrobeCategory       = "Wardrobe"
+	VehiclesCategory       = "Vehicles"
+	SpecialEffectsCategory = "Special Effects"
+	// UncategorizedCategory lists tagged text without a tag definition
+	UncategorizedCategory = "Uncategorized"
+
+	// BreakdownCSS styles the breakdown sheets written by
+	// WriteBreakdownReport as HTML, one sheet per printed page.
+	BreakdownCSS = `body { font-family: Helvetica, Arial, sans-serif; font-size: 11pt; }
+.breakdown-sheet { page-break-after: always; margin: 1em auto; max-width: 7.5in; }
+.breakdown-sheet h2 { border-bottom: 2px solid #000; }
+.breakdown-sheet table { width: 100%; border-collapse: collapse; margin-bottom: 1em; }
+.breakdown-sheet th, .breakdown-sheet td { border: 1px solid #999; padding: 0.25em 0.5em; text-align: left; vertical-align: top; }
+.breakdown-sheet th { width: 25%; background: #eee; }
+`
+)
+
+var (
+	// BreakdownCategories are the tag categories always included in
+	// a breakdown report, other categories follow when the script
+	// uses them.
+	BreakdownCategories = []string{CastCategory, ExtrasCategory, PropsCategory, WardrobeCategory, VehiclesCategory, SpecialEffectsCategory}
+)
+
+// TaggedElement describes tagged text, text tagged with more than one
+// definition (e.g. both a prop and a special effect) is listed once
+// for each.
+type TaggedElement struct {
+	// Index of the paragraph in Content, for dual dialogue the index
+	// of the paragraph holding it
+	Index int `json:"index" yaml:"index"`
+	// Paragraph holding the tagged text
+	Paragraph *Paragraph `json:"-" yaml:"-"`
+	// Text that is tagged
+	Text string `json:"text" yaml:"text"`
+	// Number of the tag
+	Number string `json:"number" yaml:"number"`
+	// Category of the tag definition (e.g. "Props")
+	Category string `json:"category,omitempty" yaml:"category,omitempty"`
+	// Label of the tag definition (e.g. "REVOLVER")
+	Label string `json:"label,omitempty" yaml:"label,omitempty"`
+}
+
+// BreakdownSheet lists the elements a scene needs by category
+type BreakdownSheet struct {
+	// Scene the sheet describes
+	Scene *Scene `json:"scene" yaml:"scene"`
+	// Elements maps a category (e.g. "Props") to the labels tagged in
+	// the scene, Cast Members includes the characters speaking.
+	Elements map[string][]string `json:"elements,omitempty" yaml:"elements,omitempty"`
+}
+
+// newTagID returns a random (version 4) UUID like the ids Final Draft
+// gives tag categories and definitions. It panics if the system's
+// random number generator fails, like Go 1.24's crypto/rand does.
+func newTagID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("fdx: can't create a tag id, %s", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return strings.ToUpper(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]))
+}
+
+// nextNumber returns one more than the largest of numbers
+func nextNumber(numbers []string) string {
+	maxNumber := 0
+	for _, number := range numbers {
+		if i, err := strconv.Atoi(number); err == nil && i > maxNumber {
+			maxNumber = i
+		}
+	}
+	return strconv.Itoa(maxNumber + 1)
+}
+
+// tagData returns the document's TagData creating it if needed
+func (document *FinalDraft) tagData() *TagData {
+	if document.TagData == nil {
+		document.TagData = new(TagData)
+	}
+	if document.TagData.TagCategories == nil {
+		document.TagData.TagCategories = new(TagCategories)
+	}
+	if document.TagData.TagDefinitions == nil {
+		document.TagData.TagDefinitions = new(TagDefinitions)
+	}
+	if document.TagData.Tags == nil {
+		document.TagData.Tags = new(Tags)
+	}
+	return document.TagData
+}
+
+// TagCategory returns the tag category named name (ignoring case),
+// nil if it isn't defined.
+func (document *FinalDraft) TagCategory(name string) *TagCategory {
+	if document == nil || document.TagData == nil || document.TagData.TagCategories == nil {
+		return nil
+	}
+	for _, category := range document.TagData.TagCategories.TagCategory {
+		if strings.EqualFold(category.Name, name) {
+			return category
+		}
+	}
+	return nil
+}
+
+// AddTagCategory returns the tag category named name adding it if
+// it isn't defined.
+func (document *FinalDraft) AddTagCategory(name string) *TagCategory {
+	if category := document.TagCategory(name); category != nil {
+		return category
+	}
+	tagData := document.tagData()
+	numbers := []string{}
+	for _, category := range tagData.TagCategories.TagCategory {
+		numbers = append(numbers, category.Number)
+	}
+	category := new(TagCategory)
+	category.ID = newTagID()
+	category.Name = name
+	category.Number = nextNumber(numbers)
+	tagData.TagCategories.TagCategory = append(tagData.TagCategories.TagCategory, category)
+	return category
+}
+
+// tagCategoryByID returns the tag category with the given id, nil if
+// it isn't defined.
+func (document *FinalDraft) tagCategoryByID(id string) *TagCategory {
+	if document.TagData == nil || document.TagData.TagCategories == nil {
+		return nil
+	}
+	for _, category := range document.TagData.TagCategories.TagCategory {
+		if category.ID == id {
+			return category
+		}
+	}
+	return nil
+}
+
+// tagDefinitionByID returns the tag definition with the given id, nil
+// if it isn't defined.
+func (document *FinalDraft) tagDefinitionByID(id string) *TagDefinition {
+	if document.TagData == nil || document.TagData.TagDefinitions == nil {
+		return nil
+	}
+	for _, def := range document.TagData.TagDefinitions.TagDefinition {
+		if def.ID == id {
+			return def
+		}
+	}
+	return nil
+}
+
+// TagDefinition returns the definition labeled label in the tag
+// category named category (ignoring case), nil if it isn't defined.
+func (document *FinalDraft) TagDefinition(category string, label string) *TagDefinition {
+	cat := document.TagCategory(category)
+	if cat == nil || document.TagData.TagDefinitions == nil {
+		return nil
+	}
+	for _, def := range document.TagData.TagDefinitions.TagDefinition {
+		if def.CategoryID == cat.ID && strings.EqualFold(def.Label, label) {
+			return def
+		}
+	}
+	return nil
+}
+
+// AddTagDefinition returns the definition labeled label in the tag
+// category named category adding the category and definition if
+// they aren't defined.
+func (document *FinalDraft) AddTagDefinition(category string, label string) *TagDefinition {
+	if def := document.TagDefinition(category, label); def != nil {
+		return def
+	}
+	cat := document.AddTagCategory(category)
+	tagData := document.tagData()
+	numbers := []string{}
+	for _, def := range tagData.TagDefinitions.TagDefinition {
+		numbers = append(numbers, def.Number)
+	}
+	def := new(TagDefinition)
+	def.CategoryID = cat.ID
+	def.ID = newTagID()
+	def.Label = label
+	def.Number = nextNumber(numbers)
+	tagData.TagDefinitions.TagDefinition = append(tagData.TagDefinitions.TagDefinition, def)
+	return def
+}
+
+// Tag returns the tag with the given number, nil if there isn't one
+func (document *FinalDraft) Tag(number string) *Tag {
+	if document == nil || document.TagData == nil || document.TagData.Tags == nil {
+		return nil
+	}
+	for _, tag := range document.TagData.Tags.Tag {
+		if tag.Number == number {
+			return tag
+		}
+	}
+	return nil
+}
+
+// sameRunStyle reports if two Text runs differ only in their text
+func sameRunStyle(a *Text, b *Text) bool {
+	return a.AdornmentStyle == b.AdornmentStyle && a.Background == b.Background &&
+		a.Color == b.Color && a.Font == b.Font && a.RevisionID == b.RevisionID &&
+		a.Size == b.Size && a.Style == b.Style && a.TagNumber == b.TagNumber &&
+		len(a.UnknownAttrs) == 0 && len(b.UnknownAttrs) == 0 &&
+		len(a.UnknownElements) == 0 && len(b.UnknownElements) == 0
+}
+
+// mergeRuns joins neighbouring Text runs that differ only in their text
+func mergeRuns(runs []*Text) []*Text {
+	out := []*Text{}
+	for _, text := range runs {
+		if n := len(out); n > 0 && sameRunStyle(out[n-1], text) {
+			out[n-1].InnerText += text.InnerText
+			continue
+		}
+		out = append(out, text)
+	}
+	return out
+}
+
+// splitRuns splits a paragraph's Text runs at the rune offsets start
+// and end returning the runs between them. An error is returned if
+// the range isn't in the paragraph's text.
+func splitRuns(paragraph *Paragraph, start int, length int) ([]*Text, error) {
+	size := len([]rune(paragraph.PlainText()))
+	if start < 0 || length <= 0 || start+length > size {
+		return nil, fmt.Errorf("range %d,%d is outside the paragraph's text (%d characters)", start, length, size)
+	}
+	end := start + length
+	selected := sliceRuns(paragraph.Text, start, end)
+	runs := append(sliceRuns(paragraph.Text, 0, start), selected...)
+	paragraph.Text = append(runs, sliceRuns(paragraph.Text, end, size)...)
+	return selected, nil
+}
+
+// usedTagNumbers returns the tag numbers used by Text in the document
+func (document *FinalDraft) usedTagNumbers() map[string]bool {
+	used := map[string]bool{}
+	var walk func([]*Paragraph)
+	walk = func(paragraphs []*Paragraph) {
+		for _, paragraph := range paragraphs {
+			for _, text := range paragraph.Text {
+				if text.TagNumber != "" {
+					used[text.TagNumber] = true
+				}
+			}
+			if paragraph.DualDialogue != nil {
+				walk(paragraph.DualDialogue.Paragraph)
+			}
+		}
+	}
+	if document.Content != nil {
+		walk(document.Content.Paragraph)
+	}
+	return used
+}
+
+// tagCovers reports if the text tagged with number is all in runs
+func (document *FinalDraft) tagCovers(number string, runs []*Text) bool {
+	inRuns := map[*Text]bool{}
+	for _, text := range runs {
+		inRuns[text] = true
+	}
+	covers := true
+	var walk func([]*Paragraph)
+	walk = func(paragraphs []*Paragraph) {
+		for _, paragraph := range paragraphs {
+			for _, text := range paragraph.Text {
+				if text.TagNumber == number && !inRuns[text] {
+					covers = false
+				}
+			}
+			if paragraph.DualDialogue != nil {
+				walk(paragraph.DualDialogue.Paragraph)
+			}
+		}
+	}
+	if document.Content != nil {
+		walk(document.Content.Paragraph)
+	}
+	return covers
+}
+
+// pruneTags removes the tags named in numbers that no longer tag any text
+func (document *FinalDraft) pruneTags(numbers map[string]bool) {
+	if document.TagData == nil || document.TagData.Tags == nil {
+		return
+	}
+	used := document.usedTagNumbers()
+	tags := []*Tag{}
+	for _, tag := range document.TagData.Tags.Tag {
+		if !numbers[tag.Number] || used[tag.Number] {
+			tags = append(tags, tag)
+		}
+	}
+	document.TagData.Tags.Tag = tags
+}
+
+// AddTag tags length characters of a paragraph's text starting at
+// start with the definition labeled label in the tag category named
+// category, the category and definition are added when needed. If
+// the range is already tagged as a whole the definition is added to
+// its tag, otherwise the range gets a new tag.
+func (document *FinalDraft) AddTag(paragraph *Paragraph, start int, length int, category string, label string) (*Tag, error) {
+	runs, err := splitRuns(paragraph, start, length)
+	if err != nil {
+		return nil, err
+	}
+	def := document.AddTagDefinition(category, label)
+	tagData := document.tagData()
+	var tag *Tag
+	previous := map[string]bool{}
+	for _, text := range runs {
+		previous[text.TagNumber] = true
+	}
+	if len(previous) == 1 && document.tagCovers(runs[0].TagNumber, runs) {
+		tag = document.Tag(runs[0].TagNumber)
+	}
+	if tag == nil {
+		numbers := []string{}
+		for _, t := range tagData.Tags.Tag {
+			numbers = append(numbers, t.Number)
+		}
+		tag = new(Tag)
+		tag.Number = nextNumber(numbers)
+		// Text already tagged keeps its definitions, e.g. tagging
+		// "RED" in a "RED REVOLVER" prop keeps the prop.
+		for _, text := range runs {
+			if old := document.Tag(text.TagNumber); old != nil {
+				for _, id := range old.DefinitionID {
+					if !slices.Contains(tag.DefinitionID, id) {
+						tag.DefinitionID = append(tag.DefinitionID, id)
+					}
+				}
+			}
+		}
+		tagData.Tags.Tag = append(tagData.Tags.Tag, tag)
+	}
+	found := false
+	for _, id := range tag.DefinitionID {
+		found = found || id == def.ID
+	}
+	if !found {
+		tag.DefinitionID = append(tag.DefinitionID, def.ID)
+	}
+	for _, text := range runs {
+		text.TagNumber = tag.Number
+	}
+	paragraph.Text = mergeRuns(paragraph.Text)
+	document.pruneTags(previous)
+	return tag, nil
+}
+
+// RemoveTag removes the tags from length characters of a paragraph's
+// text starting at start. Tags no longer used by any text are removed,
+// tag categories and definitions are kept.
+func (document *FinalDraft) RemoveTag(paragraph *Paragraph, start int, length int) error {
+	runs, err := splitRuns(paragraph, start, length)
+	if err != nil {
+		return err
+	}
+	previous := map[string]bool{}
+	for _, text := range runs {
+		previous[text.TagNumber] = true
+		text.TagNumber = ""
+	}
+	paragraph.Text = mergeRuns(paragraph.Text)
+	document.pruneTags(previous)
+	return nil
+}
+
+// taggedElements appends the tagged text of paragraph to elements
+func (document *FinalDraft) taggedElements(elements []*TaggedElement, paragraph *Paragraph, index int) []*TaggedElement {
+	runs := []*Text{}
+	for i, text := range paragraph.Text {
+		if text.TagNumber == "" {
+			continue
+		}
+		runs = append(runs, text)
+		if i+1 < len(paragraph.Text) && paragraph.Text[i+1].TagNumber == text.TagNumber {
+			continue
+		}
+		src := []string{}
+		for _, run := range runs {
+			src = append(src, run.InnerText)
+		}
+		runs = []*Text{}
+		element := &TaggedElement{
+			Index:     index,
+			Paragraph: paragraph,
+			Text:      strings.TrimSpace(strings.Join(src, "")),
+			Number:    text.TagNumber,
+		}
+		tag := document.Tag(text.TagNumber)
+		if tag == nil || len(tag.DefinitionID) == 0 {
+			elements = append(elements, element)
+			continue
+		}
+		for _, id := range tag.DefinitionID {
+			e := new(TaggedElement)
+			*e = *element
+			if def := document.tagDefinitionByID(id); def != nil {
+				e.Label = def.Label
+				if category := document.tagCategoryByID(def.CategoryID); category != nil {
+					e.Category = category.Name
+				}
+			}
+			elements = append(elements, e)
+		}
+	}
+	if paragraph.DualDialogue != nil {
+		for _, p := range paragraph.DualDialogue.Paragraph {
+			elements = document.taggedElements(elements, p, index)
+		}
+	}
+	return elements
+}
+
+// TaggedElements returns the tagged text of the script in document order
+func (document *FinalDraft) TaggedElements() []*TaggedElement {
+	elements := []*TaggedElement{}
+	if document == nil || document.Content == nil {
+		return elements
+	}
+	for i, paragraph := range document.Content.Paragraph {
+		elements = document.taggedElements(elements, paragraph, i)
+	}
+	return elements
+}
+
+// addElement adds label to a category's list unless it is already there
+func addElement(elements map[string][]string, category string, label string) {
+	for _, s := range elements[category] {
+		if strings.EqualFold(s, label) {
+			return
+		}
+	}
+	elements[category] = append(elements[category], label)
+}
+
+// Breakdown returns a breakdown sheet for each scene listing the
+// tagged elements by category along with the characters speaking.
+// Scenes without a number are numbered by their position.
+func (document *FinalDraft) Breakdown() []*BreakdownSheet {
+	sheets := []*BreakdownSheet{}
+	scenes := document.Scenes()
+	for i, scene := range scenes {
+		if scene.Number == "" {
+			scene.Number = strconv.Itoa(i + 1)
+		}
+		sheet := &BreakdownSheet{Scene: scene, Elements: map[string][]string{}}
+		for _, name := range scene.Characters {
+			addElement(sheet.Elements, CastCategory, name)
+		}
+		sheets = append(sheets, sheet)
+	}
+	if len(sheets) == 0 {
+		return sheets
+	}
+	i := 0
+	for _, element := range document.TaggedElements() {
+		if element.Index < scenes[0].Index {
+			continue
+		}
+		for i+1 < len(scenes) && element.Index >= scenes[i+1].Index {
+			i++
+		}
+		category, label := element.Category, element.Label
+		if label == "" {
+			label = element.Text
+		}
+		if category == "" {
+			category = UncategorizedCategory
+		}
+		if cat := document.TagCategory(category); cat != nil {
+			category = cat.Name
+		}
+		for _, name := range BreakdownCategories {
+			if strings.EqualFold(name, category) {
+				category = name
+			}
+		}
+		addElement(sheets[i].Elements, category, label)
+	}
+	return sheets
+}
+
+// breakdownCategories returns BreakdownCategories followed by the
+// other categories used in sheets, in the order the document defines
+// them.
+func (document *FinalDraft) breakdownCategories(sheets []*BreakdownSheet) []string {
+	categories := append([]string{}, BreakdownCategories...)
+	seen := map[string]bool{}
+	for _, name := range categories {
+		seen[name] = true
+	}
+	names := []string{}
+	if document.TagData != nil && document.TagData.TagCategories != nil {
+		for _, category := range document.TagData.TagCategories.TagCategory {
+			names = append(names, category.Name)
+		}
+	}
+	names = append(names, UncategorizedCategory)
+	for _, name := range names {
+		for _, sheet := range sheets {
+			if _, ok := sheet.Elements[name]; ok && !seen[name] {
+				categories, seen[name] = append(categories, name), true
+			}
+		}
+	}
+	return categories
+}
+
+// writeBreakdownHTML writes the breakdown sheets as an HTML document
+func (document *FinalDraft) writeBreakdownHTML(w io.Writer, sheets []*BreakdownSheet, categories []string) error {
+	out := bufio.NewWriter(w)
+	title := "Breakdown"
+	if s := strings.TrimSpace(strings.ReplaceAll(document.TitlePage.Fields()["Title"], "\n", " ")); s != "" {
+		title = s + " Breakdown"
+	}
+	fmt.Fprintf(out, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(out, "<style>\n%s</style>\n</head>\n<body>\n", BreakdownCSS)
+	for _, sheet := range sheets {
+		scene := sheet.Scene
+		out.WriteString("<section class=\"breakdown-sheet\">\n")
+		fmt.Fprintf(out, "<h2>Scene %s: %s</h2>\n", html.EscapeString(scene.Number), html.EscapeString(scene.Heading))
+		out.WriteString("<table class=\"scene\">\n")
+		for _, field := range [][]string{
+			{"Page", scene.Page},
+			{"Length", scene.Length},
+			{"Int/Ext", scene.IntExt},
+			{"Location", scene.Location},
+			{"Time", scene.TimeOfDay},
+		} {
+			fmt.Fprintf(out, "<tr><th>%s</th><td>%s</td></tr>\n", field[0], html.EscapeString(field[1]))
+		}
+		out.WriteString("</table>\n<table class=\"elements\">\n")
+		for _, category := range categories {
+			labels := []string{}
+			for _, label := range sheet.Elements[category] {
+				labels = append(labels, html.EscapeString(label))
+			}
+			fmt.Fprintf(out, "<tr><th>%s</th><td>%s</td></tr>\n", html.EscapeString(category), strings.Join(labels, "<br>"))
+		}
+		out.WriteString("</table>\n</section>\n")
+	}
+	out.WriteString("</body>\n</html>\n")
+	return out.Flush()
+}
+
+// WriteBreakdownReport writes the breakdown sheets as a table, CSV,
+// JSON or HTML (see TableFormat, CSVFormat, JSONFormat and
+// HTMLFormat). Tables and CSV have a row for each scene and a column
+// for each category, HTML has a sheet for each scene.
+func (document *FinalDraft) WriteBreakdownReport(w io.Writer, format string) error {
+	sheets := document.Breakdown()
+	categories := document.breakdownCategories(sheets)
+	if strings.ToLower(format) == HTMLFormat {
+		return document.writeBreakdownHTML(w, sheets, categories)
+	}
+	header := append([]string{"Scene", "Page", "Length", "Int/Ext", "Location", "Time"}, categories...)
+	rows := [][]string{}
+	for _, sheet := range sheets {
+		scene := sheet.Scene
+		row := []string{scene.Number, scene.Page, scene.Length, scene.IntExt, scene.Location, scene.TimeOfDay}
+		for _, category := range categories {
+			row = append(row, strings.Join(sheet.Elements[category], ", "))
+		}
+		rows = append(rows, row)
+	}
+	return writeReport(w, format, header, rows, sheets)
+}
@@ -0,0 +1,216 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TitlePageLines is the number of lines on a title page
+	// (Letter with one inch margins in Courier 12pt)
+	TitlePageLines = 54
+)
+
+var (
+	// TitlePageKeys lists the Fountain title page keys in the
+	// order they are rendered, other keys follow in sorted order.
+	TitlePageKeys = []string{
+		"Title",
+		"Credit",
+		"Author",
+		"Source",
+		"Draft date",
+		"Copyright",
+		"Revision",
+		"Notes",
+		"Contact",
+	}
+
+	reCredit   = regexp.MustCompile(`^([a-z]+ ){0,3}by$`)
+	reDate     = regexp.MustCompile(`^(\d{1,4}[-/.]\d{1,2}[-/.]\d{1,4}|([a-z]+ )?\d{1,2},? \d{4}|[a-z]+ \d{4})$`)
+	reFieldKey = regexp.MustCompile(`^([A-Za-z][A-Za-z ]{0,30}):\s*(.*)$`)
+)
+
+// titlePageKey returns the canonical spelling of a known Fountain
+// title page key, otherwise the key unchanged.
+func titlePageKey(key string) string {
+	key = strings.TrimSpace(key)
+	switch strings.ToLower(key) {
+	case "authors":
+		return "Author"
+	case "date":
+		return "Draft date"
+	}
+	for _, k := range TitlePageKeys {
+		if strings.EqualFold(k, key) {
+			return k
+		}
+	}
+	return key
+}
+
+// isTitlePageKey reports if key is one of TitlePageKeys
+func isTitlePageKey(key string) bool {
+	for _, k := range TitlePageKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// fountainText renders a paragraph's Text runs with Fountain emphasis
+func (paragraph *Paragraph) fountainText() string {
+	runs := []string{}
+	for _, text := range paragraph.Text {
+		runs = append(runs, text.String())
+	}
+	return strings.TrimSpace(strings.Join(runs, ""))
+}
+
+// lines returns the number of lines a paragraph occupies
+// including any space before it.
+func (paragraph *Paragraph) lines() int {
+	n := 1
+	if s := paragraph.PlainText(); s != "" {
+		n += strings.Count(s, "\n")
+	}
+	if points, err := strconv.Atoi(paragraph.SpaceBefore); err == nil && points > 0 {
+		n += points / 12
+	}
+	return n
+}
+
+// Fields (of TitlePage) extracts the Fountain title page fields
+// (e.g. Title, Credit, Author, Draft date, Contact) from a Final Draft
+// title page. It is a heuristic based on the vertical position and
+// alignment of each paragraph as well as keywords like "Written by".
+// Centered paragraphs hold the title, credit, author(s) and source,
+// the lower half of the page the draft date, copyright, contact and any
+// other "Key: value" fields. Only the first page of a multi-page
+// title page is read.
+func (tp *TitlePage) Fields() map[string]string {
+	fields := map[string]string{}
+	if tp == nil || tp.Content == nil {
+		return fields
+	}
+	add := func(key, value string) {
+		if prev, ok := fields[key]; ok {
+			fields[key] = prev + "\n" + value
+		} else {
+			fields[key] = value
+		}
+	}
+	top := "Title"
+	y := 0
+	for i, paragraph := range tp.Content.Paragraph {
+		if i > 0 && paragraph.StartsNewPage == "Yes" {
+			break
+		}
+		y += paragraph.lines()
+		plain := strings.TrimSpace(paragraph.PlainText())
+		if plain == "" {
+			continue
+		}
+		lower := strings.ToLower(plain)
+		value := paragraph.fountainText()
+		bottom := paragraph.Alignment != CenterAlignment &&
+			(y > TitlePageLines/2 || (len(fields) > 0 && top != "Title"))
+		if !bottom {
+			switch {
+			case strings.HasPrefix(lower, "story by") || strings.HasPrefix(lower, "based on"):
+				top = "Source"
+				add(top, value)
+			case reCredit.MatchString(lower):
+				top = "Author"
+				add("Credit", value)
+			default:
+				add(top, value)
+			}
+			continue
+		}
+		switch m := reFieldKey.FindStringSubmatch(plain); {
+		case m != nil && !strings.Contains(plain, "\n"):
+			add(titlePageKey(m[1]), strings.TrimSpace(m[2]))
+		case strings.HasPrefix(lower, "copyright") || strings.HasPrefix(lower, "(c)") || strings.HasPrefix(lower, "©"):
+			add("Copyright", value)
+		case paragraph.Alignment == RightAlignment || reDate.MatchString(lower):
+			add("Draft date", value)
+		default:
+			add("Contact", value)
+		}
+	}
+	return fields
+}
+
+// FountainTitlePage renders title page fields as a Fountain title
+// page block, known keys first in TitlePageKeys order.
+func FountainTitlePage(fields map[string]string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := []string{}
+	for _, key := range TitlePageKeys {
+		if _, ok := fields[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	others := []string{}
+	for key := range fields {
+		if !isTitlePageKey(key) {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	keys = append(keys, others...)
+
+	src := []string{}
+	for _, key := range keys {
+		value := strings.TrimSpace(fields[key])
+		if strings.Contains(value, "\n") {
+			src = append(src, key+":")
+			for _, line := range strings.Split(value, "\n") {
+				src = append(src, "\t"+strings.TrimSpace(line))
+			}
+		} else {
+			src = append(src, key+": "+value)
+		}
+	}
+	return strings.Join(src, "\n") + "\n"
+}
+
+// Fountain (of TitlePage) returns the title page as a Fountain title page block
+func (tp *TitlePage) Fountain() string {
+	return FountainTitlePage(tp.Fields())
+}
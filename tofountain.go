@@ -0,0 +1,243 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	// reSceneHeading matches the scene heading prefixes Fountain
+	// recognizes without a forced "." marker.
+	reSceneHeading = regexp.MustCompile(`(?i)^(INT|EXT|EST|INT\.?/EXT|I/E)[. ]`)
+)
+
+// escapeEmphasis escapes Fountain emphasis markers in literal text
+func escapeEmphasis(s string) string {
+	runes := []rune(s)
+	out := []rune{}
+	for i, r := range runes {
+		switch {
+		case r == '\\' || r == '*' || r == '_':
+			out = append(out, '\\', r)
+		case r == '~' && i+1 < len(runes) && runes[i+1] == '~':
+			out = append(out, '\\', r)
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out)
+}
+
+// fountainRun renders a Text element with its style as Fountain emphasis
+func fountainRun(text *Text) string {
+	escaped := &Text{
+		Font:      text.Font,
+		Style:     text.Style,
+		InnerText: escapeEmphasis(text.InnerText),
+	}
+	return escaped.String()
+}
+
+// hasLower reports if s contains a lowercase letter
+func hasLower(s string) bool {
+	return s != strings.ToUpper(s)
+}
+
+// hasLetter reports if s contains a letter
+func hasLetter(s string) bool {
+	return strings.ToLower(s) != strings.ToUpper(s)
+}
+
+// looksLikeSceneHeading reports if a line would be read as a scene heading.
+// NOTE: the fountain package also treats any line holding " -" as
+// a scene heading.
+func looksLikeSceneHeading(line string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(line))
+	switch upper {
+	case "FADE IN:", "THE END", "THE END.", "LA FIN", "LA FIN.":
+		return true
+	}
+	return reSceneHeading.MatchString(upper) ||
+		strings.HasPrefix(upper, ".") ||
+		strings.Contains(upper, " -")
+}
+
+// looksLikeTransition reports if a line would be read as a transition
+func looksLikeTransition(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, ">") ||
+		strings.HasSuffix(line, "TO:") || strings.HasSuffix(line, "IN:")
+}
+
+// forceAction prefixes "!" to an action line that would otherwise be
+// read as another element type.
+func forceAction(line string) string {
+	s := strings.TrimSpace(line)
+	switch {
+	case s == "":
+		return line
+	case strings.ContainsAny(s[0:1], "!.>~@#=(") ||
+		strings.HasPrefix(s, "[[") || strings.HasPrefix(s, "/*"):
+		return "!" + line
+	case looksLikeSceneHeading(s) || looksLikeTransition(s):
+		return "!" + line
+	case hasLetter(s) && !hasLower(s):
+		// All caps lines read as a character name
+		return "!" + line
+	}
+	return line
+}
+
+// forceCharacter prefixes "@" to a character name with lowercase
+// letters or that would otherwise be read as another element type.
+func forceCharacter(name string) string {
+	upper := strings.ToUpper(name)
+	switch {
+	case hasLower(name),
+		looksLikeSceneHeading(name), looksLikeTransition(name),
+		strings.ContainsAny(name[0:1], "!.~@#=(\""),
+		strings.Contains(upper, "--"),
+		strings.HasSuffix(upper, ":"),
+		strings.HasSuffix(upper, "ANGLE"),
+		strings.HasSuffix(upper, "SHOT"),
+		strings.HasSuffix(upper, "P.O.V."):
+		return "@" + name
+	}
+	return name
+}
+
+// ToFountain (of Paragraph) returns the paragraph as Fountain source
+// with forced markers where Fountain would otherwise read the
+// text as a different element type. It does not include the blank
+// line separating elements.
+func (paragraph *Paragraph) ToFountain() string {
+	if paragraph == nil {
+		return ""
+	}
+	if paragraph.DualDialogue != nil {
+		return paragraph.DualDialogue.ToFountain()
+	}
+	runs := []string{}
+	for _, text := range paragraph.Text {
+		runs = append(runs, fountainRun(text))
+	}
+	s := strings.TrimSpace(strings.Join(runs, ""))
+	lines := []string{}
+	switch paragraph.Type {
+	case SceneHeadingType:
+		s = strings.ToUpper(s)
+		if !reSceneHeading.MatchString(s) {
+			s = "." + s
+		}
+		if paragraph.Number != "" {
+			s += " #" + paragraph.Number + "#"
+		}
+		lines = append(lines, s)
+	case CharacterType:
+		if s != "" {
+			lines = append(lines, forceCharacter(s))
+		}
+	case ParentheticalType:
+		if !strings.HasPrefix(s, "(") && !strings.HasSuffix(s, ")") {
+			s = "(" + s + ")"
+		}
+		lines = append(lines, s)
+	case DialogueType:
+		for _, line := range strings.Split(s, "\n") {
+			if strings.TrimSpace(line) == "" {
+				// Two spaces keep a blank line inside dialogue
+				line = "  "
+			}
+			lines = append(lines, line)
+		}
+	case TransitionType:
+		s = strings.ToUpper(s)
+		if !strings.HasSuffix(s, "TO:") {
+			s = ">" + s
+		}
+		lines = append(lines, s)
+	case SingingType:
+		for _, line := range strings.Split(s, "\n") {
+			lines = append(lines, "~"+line)
+		}
+	case ShotType:
+		// Fountain has no shots, they are written as all caps action
+		for _, line := range strings.Split(strings.ToUpper(s), "\n") {
+			lines = append(lines, forceAction(line))
+		}
+	default:
+		// Action, General, Cast List and other types are action
+		if paragraph.Alignment == CenterAlignment && s != "" {
+			lines = append(lines, ">"+s+"<")
+		} else if s != "" {
+			for _, line := range strings.Split(s, "\n") {
+				lines = append(lines, forceAction(line))
+			}
+		}
+	}
+	if ShowNotes && len(paragraph.ScriptNote) > 0 {
+		notes := fountainNotes(paragraph.ScriptNote)
+		if len(lines) > 0 {
+			lines[len(lines)-1] += " " + notes
+		} else {
+			lines = append(lines, notes)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inDialogue reports if a paragraph type continues a dialogue block
+func inDialogue(prev string, current string) bool {
+	switch prev {
+	case CharacterType, ParentheticalType, DialogueType:
+		return current == ParentheticalType || current == DialogueType
+	}
+	return false
+}
+
+// ToFountain (of FinalDraft) writes the document as Fountain source.
+// Scene headings, action, characters and transitions get forced
+// markers where needed so the Fountain element types match the Final
+// Draft paragraph types, Shot, General and Cast List paragraphs are
+// written as action.
+func (document *FinalDraft) ToFountain(w io.Writer) error {
+	tw := NewTextWriter(w, document, true)
+	if document.Content != nil {
+		for _, paragraph := range document.Content.Paragraph {
+			if err := tw.WriteParagraph(paragraph); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
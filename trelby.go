@@ -0,0 +1,530 @@
+// fdx is a package encoding/decoding fdx formatted XML files.
+//
+// @author R. S. Doiel, <rsdoiel@gmail.com>
+//
+// # BSD 2-Clause License
+//
+// Copyright (c) 2019, R. S. Doiel
+// All rights reserved.
+//
+// Redistribution and use in source and binary forms, with or without
+// modification, are permitted provided that the following conditions are met:
+//
+//   - Redistributions of source code must retain the above copyright notice, this
+//     list of conditions and the following disclaimer.
+//
+//   - Redistributions in binary form must reproduce the above copyright notice,
+//     this list of conditions and the following disclaimer in the documentation
+//     and/or other materials provided with the distribution.
+//
+// THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS "AS IS"
+// AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT LIMITED TO, THE
+// IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR A PARTICULAR PURPOSE ARE
+// DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT HOLDER OR CONTRIBUTORS BE LIABLE
+// FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL, EXEMPLARY, OR CONSEQUENTIAL
+// DAMAGES (INCLUDING, BUT NOT LIMITED TO, PROCUREMENT OF SUBSTITUTE GOODS OR
+// SERVICES; LOSS OF USE, DATA, OR PROFITS; OR BUSINESS INTERRUPTION) HOWEVER
+// CAUSED AND ON ANY THEORY OF LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY,
+// OR TORT (INCLUDING NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE
+// OF THIS SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package fdx
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// Trelby's native format is line based. A header of "#" lines
+// (version, config, title page and header strings) is followed by
+// "#Start-Script" and the script, one line per wrapped line of text.
+// Each script line starts with a line break character telling how the
+// line joins the next, then an element type character, then the text.
+//
+// e.g.
+//
+//	#Version 3
+//	#Title-String 0.000000,105.000000,12,cu,Courier,MY SCRIPT
+//	#Header-String 1,0,1,,${PAGE}.
+//	#Start-Script
+//	.\INT. HOUSE - DAY
+//	>.A long line of action wrapped by
+//	..Trelby.
+//
+
+const (
+	// NoteType and ActBreakType are the paragraph types of Trelby's
+	// note and act break elements, Final Draft has no equivalent.
+	NoteType     = "Note"
+	ActBreakType = "Act Break"
+
+	// TrelbyVersion is the file format version written by ToTrelby
+	TrelbyVersion = "3"
+
+	// TrelbyPageNo is the page number placeholder in Trelby's headers
+	TrelbyPageNo = "${PAGE}"
+
+	// MillimetersPerInch converts Trelby's title page positions
+	MillimetersPerInch = 25.4
+)
+
+var (
+	// trelbyTypes maps Trelby's element type characters to paragraph types
+	trelbyTypes = map[byte]string{
+		'\\': SceneHeadingType,
+		'.':  ActionType,
+		'_':  CharacterType,
+		':':  DialogueType,
+		'(':  ParentheticalType,
+		'/':  TransitionType,
+		'=':  ShotType,
+		'%':  NoteType,
+		'@':  ActBreakType,
+	}
+
+	// trelbyBreaks maps Trelby's line break characters to the text
+	// joining a line to the next, "." ends the paragraph.
+	trelbyBreaks = map[byte]string{
+		'>': " ",
+		'+': "  ",
+		'&': "",
+		'|': "\n",
+	}
+
+	// trelbyFlags are the title and header string style flags
+	trelbyFlags = []struct {
+		flag  string
+		style string
+	}{
+		{"b", BoldStyle},
+		{"i", ItalicStyle},
+		{"u", UnderlineStyle},
+	}
+)
+
+// trelbyTitleString is a block of text positioned on a title page.
+// X and Y are in millimeters from the page's top left corner.
+type trelbyTitleString struct {
+	X, Y  float64
+	Size  string
+	Flags string
+	Font  string
+	Items []string
+}
+
+// trelbyTypeChar returns the Trelby element type character for a
+// paragraph type, types Trelby lacks are written as action (or
+// dialogue for Singing).
+func trelbyTypeChar(paragraphType string) byte {
+	for c, t := range trelbyTypes {
+		if t == paragraphType {
+			return c
+		}
+	}
+	if paragraphType == SingingType {
+		return ':'
+	}
+	return '.'
+}
+
+// trelbyStyle converts title and header string flags (e.g. "cbu") to
+// a Text style (e.g. "Bold+Underline").
+func trelbyStyle(flags string) string {
+	styles := []string{}
+	for _, f := range trelbyFlags {
+		if strings.Contains(flags, f.flag) {
+			styles = append(styles, f.style)
+		}
+	}
+	return strings.Join(styles, "+")
+}
+
+// trelbyStyleFlags converts a Text style to title and header string flags
+func trelbyStyleFlags(style string) string {
+	flags := ""
+	for _, f := range trelbyFlags {
+		if strings.Contains(style, f.style) {
+			flags += f.flag
+		}
+	}
+	return flags
+}
+
+// trelbyEscape joins items with "\n" escaping backslashes
+func trelbyEscape(items []string) string {
+	escaped := []string{}
+	for _, item := range items {
+		escaped = append(escaped, strings.ReplaceAll(item, `\`, `\\`))
+	}
+	return strings.Join(escaped, `\n`)
+}
+
+// trelbyUnescape splits a string escaped by trelbyEscape into items
+func trelbyUnescape(s string) []string {
+	items := []string{}
+	item := []rune{}
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == '\\' && i+1 < len(runes) {
+			i++
+			if runes[i] == 'n' {
+				items = append(items, string(item))
+				item = []rune{}
+				continue
+			}
+		}
+		item = append(item, runes[i])
+	}
+	return append(items, string(item))
+}
+
+// parseTrelbyTitleString parses the value of a "#Title-String" line,
+// "x,y,size,flags,font,items" where flags holds "c" (centered), "r"
+// (right aligned), "b", "i" and "u" (bold, italic, underline).
+func parseTrelbyTitleString(s string) (*trelbyTitleString, error) {
+	parts := strings.SplitN(s, ",", 6)
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("malformed title string %q", s)
+	}
+	ts := new(trelbyTitleString)
+	var err error
+	if ts.X, err = strconv.ParseFloat(parts[0], 64); err != nil {
+		return nil, fmt.Errorf("malformed title string x position %q", parts[0])
+	}
+	if ts.Y, err = strconv.ParseFloat(parts[1], 64); err != nil {
+		return nil, fmt.Errorf("malformed title string y position %q", parts[1])
+	}
+	ts.Size, ts.Flags, ts.Font = parts[2], parts[3], parts[4]
+	ts.Items = trelbyUnescape(parts[5])
+	return ts, nil
+}
+
+// String (of trelbyTitleString) renders the value of a "#Title-String" line
+func (ts *trelbyTitleString) String() string {
+	return fmt.Sprintf("%f,%f,%s,%s,%s,%s", ts.X, ts.Y, ts.Size, ts.Flags, ts.Font, trelbyEscape(ts.Items))
+}
+
+// parseTrelbyHeaderString parses the value of a "#Header-String" line,
+// "line,x,align,flags,text" where align is -1 (left), 0 (center) or
+// 1 (right) and ${PAGE} in text is the page number.
+func parseTrelbyHeaderString(s string, left float64, right float64) (int, Paragraph, error) {
+	parts := strings.SplitN(s, ",", 5)
+	if len(parts) != 5 {
+		return 0, Paragraph{}, fmt.Errorf("malformed header string %q", s)
+	}
+	line, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, Paragraph{}, fmt.Errorf("malformed header string line %q", parts[0])
+	}
+	alignment := RightAlignment
+	switch parts[2] {
+	case "-1":
+		alignment = LeftAlignment
+	case "0":
+		alignment = CenterAlignment
+	}
+	paragraph := headerParagraph(parts[4], TrelbyPageNo, alignment, left, right)
+	if style := trelbyStyle(parts[3]); style != "" {
+		for _, text := range paragraph.Text {
+			text.Style = style
+		}
+	}
+	return line, paragraph, nil
+}
+
+// trelbyTitlePage converts Trelby title pages to a Final Draft title
+// page. Strings are placed by their vertical position, the first
+// paragraph of each following page starts a new page. top is the top
+// margin in inches.
+func trelbyTitlePage(pages [][]*trelbyTitleString, top float64) *TitlePage {
+	tp := new(TitlePage)
+	tp.Content = new(Content)
+	for i, page := range pages {
+		sort.SliceStable(page, func(a, b int) bool { return page[a].Y < page[b].Y })
+		y := 0
+		for j, ts := range page {
+			line := int(math.Round((ts.Y/MillimetersPerInch - top) * 6))
+			paragraph := new(Paragraph)
+			paragraph.Alignment = LeftAlignment
+			paragraph.LeftIndent = fmt.Sprintf("%.2f", ts.X/MillimetersPerInch)
+			switch {
+			case strings.Contains(ts.Flags, "c"):
+				paragraph.Alignment = CenterAlignment
+				paragraph.LeftIndent = "1.25"
+				paragraph.RightIndent = "7.25"
+			case strings.Contains(ts.Flags, "r"):
+				paragraph.Alignment = RightAlignment
+				paragraph.LeftIndent = "1.25"
+				paragraph.RightIndent = fmt.Sprintf("%.2f", ts.X/MillimetersPerInch)
+			}
+			if line > y {
+				paragraph.SpaceBefore = strconv.Itoa((line - y) * PointsPerLine)
+				y = line
+			}
+			if i > 0 && j == 0 {
+				paragraph.StartsNewPage = "Yes"
+			}
+			text := new(Text)
+			text.Style = trelbyStyle(ts.Flags)
+			text.InnerText = strings.Join(ts.Items, "\n")
+			paragraph.Text = []*Text{text}
+			tp.Content.Paragraph = append(tp.Content.Paragraph, paragraph)
+			y += len(ts.Items)
+		}
+	}
+	return tp
+}
+
+// trelbyTitleStrings converts a Final Draft title page to Trelby title
+// pages. top is the top margin in inches.
+func trelbyTitleStrings(tp *TitlePage, top float64) [][]*trelbyTitleString {
+	pages := [][]*trelbyTitleString{{}}
+	if tp == nil || tp.Content == nil {
+		return pages
+	}
+	y := 0
+	for i, paragraph := range tp.Content.Paragraph {
+		if paragraph.StartsNewPage == "Yes" && i > 0 {
+			pages = append(pages, []*trelbyTitleString{})
+			y = 0
+		}
+		n := paragraph.lines()
+		lines := strings.Split(paragraph.PlainText(), "\n")
+		start := y + n - len(lines)
+		y += n
+		if strings.TrimSpace(paragraph.PlainText()) == "" {
+			continue
+		}
+		ts := new(trelbyTitleString)
+		ts.Y = (top + float64(start)/6) * MillimetersPerInch
+		ts.Size, ts.Font, ts.Items = "12", "Courier", lines
+		switch paragraph.Alignment {
+		case CenterAlignment:
+			ts.Flags = "c"
+		case RightAlignment:
+			ts.Flags = "r"
+			ts.X = measure(paragraph.RightIndent, 7.5) * MillimetersPerInch
+		default:
+			ts.X = measure(paragraph.LeftIndent, 1.5) * MillimetersPerInch
+		}
+		if len(paragraph.Text) > 0 {
+			ts.Flags += trelbyStyleFlags(paragraph.Text[0].Style)
+		}
+		pages[len(pages)-1] = append(pages[len(pages)-1], ts)
+	}
+	return pages
+}
+
+// ParseTrelby takes []byte holding a Trelby (.trelby) file and returns
+// a FinalDraft struct and error. Elements become paragraphs (notes
+// and act breaks use NoteType and ActBreakType), title pages become
+// the title page and header strings the page header. Trelby's config,
+// auto completion, location and spell checker sections are skipped.
+func ParseTrelby(src []byte) (*FinalDraft, error) {
+	src = bytes.TrimPrefix(src, []byte("\xef\xbb\xbf"))
+	lines := strings.Split(strings.ReplaceAll(string(src), "\r\n", "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "#Version ") {
+		return nil, fmt.Errorf("not a Trelby file, missing #Version")
+	}
+	document := NewFinalDraft()
+	document.Content = new(Content)
+	pageWidth, _ := document.PageSize()
+	general := document.ElementSetting(GeneralType).ParagraphSpec
+	left := measure(general.LeftIndent, 1.5)
+	right := pageWidth - measure(general.RightIndent, pageWidth-1)
+	top, _, _, _ := document.margins()
+
+	titlePages := [][]*trelbyTitleString{{}}
+	headers := map[int]Paragraph{}
+	inScript := false
+	var (
+		paragraph *Paragraph
+		text      strings.Builder
+	)
+	for i, line := range lines[1:] {
+		lineNo := i + 2
+		if !inScript {
+			key, value := line, ""
+			if j := strings.Index(line, " "); j >= 0 {
+				key, value = line[0:j], line[j+1:]
+			}
+			switch key {
+			case "#Start-Script":
+				inScript = true
+			case "#Title-Page":
+				titlePages = append(titlePages, []*trelbyTitleString{})
+			case "#Title-String":
+				ts, err := parseTrelbyTitleString(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d, %s", lineNo, err)
+				}
+				titlePages[len(titlePages)-1] = append(titlePages[len(titlePages)-1], ts)
+			case "#Header-String":
+				n, header, err := parseTrelbyHeaderString(value, left, right)
+				if err != nil {
+					return nil, fmt.Errorf("line %d, %s", lineNo, err)
+				}
+				headers[n] = header
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if len(line) < 2 {
+			return nil, fmt.Errorf("line %d, malformed script line %q", lineNo, line)
+		}
+		join, ok := trelbyBreaks[line[0]]
+		if !ok && line[0] != '.' {
+			return nil, fmt.Errorf("line %d, unknown line break %q", lineNo, line[0:1])
+		}
+		elementType, ok := trelbyTypes[line[1]]
+		if !ok {
+			return nil, fmt.Errorf("line %d, unknown element type %q", lineNo, line[1:2])
+		}
+		if paragraph == nil {
+			paragraph = new(Paragraph)
+			paragraph.Type = elementType
+			text.Reset()
+		}
+		text.WriteString(line[2:])
+		if line[0] == '.' {
+			paragraph.Text = []*Text{{InnerText: text.String()}}
+			document.Content.Paragraph = append(document.Content.Paragraph, paragraph)
+			paragraph = nil
+		} else {
+			text.WriteString(join)
+		}
+	}
+	if !inScript {
+		return nil, fmt.Errorf("not a Trelby file, missing #Start-Script")
+	}
+	if paragraph != nil {
+		// The last paragraph wasn't ended
+		paragraph.Text = []*Text{{InnerText: text.String()}}
+		document.Content.Paragraph = append(document.Content.Paragraph, paragraph)
+	}
+
+	if len(titlePages) > 1 || len(titlePages[0]) > 0 {
+		document.TitlePage = trelbyTitlePage(titlePages, top/PointsPerInch)
+	}
+	if len(headers) > 0 {
+		document.HeaderAndFooter = new(HeaderAndFooter)
+		document.HeaderAndFooter.HeaderVisible = "Yes"
+		document.HeaderAndFooter.HeaderFirstPage = "No"
+		document.HeaderAndFooter.FooterVisible = "No"
+		keys := []int{}
+		for n := range headers {
+			keys = append(keys, n)
+		}
+		sort.Ints(keys)
+		for _, n := range keys {
+			document.HeaderAndFooter.Header.Paragraph = append(document.HeaderAndFooter.Header.Paragraph, headers[n])
+		}
+	}
+	return document, nil
+}
+
+// ParseTrelbyFile takes a filename of a Trelby file and returns a
+// FinalDraft struct and error
+func ParseTrelbyFile(fname string) (*FinalDraft, error) {
+	src, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrelby(src)
+}
+
+// trelbyLines word wraps a paragraph into Trelby script lines
+func (document *FinalDraft) trelbyLines(paragraph *Paragraph) []string {
+	settings := document.ElementSetting(paragraph.Type)
+	spec := settings.ParagraphSpec
+	s := paragraph.PlainText()
+	if settings.FontSpec != nil && strings.Contains(settings.FontSpec.Style, AllCapsStyle) {
+		s = strings.ToUpper(s)
+	}
+	width := columns(measure(spec.RightIndent, 7.5) - measure(spec.LeftIndent, 1.5))
+	runes := []rune(s)
+	ranges := wrapRanges(runes, width, width)
+	lt := string(trelbyTypeChar(paragraph.Type))
+	lines := []string{}
+	for i, r := range ranges {
+		lb := "."
+		if i+1 < len(ranges) {
+			gap := string(runes[r[1]:ranges[i+1][0]])
+			switch {
+			case strings.Contains(gap, "\n"):
+				lb = "|"
+			case gap == "":
+				lb = "&"
+			case gap == "  ":
+				lb = "+"
+			default:
+				lb = ">"
+			}
+		}
+		lines = append(lines, lb+lt+string(runes[r[0]:r[1]]))
+	}
+	return lines
+}
+
+// ToTrelby writes the document in Trelby's native (.trelby) format.
+// Paragraphs are word wrapped to their element's width, dual dialogue
+// is written as consecutive dialogue and types Trelby lacks (e.g.
+// General, Cast List) are written as action.
+func (document *FinalDraft) ToTrelby(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	out.WriteString("\xef\xbb\xbf#Version " + TrelbyVersion + "\n")
+	for _, section := range []string{"Auto-Completion", "Config", "Locations", "Spell-Checker-Dict"} {
+		out.WriteString("#Begin-" + section + " \n#End-" + section + " \n")
+	}
+	top, _, _, _ := document.margins()
+	for i, page := range trelbyTitleStrings(document.TitlePage, top/PointsPerInch) {
+		if i > 0 {
+			out.WriteString("#Title-Page \n")
+		}
+		for _, ts := range page {
+			out.WriteString("#Title-String " + ts.String() + "\n")
+		}
+	}
+	if hf := document.HeaderAndFooter; hf != nil && hf.HeaderVisible == "Yes" {
+		for i := range hf.Header.Paragraph {
+			header := &hf.Header.Paragraph[i]
+			align := "1"
+			switch header.Alignment {
+			case LeftAlignment:
+				align = "-1"
+			case CenterAlignment:
+				align = "0"
+			}
+			flags := ""
+			if len(header.Text) > 0 {
+				flags = trelbyStyleFlags(header.Text[0].Style)
+			}
+			out.WriteString(fmt.Sprintf("#Header-String %d,0,%s,%s,%s\n", i+1, align, flags, header.headerTemplate(TrelbyPageNo)))
+		}
+		out.WriteString("#Header-Empty-Lines 1\n")
+	}
+	out.WriteString("#Start-Script \n")
+	if document.Content != nil {
+		for _, paragraph := range document.Content.Paragraph {
+			paragraphs := []*Paragraph{paragraph}
+			if paragraph.DualDialogue != nil {
+				paragraphs = paragraph.DualDialogue.Paragraph
+			}
+			for _, p := range paragraphs {
+				for _, line := range document.trelbyLines(p) {
+					out.WriteString(line + "\n")
+				}
+			}
+		}
+	}
+	return out.Flush()
+}